@@ -32,9 +32,23 @@ var (
 	ErrNoSuchResource = errors.New("api: resource doesn't exist")
 	// ErrNoSuchAsset indicates that the asset does not exist
 	ErrNoSuchAsset = errors.New("api: asset doesn't exist")
+	// ErrNoSuchWebhook indicates that the webhook or delivery does not exist
+	ErrNoSuchWebhook = errors.New("api: webhook doesn't exist")
 	// ErrBuildFailed indicates that the feed build failed
 	ErrBuildFailed = errors.New("api: build failed")
 
+	// ErrInvalidCursor indicates that a pagination cursor could not be decoded
+	ErrInvalidCursor = errors.New("api: invalid cursor")
+
+	// ErrQuotaExceeded indicates that the owner used up the storage, egress or build quota
+	ErrQuotaExceeded = errors.New("api: quota exceeded")
+	// ErrEpisodeLimit indicates that the owner can not add more episodes
+	ErrEpisodeLimit = errors.New("api: episode limit reached")
+	// ErrRateLimited indicates that the client sent too many requests
+	ErrRateLimited = errors.New("api: rate limit exceeded")
+	// ErrRevisionConflict indicates that a resource was changed since the revision a client expected
+	ErrRevisionConflict = errors.New("api: resource was modified")
+
 	// ErrInternalError indicates that an unspecified internal error happened
 	ErrInternalError = errors.New("api: internal error")
 )
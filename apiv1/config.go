@@ -12,6 +12,8 @@ const (
 	defaultAPIEndpoint     = "https://api.podops.dev"
 	defaultCDNEndpoint     = "https://cdn.podops.dev"
 	defaultStorageEndpoint = "https://storage.googleapis.com/cdn.podops.dev"
+
+	defaultGCGracePeriod = 7 * 86400 // seconds
 )
 
 var (
@@ -35,4 +37,19 @@ var (
 
 	// StorageEndpoint is the direct link to assets in Google Storage
 	StorageEndpoint string = env.GetString("STORAGE_ENDPOINT", defaultStorageEndpoint)
+
+	// QuotaStorage is the default limit of stored assets in bytes of all productions of an owner, 0 = unlimited
+	QuotaStorage int64 = env.GetInt("QUOTA_STORAGE", 0)
+
+	// QuotaEgress is the default monthly limit of bytes served by the CDN, 0 = unlimited
+	QuotaEgress int64 = env.GetInt("QUOTA_EGRESS", 0)
+
+	// QuotaEpisodes is the default limit of episodes of all productions of an owner, 0 = unlimited
+	QuotaEpisodes int64 = env.GetInt("QUOTA_EPISODES", 0)
+
+	// QuotaBuilds is the default monthly limit of builds, 0 = unlimited
+	QuotaBuilds int64 = env.GetInt("QUOTA_BUILDS", 0)
+
+	// GCGracePeriod is the minimum age in seconds of an unreferenced asset before it is garbage collected
+	GCGracePeriod int64 = env.GetInt("GC_GRACE_PERIOD", defaultGCGracePeriod)
 )
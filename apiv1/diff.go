@@ -0,0 +1,167 @@
+package apiv1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// DiffAdded indicates an attribute that only exists in the new resource
+	DiffAdded = "+"
+	// DiffRemoved indicates an attribute that only exists in the old resource
+	DiffRemoved = "-"
+	// DiffChanged indicates an attribute with different values
+	DiffChanged = "~"
+)
+
+type (
+	// Difference describes a single attribute that differs between two resources
+	Difference struct {
+		Type string // one of DiffAdded, DiffRemoved, DiffChanged
+		Path string // the attribute's path using the .yaml names, e.g. 'metadata.labels.date'
+		From string
+		To   string
+	}
+)
+
+// Diff compares two resources of the same kind, e.g. two shows, attribute by attribute.
+// Nil and empty values are considered equal.
+func Diff(from, to interface{}) []*Difference {
+	var d []*Difference
+	diffValue(&d, "", reflect.ValueOf(from), reflect.ValueOf(to))
+	return d
+}
+
+// String returns a one-line description of the difference
+func (d *Difference) String() string {
+	switch d.Type {
+	case DiffAdded:
+		return fmt.Sprintf("%s %s: %q", d.Type, d.Path, d.To)
+	case DiffRemoved:
+		return fmt.Sprintf("%s %s: %q", d.Type, d.Path, d.From)
+	}
+	return fmt.Sprintf("%s %s: %q -> %q", d.Type, d.Path, d.From, d.To)
+}
+
+func diffValue(d *[]*Difference, path string, from, to reflect.Value) {
+	from = indirect(from)
+	to = indirect(to)
+
+	if !from.IsValid() && !to.IsValid() {
+		return
+	}
+	if !from.IsValid() {
+		diffValue(d, path, reflect.Zero(to.Type()), to)
+		return
+	}
+	if !to.IsValid() {
+		diffValue(d, path, from, reflect.Zero(from.Type()))
+		return
+	}
+
+	switch from.Kind() {
+	case reflect.Struct:
+		t := from.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // unexported
+			}
+			diffValue(d, joinPath(path, fieldName(f)), from.Field(i), to.Field(i))
+		}
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, k := range from.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		for _, k := range to.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		names := make([]string, 0, len(keys))
+		for n := range keys {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		for _, n := range names {
+			diffEntry(d, joinPath(path, n), from.MapIndex(keys[n]), to.MapIndex(keys[n]))
+		}
+	case reflect.Slice, reflect.Array:
+		n := from.Len()
+		if to.Len() > n {
+			n = to.Len()
+		}
+		for i := 0; i < n; i++ {
+			var f, t reflect.Value
+			if i < from.Len() {
+				f = from.Index(i)
+			}
+			if i < to.Len() {
+				t = to.Index(i)
+			}
+			diffEntry(d, fmt.Sprintf("%s[%d]", path, i), f, t)
+		}
+	default:
+		fs := fmt.Sprint(from.Interface())
+		ts := fmt.Sprint(to.Interface())
+		if fs != ts {
+			*d = append(*d, &Difference{Type: DiffChanged, Path: path, From: fs, To: ts})
+		}
+	}
+}
+
+// diffEntry compares map or slice entries which might be missing on either side
+func diffEntry(d *[]*Difference, path string, from, to reflect.Value) {
+	from = indirect(from)
+	to = indirect(to)
+
+	if from.IsValid() && to.IsValid() {
+		diffValue(d, path, from, to)
+		return
+	}
+	if from.IsValid() && isScalar(from) {
+		*d = append(*d, &Difference{Type: DiffRemoved, Path: path, From: fmt.Sprint(from.Interface())})
+		return
+	}
+	if to.IsValid() && isScalar(to) {
+		*d = append(*d, &Difference{Type: DiffAdded, Path: path, To: fmt.Sprint(to.Interface())})
+		return
+	}
+	diffValue(d, path, from, to)
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isScalar(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	}
+	return true
+}
+
+// fieldName returns the .yaml name of a struct field
+func fieldName(f reflect.StructField) string {
+	tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if tag == "" || tag == "-" {
+		return strings.ToLower(f.Name)
+	}
+	return tag
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,76 @@
+package apiv1
+
+import (
+	"testing"
+)
+
+func TestDiffEqual(t *testing.T) {
+	s1 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+	s2 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+
+	if d := Diff(s1, s2); len(d) != 0 {
+		t.Errorf("expected no differences, got %d", len(d))
+	}
+}
+
+func TestDiffShow(t *testing.T) {
+	s1 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+	s2 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+
+	s2.Description.Title = "NEW TITLE"
+	s2.Image.URI = "cover.png"
+	s2.Image.Rel = ResourceTypeLocal
+	s2.Metadata.Labels["extra"] = "value"
+	delete(s2.Metadata.Labels, LabelBlock)
+
+	expected := map[string]string{
+		"description.title":     DiffChanged,
+		"image.uri":             DiffChanged,
+		"image.rel":             DiffChanged,
+		"metadata.labels.extra": DiffAdded,
+		"metadata.labels.block": DiffRemoved,
+	}
+
+	d := Diff(s1, s2)
+	if len(d) != len(expected) {
+		t.Errorf("expected %d differences, got %d", len(expected), len(d))
+	}
+	for _, diff := range d {
+		if expected[diff.Path] != diff.Type {
+			t.Errorf("unexpected difference '%s'", diff.String())
+		}
+	}
+}
+
+func TestDiffEpisode(t *testing.T) {
+	e1 := DefaultEpisode("NAME", "PARENT_NAME", "GUID", "PARENT_GUID", "BASE_URL", "PORTAL_URL")
+	e2 := DefaultEpisode("NAME", "PARENT_NAME", "GUID", "PARENT_GUID", "BASE_URL", "PORTAL_URL")
+
+	e2.Enclosure.Size = 42
+	e2.Description.Duration = 21
+
+	d := Diff(e1, e2)
+	if len(d) != 2 {
+		t.Fatalf("expected 2 differences, got %d", len(d))
+	}
+	if d[0].Path != "description.duration" || d[0].From != "1" || d[0].To != "21" {
+		t.Errorf("unexpected difference '%s'", d[0].String())
+	}
+	if d[1].Path != "enclosure.size" {
+		t.Errorf("unexpected difference '%s'", d[1].String())
+	}
+}
+
+func TestDiffNilAndEmpty(t *testing.T) {
+	s1 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+	s2 := DefaultShow("NAME", "TITLE", "SUMMARY", "GUID", "BASE_URL", "PORTAL_URL")
+
+	s1.Description.Category.SubCategory = nil
+	s2.Description.Category.SubCategory = []string{}
+	s2.Description.NewFeed = &Asset{URI: "https://example.com/feed.xml"}
+
+	d := Diff(s1, s2)
+	if len(d) != 1 || d[0].Path != "description.newFeed.uri" {
+		t.Errorf("expected a single difference in 'description.newFeed.uri', got %v", d)
+	}
+}
@@ -2,6 +2,7 @@ package apiv1
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/podops/podops/pkg/rss"
@@ -52,10 +53,10 @@ func TransformToPodcast(s *Show) (*rss.Channel, error) {
 	} else {
 		return nil, errors.New("Show type must be 'Episodic' or 'Serial' ")
 	}
-	if s.Metadata.Labels[LabelBlock] == "yes" {
+	if strings.EqualFold(s.Metadata.Labels[LabelBlock], "yes") {
 		pf.IBlock = "yes"
 	}
-	if s.Metadata.Labels[LabelComplete] == "yes" {
+	if strings.EqualFold(s.Metadata.Labels[LabelComplete], "yes") {
 		pf.IComplete = "yes"
 	}
 
@@ -88,11 +89,14 @@ func TransformToItem(e *Episode) (*rss.Item, error) {
 	ef.Link = e.Description.Link.URI
 	ef.ISubtitle = e.Description.Summary
 	ef.GUID = e.Metadata.Labels[LabelGUID]
+	if guid, ok := e.Metadata.Labels[LabelItemGUID]; ok && guid != "" {
+		ef.GUID = guid
+	}
 	ef.IExplicit = e.Metadata.Labels[LabelExplicit]
 	ef.ISeason = e.Metadata.Labels[LabelSeason]
 	ef.IEpisode = e.Metadata.Labels[LabelEpisode]
 	ef.IEpisodeType = e.Metadata.Labels[LabelType]
-	if e.Metadata.Labels[LabelBlock] == "yes" {
+	if e.Blocked() {
 		ef.IBlock = "yes"
 	}
 
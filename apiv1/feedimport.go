@@ -0,0 +1,205 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johngb/langreg"
+	"github.com/podops/podops/pkg/rss"
+)
+
+var (
+	nameReplaceRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+	extensionTypeMap = map[string]string{
+		".m4a":  "audio/x-m4a",
+		".m4v":  "video/x-m4v",
+		".mp4":  "video/mp4",
+		".mp3":  "audio/mpeg",
+		".mov":  "video/quicktime",
+		".pdf":  "application/pdf",
+		".epub": "document/x-epub",
+	}
+)
+
+// TransformFromPodcast converts a parsed podcast feed into a Show. Attributes missing in the feed
+// are taken from DefaultShow. All images are imported into the CDN.
+func TransformFromPodcast(ch *rss.Channel, name, guid string) *Show {
+	show := DefaultShow(name, ch.Title, ch.Description, guid, DefaultPortalEndpoint, DefaultCDNEndpoint)
+
+	if show.Description.Title == "" {
+		show.Description.Title = ch.ITitle
+	}
+	if show.Description.Summary == "" {
+		if ch.ISummary != nil && ch.ISummary.Text != "" {
+			show.Description.Summary = ch.ISummary.Text
+		} else {
+			show.Description.Summary = show.Description.Title
+		}
+	}
+	if ch.Link != "" {
+		show.Description.Link = Asset{URI: ch.Link, Rel: ResourceTypeExternal}
+	}
+	if len(ch.ICategories) > 0 && ch.ICategories[0].Text != "" {
+		show.Description.Category = Category{Name: ch.ICategories[0].Text}
+		for _, c := range ch.ICategories[0].ICategories {
+			show.Description.Category.SubCategory = append(show.Description.Category.SubCategory, c.Text)
+		}
+	} else if ch.Category != "" {
+		show.Description.Category = Category{Name: strings.Split(ch.Category, ",")[0]}
+	}
+	if ch.IOwner != nil {
+		if ch.IOwner.Name != "" {
+			show.Description.Owner.Name = ch.IOwner.Name
+		}
+		if ch.IOwner.Email != "" {
+			show.Description.Owner.Email = ch.IOwner.Email
+		}
+	}
+	if ch.IAuthor != "" {
+		show.Description.Author = ch.IAuthor
+	} else if ch.IOwner != nil && ch.IOwner.Name != "" {
+		show.Description.Author = ch.IOwner.Name
+	}
+	show.Description.Copyright = ch.Copyright
+
+	if ch.IImage != nil && ch.IImage.HREF != "" {
+		show.Image = importAsset(ch.IImage.HREF, "", 0)
+	} else if ch.Image != nil && ch.Image.URL != "" {
+		show.Image = importAsset(ch.Image.URL, "", 0)
+	}
+
+	show.Metadata.Labels[LabelLanguage] = importLanguage(ch.Language)
+	show.Metadata.Labels[LabelExplicit] = importFlag(ch.IExplicit)
+	show.Metadata.Labels[LabelBlock] = importFlag(ch.IBlock)
+	show.Metadata.Labels[LabelComplete] = importFlag(ch.IComplete)
+	if strings.ToLower(ch.IType) == "serial" {
+		show.Metadata.Labels[LabelType] = ShowTypeSerial
+	}
+
+	return show
+}
+
+// TransformFromItem converts an item of a parsed podcast feed into an Episode of show 'show'.
+// The item's guid is kept in label 'item_guid', 'index' is used if the item has no episode number.
+func TransformFromItem(i *rss.Item, name, guid string, show *Show, index int) (*Episode, error) {
+	if i.Enclosure == nil || i.Enclosure.URL == "" {
+		return nil, fmt.Errorf("item '%s' has no enclosure", i.Title)
+	}
+
+	episode := DefaultEpisode(name, show.Metadata.Name, guid, show.GUID(), DefaultPortalEndpoint, DefaultCDNEndpoint)
+
+	episode.Description.Title = i.Title
+	episode.Description.Summary = firstOf(i.Description, i.ISubtitle, i.Title)
+	summary := ""
+	if i.ISummary != nil {
+		summary = i.ISummary.Text
+	}
+	episode.Description.EpisodeText = firstOf(i.Content, summary, episode.Description.Summary)
+	if i.Link != "" {
+		episode.Description.Link = Asset{URI: i.Link, Rel: ResourceTypeExternal}
+	}
+	if d, err := rss.ParseDuration(i.IDuration); err == nil && d > 0 {
+		episode.Description.Duration = int(d)
+	}
+
+	if i.IImage != nil && i.IImage.HREF != "" {
+		episode.Image = importAsset(i.IImage.HREF, "", 0)
+	} else {
+		episode.Image = show.Image
+	}
+	episode.Enclosure = importAsset(i.Enclosure.URL, i.Enclosure.TypeFormatted, int(i.Enclosure.Length))
+	if i.Transcript != "" {
+		episode.Transcript = &Asset{URI: i.Transcript, Rel: ResourceTypeExternal}
+	}
+
+	labels := episode.Metadata.Labels
+	if i.PubDate != nil {
+		labels[LabelDate] = i.PubDate.Format(time.RFC1123Z)
+	}
+	if i.GUID != "" {
+		labels[LabelItemGUID] = i.GUID
+	}
+	if _, err := strconv.Atoi(i.ISeason); err == nil {
+		labels[LabelSeason] = i.ISeason
+	}
+	if _, err := strconv.Atoi(i.IEpisode); err == nil {
+		labels[LabelEpisode] = i.IEpisode
+	} else {
+		labels[LabelEpisode] = strconv.Itoa(index)
+	}
+	labels[LabelExplicit] = importFlag(i.IExplicit)
+	labels[LabelBlock] = importFlag(i.IBlock)
+	switch strings.ToLower(i.IEpisodeType) {
+	case "trailer":
+		labels[LabelType] = EpisodeTypeTrailer
+	case "bonus":
+		labels[LabelType] = EpisodeTypeBonus
+	}
+
+	return episode, nil
+}
+
+// ImportName derives a resource name from a title, e.g. 'My Show!' becomes 'my-show'
+func ImportName(title string) string {
+	name := strings.Trim(nameReplaceRegex.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(name) > 40 {
+		name = strings.TrimRight(name[:40], "-")
+	}
+	return name
+}
+
+// importAsset returns an asset that will be imported into the CDN
+func importAsset(uri, mimeType string, size int) Asset {
+	if _, ok := mediaTypeMap[mimeType]; !ok {
+		if u, err := url.Parse(uri); err == nil {
+			if t, ok := extensionTypeMap[strings.ToLower(path.Ext(u.Path))]; ok {
+				mimeType = t
+			}
+		}
+	}
+	return Asset{
+		URI:  uri,
+		Rel:  ResourceTypeImport,
+		Type: mimeType,
+		Size: size,
+	}
+}
+
+// importLanguage converts e.g. 'en-us' into 'en_US' and falls back to 'en_US' for unknown codes
+func importLanguage(lang string) string {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(lang), "-", "_", 1), "_")
+	code := strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		code = code + "_" + strings.ToUpper(parts[1])
+		if langreg.IsValidLangRegCode(code) {
+			return code
+		}
+	} else if langreg.IsValidLangRegCode(code + "_" + strings.ToUpper(code)) {
+		return code // same rule as in AssertISO639
+	}
+	return "en_US"
+}
+
+// importFlag maps the different spellings of itunes:explicit, itunes:block etc. to 'yes' or 'no'
+func importFlag(flag string) string {
+	switch strings.ToLower(strings.TrimSpace(flag)) {
+	case "yes", "true", "explicit":
+		return "yes"
+	}
+	return "no"
+}
+
+func firstOf(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
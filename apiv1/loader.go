@@ -27,6 +27,9 @@ func init() {
 func LoadResource(data []byte) (interface{}, string, string, error) {
 
 	r, err := LoadResourceMetadata(data)
+	if err != nil {
+		return nil, "", "", err
+	}
 	loader := resourceLoaders[r.Kind]
 	if loader == nil {
 		return nil, "", "", fmt.Errorf("unsupported resource '%s'", r.Kind)
@@ -9,9 +9,16 @@ type (
 		Title     string `json:"title"`
 		Summary   string `json:"summary"`
 		BuildDate int64  `json:"build_date"`
+		NextBuild int64  `json:"next_build,omitempty"` // publish date of the next scheduled episode
+		BuildID   string `json:"build_id,omitempty"`   // the feed snapshot that is currently published
+		// feed migration
+		State      string `json:"state,omitempty"`        // ProductionStateMoved if the show moved to another host
+		NewFeedURL string `json:"new_feed_url,omitempty"` // the feed's new location, if moved
+		OldFeedURL string `json:"old_feed_url,omitempty"` // the feed's location before it was imported
 		// internal
-		Created int64 `json:"-"`
-		Updated int64 `json:"-"`
+		FeedAlias string `json:"-"` // normalized OldFeedURL, used to find a production on inbound requests
+		Created   int64  `json:"-"`
+		Updated   int64  `json:"-"`
 	}
 
 	// ProductionList returns a list of productions
@@ -30,14 +37,23 @@ type (
 		Title     string `json:"title"`
 		Summary   string `json:"summary"`
 		Published int64  `json:"published"`
-		Index     int    `json:"index"`  // A running number that can be used to sort resources, e.g. episode number
-		Extra1    string `json:"extra1"` // These two attributes are just placeholders for any kind of resource type specific data.
-		Extra2    string `json:"extra2"` // One possible use is to e.g. store the URL of an episodes media file here.
+		Status    string `json:"status,omitempty"` // lifecycle status of an episode, see Episode.PublishStatus()
+		Index     int    `json:"index"`            // A running number that can be used to sort resources, e.g. episode number
+		Extra1    string `json:"extra1"`           // These two attributes are just placeholders for any kind of resource type specific data.
+		Extra2    string `json:"extra2"`           // One possible use is to e.g. store the URL of an episodes media file here.
+		// Episode labels used to filter episodes
+		Season      int    `json:"season,omitempty"`
+		EpisodeType string `json:"episode_type,omitempty"` // Full, Trailer or Bonus
+		Explicit    bool   `json:"explicit,omitempty"`
 		// Media metadata used for e.g. .mp3/.png
 		Image       string `json:"image"` // Full URL to the show/episode image
 		ContentType string `json:"content_type"`
 		Duration    int64  `json:"duration"`
 		Size        int64  `json:"size"`
+		Checksum    string `json:"checksum"` // MD5 of the asset, hex encoded
+		// Revision history of shows and episodes
+		Revision  int    `json:"revision,omitempty"`
+		UpdatedBy string `json:"updated_by,omitempty"` // client that created the current revision
 		// internal
 		Created int64 `json:"-"`
 		Updated int64 `json:"-"`
@@ -53,6 +69,24 @@ type (
 		GUID         string `json:"guid" binding:"required"`
 		FeedURL      string `json:"feed"`
 		FeedAliasURL string `json:"alias"`
+		BuildID      string `json:"build_id,omitempty"`
+	}
+
+	// FeedSnapshot is an immutable copy of a generated feed.xml
+	FeedSnapshot struct {
+		BuildID  string `json:"build_id"`
+		GUID     string `json:"guid"`     // the production
+		Checksum string `json:"checksum"` // MD5 of the feed, hex encoded
+		Location string `json:"location"`
+		Size     int64  `json:"size"`
+		Episodes int    `json:"episodes"`
+		Created  int64  `json:"created"`
+	}
+
+	// FeedSnapshotList returns a list of feed snapshots
+	FeedSnapshotList struct {
+		Active    string          `json:"active"` // BuildID of the published feed
+		Snapshots []*FeedSnapshot `json:"snapshots"`
 	}
 
 	// Import is used by the import task
@@ -61,6 +95,230 @@ type (
 		Dest   string `json:"dest" binding:"required"`
 	}
 
+	// Revision is a previous version of a show or episode
+	Revision struct {
+		GUID       string `json:"guid"` // the resource
+		ParentGUID string `json:"parent_guid"`
+		Kind       string `json:"kind"`
+		Revision   int    `json:"revision"`
+		Location   string `json:"location"` // path to the .yaml of this revision
+		ClientID   string `json:"client_id"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+
+	// RevisionList returns a list of revisions
+	RevisionList struct {
+		Current   int         `json:"current"`
+		Revisions []*Revision `json:"revisions"`
+	}
+
+	// Rollback restores a previous revision of a resource
+	Rollback struct {
+		Revision int `json:"revision" binding:"required"`
+	}
+
+	// StatusChange requests a new lifecycle status for an episode
+	StatusChange struct {
+		Status string `json:"status" binding:"required"`
+	}
+
+	// AuditEntry records who changed the status of a resource and when
+	AuditEntry struct {
+		GUID       string `json:"guid"` // the resource
+		ParentGUID string `json:"parent_guid"`
+		From       string `json:"from"`
+		To         string `json:"to"`
+		ClientID   string `json:"client_id"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+
+	// AuditList returns a list of audit entries
+	AuditList struct {
+		Entries []*AuditEntry `json:"entries"`
+	}
+
+	// FeedImport is used to create a production from an existing podcast feed
+	FeedImport struct {
+		URL      string `json:"url" binding:"required"`
+		Name     string `json:"name"`
+		GUID     string `json:"guid,omitempty"`
+		Episodes int    `json:"episodes,omitempty"`
+	}
+
+	// FeedMigration moves a production to another host or registers the feed's previous location
+	FeedMigration struct {
+		GUID       string `json:"guid" binding:"required"`
+		NewFeedURL string `json:"new_feed_url,omitempty"` // move the show to this URL
+		OldFeedURL string `json:"old_feed_url,omitempty"` // redirect requests for this URL to the show
+		Cancel     bool   `json:"cancel,omitempty"`       // revert a move
+	}
+
+	// FsckIssue is an inconsistency between the inventory and the storage buckets
+	FsckIssue struct {
+		Issue    string `json:"issue"` // FsckOrphanedObject, FsckDanglingEntry, ...
+		GUID     string `json:"guid,omitempty"`
+		Name     string `json:"name,omitempty"`
+		Location string `json:"location"`
+		Message  string `json:"message"`
+		Repaired bool   `json:"repaired"`
+	}
+
+	// FsckReport lists all inconsistencies found in a production
+	FsckReport struct {
+		GUID      string       `json:"guid"`
+		Resources int          `json:"resources"` // number of inventory entries checked
+		Objects   int          `json:"objects"`   // number of files checked
+		Issues    []*FsckIssue `json:"issues"`
+	}
+
+	// GCAsset is an asset that no show or episode references
+	GCAsset struct {
+		GUID      string `json:"guid,omitempty"` // empty if the asset has no inventory entry
+		Name      string `json:"name"`
+		Location  string `json:"location"`
+		Size      int64  `json:"size"`
+		Updated   int64  `json:"updated"`
+		Collected bool   `json:"collected"` // false during a dry run or within the grace period
+	}
+
+	// GCReport lists the unreferenced assets of a production
+	GCReport struct {
+		GUID        string     `json:"guid"`
+		GracePeriod int64      `json:"grace_period"` // seconds
+		DryRun      bool       `json:"dry_run"`
+		Referenced  int        `json:"referenced"` // number of assets in use
+		Assets      []*GCAsset `json:"assets"`
+		Size        int64      `json:"size"` // bytes collected, or collectable during a dry run
+	}
+
+	// Progress reports how far a build or an asset import got
+	Progress struct {
+		Kind      string `json:"kind"`               // ProgressBuild or ProgressImport
+		GUID      string `json:"guid"`               // the production
+		Resource  string `json:"resource,omitempty"` // the imported asset's source
+		Stage     string `json:"stage"`              // StageStarted, ..., StageDone or StageFailed
+		Current   int64  `json:"current"`            // episodes read or bytes transferred
+		Total     int64  `json:"total"`              // 0 if unknown
+		Error     string `json:"error,omitempty"`    // set if Stage == StageFailed
+		BuildID   string `json:"build_id,omitempty"` // set if a build is done
+		Timestamp int64  `json:"timestamp"`
+	}
+
+	// Webhook subscribes a URL to the events of a production
+	Webhook struct {
+		ID      string   `json:"id"`
+		GUID    string   `json:"guid"` // the production
+		URL     string   `json:"url" binding:"required"`
+		Events  []string `json:"events"`           // EventEpisodeCreated, ..., all events if empty
+		Secret  string   `json:"secret,omitempty"` // the HMAC-SHA256 key, only returned when the webhook is created
+		Active  bool     `json:"active"`
+		Created int64    `json:"created"`
+		Updated int64    `json:"updated"`
+	}
+
+	// WebhookList returns the webhooks of a production
+	WebhookList struct {
+		Webhooks []*Webhook `json:"webhooks"`
+	}
+
+	// WebhookEvent is the JSON payload POSTed to a webhook
+	WebhookEvent struct {
+		ID        string `json:"id"` // unique, replays keep the ID
+		Event     string `json:"event"`
+		GUID      string `json:"guid"`               // the production
+		Resource  string `json:"resource,omitempty"` // the GUID of the episode or asset
+		Name      string `json:"name,omitempty"`     // the name of the episode or asset
+		BuildID   string `json:"build_id,omitempty"`
+		URL       string `json:"url,omitempty"`   // the feed, episode or asset
+		Error     string `json:"error,omitempty"` // why a build failed
+		Timestamp int64  `json:"timestamp"`
+	}
+
+	// WebhookDelivery records the delivery of an event to a webhook
+	WebhookDelivery struct {
+		ID          string `json:"id"`
+		WebhookID   string `json:"webhook_id"`
+		GUID        string `json:"guid"`
+		Event       string `json:"event"`
+		EventID     string `json:"event_id"`
+		Payload     string `json:"payload" datastore:",noindex"`
+		Attempts    int    `json:"attempts"`
+		Status      int    `json:"status"` // the HTTP status of the last attempt, 0 if there was no response
+		Error       string `json:"error,omitempty"`
+		Delivered   bool   `json:"delivered"`
+		NextAttempt int64  `json:"next_attempt,omitempty"` // 0 if delivered or given up
+		Created     int64  `json:"created"`
+		Updated     int64  `json:"updated"`
+	}
+
+	// WebhookDeliveryList returns the recent deliveries of a webhook, the latest first
+	WebhookDeliveryList struct {
+		Deliveries []*WebhookDelivery `json:"deliveries"`
+	}
+
+	// Subscription is a verified subscriber of the WebSub hub
+	Subscription struct {
+		ID           string `json:"id"`
+		GUID         string `json:"guid"`  // the production
+		Topic        string `json:"topic"` // the feed URL the subscriber used
+		Callback     string `json:"callback"`
+		Secret       string `json:"-" datastore:",noindex"`
+		LeaseSeconds int64  `json:"lease_seconds"`
+		Expires      int64  `json:"expires"`
+		Created      int64  `json:"created"`
+		Updated      int64  `json:"updated"`
+	}
+
+	// SearchResult is a show or episode that matches a search query
+	SearchResult struct {
+		GUID       string            `json:"guid"`
+		Kind       string            `json:"kind"`
+		Name       string            `json:"name"`
+		ParentGUID string            `json:"parent_guid"`
+		Show       string            `json:"show"` // name of the show
+		Title      string            `json:"title"`
+		Summary    string            `json:"summary"`
+		Published  int64             `json:"published,omitempty"`
+		Score      float64           `json:"score"`
+		Highlights map[string]string `json:"highlights,omitempty"` // title, summary or text with the matches wrapped in <em>
+		Cursor     string            `json:"cursor"`               // the cursor of the results that follow
+	}
+
+	// SearchResults returns a page of search results, the best matches first
+	SearchResults struct {
+		Query     string          `json:"query"`
+		Results   []*SearchResult `json:"results"`
+		Cursor    string          `json:"cursor,omitempty"` // the cursor of the next page
+		More      bool            `json:"more"`
+		Truncated bool            `json:"truncated"` // only some of the matches were ranked, a more specific query finds the others
+	}
+
+	// Usage is the resource consumption of a production, or of all productions of an owner
+	Usage struct {
+		GUID     string `json:"guid,omitempty"`
+		Month    string `json:"month"`    // YYYY-MM
+		Storage  int64  `json:"storage"`  // bytes of all assets
+		Egress   int64  `json:"egress"`   // bytes served by the CDN this month
+		Episodes int64  `json:"episodes"` // number of episodes
+		Builds   int64  `json:"builds"`   // builds this month
+	}
+
+	// Quota limits the resource consumption of all productions of an owner. 0 = unlimited.
+	Quota struct {
+		Owner    string `json:"owner" binding:"required"`
+		Storage  int64  `json:"storage"`
+		Egress   int64  `json:"egress"` // per month
+		Episodes int64  `json:"episodes"`
+		Builds   int64  `json:"builds"` // per month
+	}
+
+	// UsageReport compares the usage of a production and its owner with the owner's quota
+	UsageReport struct {
+		Production *Usage `json:"production"`
+		Owner      *Usage `json:"owner"` // all productions of the owner
+		Quota      *Quota `json:"quota"`
+	}
+
 	// AuthorizationRequest struct is used to request a token
 	// Imported from https://github.com/txsvc/service/blob/main/pkg/auth/types.go
 	AuthorizationRequest struct {
@@ -2,6 +2,9 @@ package apiv1
 
 import (
 	"fmt"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +24,7 @@ const (
 	//
 	//	episode:
 	//		guid:		<unique id> 'item.guid'
+	//		item_guid:	<guid> OPTIONAL 'item.guid' overrides guid, e.g. to keep the guid of an imported episode
 	//		date:		<publish date> REQUIRED 'item.pubDate'
 	//		season: 	<season number> OPTIONAL 'item.itunes.season'
 	//		episode:	<episode number> REQUIRED 'item.itunes.episode'
@@ -41,6 +45,8 @@ const (
 	LabelComplete = "complete"
 	// LabelGUID resources GUID
 	LabelGUID = "guid"
+	// LabelItemGUID overrides the item.guid of an episode, e.g. to keep the guid of an imported episode
+	LabelItemGUID = "item_guid"
 	// LabelParentGUID guid of the resources parent resource
 	LabelParentGUID = "parent_guid"
 	// LabelDate used as e.g. publish date of an episode
@@ -50,6 +56,56 @@ const (
 	// LabelEpisode positive integer 1..
 	LabelEpisode = ResourceEpisode
 
+	// EpisodeStatusDraft episode is work in progress and never part of the feed
+	EpisodeStatusDraft = "draft"
+	// EpisodeStatusScheduled episode is published but its publish date lies in the future
+	EpisodeStatusScheduled = "scheduled"
+	// EpisodeStatusPublished episode is part of the feed. This is the default.
+	EpisodeStatusPublished = "published"
+	// EpisodeStatusUnpublished episode was removed from the feed
+	EpisodeStatusUnpublished = "unpublished"
+
+	// ProductionStateMoved indicates that a show moved to another host
+	ProductionStateMoved = "moved"
+
+	// FsckOrphanedObject a file without an inventory entry
+	FsckOrphanedObject = "orphaned"
+	// FsckDanglingEntry an inventory entry without a file
+	FsckDanglingEntry = "dangling"
+	// FsckDuplicateName several inventory entries with the same name
+	FsckDuplicateName = "duplicate"
+	// FsckMismatch an inventory entry that does not match the file's size, content type or checksum
+	FsckMismatch = "mismatch"
+
+	// ProgressBuild the progress of building a feed
+	ProgressBuild = "build"
+	// ProgressImport the progress of importing an asset
+	ProgressImport = "import"
+
+	// StageStarted the build or import started
+	StageStarted = "started"
+	// StageEpisodes the build reads the episodes
+	StageEpisodes = "episodes"
+	// StageFeed the build assembles the feed
+	StageFeed = "feed"
+	// StageTransfer the import copies the asset
+	StageTransfer = "transfer"
+	// StageDone the build or import succeeded
+	StageDone = "done"
+	// StageFailed the build or import failed, see Progress.Error
+	StageFailed = "failed"
+
+	// EventEpisodeCreated an episode was added
+	EventEpisodeCreated = "episode.created"
+	// EventEpisodePublished an episode is published, either right away or once its publish date passed
+	EventEpisodePublished = "episode.published"
+	// EventBuildSucceeded a new feed was published
+	EventBuildSucceeded = "build.succeeded"
+	// EventBuildFailed a build failed
+	EventBuildFailed = "build.failed"
+	// EventAssetImported an asset was imported
+	EventAssetImported = "asset.imported"
+
 	// ShowTypeEpisodic type of podcast is episodic
 	ShowTypeEpisodic = "Episodic"
 	// ShowTypeSerial type of podcast is serial
@@ -113,6 +169,8 @@ type (
 		Description EpisodeDescription `json:"description" yaml:"description" binding:"required"` // REQUIRED
 		Image       Asset              `json:"image" yaml:"image" binding:"required"`             // REQUIRED 'item.itunes.image'
 		Enclosure   Asset              `json:"enclosure" yaml:"enclosure" binding:"required"`     // REQUIRED
+		Transcript  *Asset             `json:"transcript,omitempty" yaml:"transcript,omitempty"`  // OPTIONAL 'item.podcast:transcript'
+		Status      string             `json:"status,omitempty" yaml:"status,omitempty"`          // OPTIONAL draft | published | unpublished, default: published
 	}
 
 	// ShowDescription holds essential show metadata
@@ -192,6 +250,68 @@ func (e *Episode) ParentGUID() string {
 	return e.Metadata.Labels[LabelParentGUID]
 }
 
+// Season returns the episode's season, 0 if the label is missing
+func (e *Episode) Season() int {
+	season, _ := strconv.Atoi(e.Metadata.Labels[LabelSeason])
+	return season
+}
+
+// Explicit returns true if the episode's 'explicit' label is set to 'yes' or 'true'
+func (e *Episode) Explicit() bool {
+	explicit := e.Metadata.Labels[LabelExplicit]
+	return strings.EqualFold(explicit, "yes") || strings.EqualFold(explicit, "true")
+}
+
+// Blocked returns true if the episode's 'block' label is set to 'yes'
+func (e *Episode) Blocked() bool {
+	return strings.EqualFold(e.Metadata.Labels[LabelBlock], "yes")
+}
+
+// PublishStatus returns the lifecycle status set by the author, i.e. draft, published or unpublished.
+// Episodes without a status are published, blocked episodes are unpublished.
+func (e *Episode) PublishStatus() string {
+	if e.Status == EpisodeStatusDraft || e.Status == EpisodeStatusUnpublished {
+		return e.Status
+	}
+	if e.Blocked() {
+		return EpisodeStatusUnpublished
+	}
+	return EpisodeStatusPublished
+}
+
+// StatusAt returns the lifecycle status at time 'now'. Only published episodes are part of the feed.
+func (e *Episode) StatusAt(now int64) string {
+	return statusAt(e.PublishStatus(), e.PublishDateTimestamp(), now)
+}
+
+// StatusAt returns the lifecycle status of an episode at time 'now'
+func (r *Resource) StatusAt(now int64) string {
+	return statusAt(r.Status, r.Published, now)
+}
+
+// ValidStatusTransition verifies that an episode can change from status 'from' to 'to'
+func ValidStatusTransition(from, to string) bool {
+	switch to {
+	case EpisodeStatusPublished:
+		return from == EpisodeStatusDraft || from == EpisodeStatusUnpublished
+	case EpisodeStatusUnpublished:
+		return from == EpisodeStatusPublished || from == EpisodeStatusScheduled
+	case EpisodeStatusDraft:
+		return from == EpisodeStatusUnpublished
+	}
+	return false
+}
+
+func statusAt(status string, published, now int64) string {
+	if status == EpisodeStatusDraft || status == EpisodeStatusUnpublished {
+		return status
+	}
+	if published > now {
+		return EpisodeStatusScheduled
+	}
+	return EpisodeStatusPublished
+}
+
 // GUID is a convenience method to access the resources guid
 func (r *ResourceMetadata) GUID() string {
 	return r.Metadata.Labels[LabelGUID]
@@ -223,9 +343,19 @@ func (r *Asset) ResolveURI(cdn, parent string) string {
 // FingerprintURI is used in rewriting the URI when Rel == IMPORT
 func (r *Asset) FingerprintURI(parent string) string {
 	id := util.Checksum(r.URI)
-	parts := strings.Split(r.URI, ".")
-	if len(parts) == 0 {
-		return fmt.Sprintf("%s", id)
+	ext := ""
+	if u, err := url.Parse(r.URI); err == nil {
+		ext = path.Ext(u.Path) // ignore the query part, e.g. ep1.mp3?source=rss
 	}
-	return fmt.Sprintf("%s/%s.%s", parent, id, parts[len(parts)-1])
+	if ext == "" {
+		return fmt.Sprintf("%s/%s", parent, id)
+	}
+	return fmt.Sprintf("%s/%s%s", parent, id, ext)
+}
+
+// LegacyFingerprintURI is the location of assets imported before FingerprintURI ignored the query part of
+// the URI. 'po admin fsck --repair' moves them to their current location.
+func (r *Asset) LegacyFingerprintURI(parent string) string {
+	parts := strings.Split(r.URI, ".")
+	return fmt.Sprintf("%s/%s.%s", parent, util.Checksum(r.URI), parts[len(parts)-1])
 }
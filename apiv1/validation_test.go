@@ -33,3 +33,30 @@ func TestTemplateEpisode(t *testing.T) {
 		t.Errorf(v.AsError().Error())
 	}
 }
+
+func TestEpisodeStatus(t *testing.T) {
+	e := DefaultEpisode("NAME", "PARENT_NAME", "GUID", "PARENT_GUID", "BASE_URL", "PORTAL_URL")
+	now := e.PublishDateTimestamp()
+
+	if s := e.StatusAt(now); s != EpisodeStatusPublished {
+		t.Errorf("expected '%s', got '%s'", EpisodeStatusPublished, s)
+	}
+	if s := e.StatusAt(now - 60); s != EpisodeStatusScheduled {
+		t.Errorf("expected '%s', got '%s'", EpisodeStatusScheduled, s)
+	}
+
+	e.Metadata.Labels[LabelBlock] = "Yes"
+	if s := e.StatusAt(now); s != EpisodeStatusUnpublished {
+		t.Errorf("expected '%s', got '%s'", EpisodeStatusUnpublished, s)
+	}
+
+	e.Status = EpisodeStatusDraft
+	if s := e.StatusAt(now); s != EpisodeStatusDraft {
+		t.Errorf("expected '%s', got '%s'", EpisodeStatusDraft, s)
+	}
+
+	e.Status = "live"
+	if v := e.Validate(NewValidator(ResourceEpisode)); v.IsValid() {
+		t.Errorf("expected an invalid status")
+	}
+}
@@ -1,6 +1,9 @@
 package apiv1
 
-import "regexp"
+import (
+	"fmt"
+	"regexp"
+)
 
 var (
 	nameRegex = regexp.MustCompile(`^[a-z]+[a-z0-9_-]`)
@@ -40,6 +43,7 @@ func (s *Show) Validate(v *Validator) *Validator {
 //	Description EpisodeDescription `json:"description" yaml:"description" binding:"required"` // REQUIRED
 //	Image       Resource           `json:"image" yaml:"image" binding:"required"`             // REQUIRED 'item.itunes.image'
 //	Enclosure   Resource           `json:"enclosure" yaml:"enclosure" binding:"required"`     // REQUIRED
+//	Status      string             `json:"status,omitempty" yaml:"status,omitempty"`          // OPTIONAL
 func (e *Episode) Validate(v *Validator) *Validator {
 	v.AssertStringError(e.APIVersion, Version)
 	v.AssertStringError(e.Kind, ResourceEpisode)
@@ -57,6 +61,9 @@ func (e *Episode) Validate(v *Validator) *Validator {
 	v.Validate(&e.Description)
 	v.Validate(&e.Image)
 	v.Validate(&e.Enclosure)
+	if e.Status != "" && e.Status != EpisodeStatusDraft && e.Status != EpisodeStatusPublished && e.Status != EpisodeStatusUnpublished {
+		v.AssertError(fmt.Sprintf("Invalid status '%s'", e.Status))
+	}
 
 	return v
 }
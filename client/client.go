@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"sync"
 
 	a "github.com/podops/podops/apiv1"
 	"github.com/podops/podops/pkg/api"
@@ -26,6 +27,9 @@ type (
 		Token           string `json:"token" binding:"required"`
 		GUID            string `json:"guid" binding:"required"`
 		Namespace       string
+		// ETags of the shows/episodes this client has read or written, sent as If-Match on updates
+		Revisions map[string]string `json:"revisions,omitempty"`
+		mu        sync.Mutex
 	}
 )
 
@@ -41,7 +45,9 @@ func DefaultClient(token string) *Client {
 
 // Store persists the Client state
 func (cl *Client) Store(path string) error {
+	cl.mu.Lock()
 	config, _ := json.Marshal(cl)
+	cl.mu.Unlock()
 
 	// create the location if it does not exist
 	baseDir := filepath.Dir(path)
@@ -164,7 +170,36 @@ func (cl *Client) delete(cmd string, request interface{}) (int, error) {
 	return cl.invoke(req, nil)
 }
 
+// send is used to invoke an API method with additional request headers. It returns the response headers.
+func (cl *Client) send(method, cmd string, header http.Header, request, response interface{}) (int, http.Header, error) {
+	url := cl.ServiceEndpoint + cmd
+
+	var body io.Reader
+	if request != nil {
+		m, err := json.Marshal(&request)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		body = bytes.NewBuffer(m)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return cl.invokeWithHeader(req, response)
+}
+
 func (cl *Client) invoke(req *http.Request, response interface{}) (int, error) {
+	status, _, err := cl.invokeWithHeader(req, response)
+	return status, err
+}
+
+func (cl *Client) invokeWithHeader(req *http.Request, response interface{}) (int, http.Header, error) {
 
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	req.Header.Set("Authorization", "Bearer "+cl.Token)
@@ -175,9 +210,9 @@ func (cl *Client) invoke(req *http.Request, response interface{}) (int, error) {
 	resp, err := client.Do(req)
 	if err != nil {
 		if resp == nil {
-			return http.StatusInternalServerError, err
+			return http.StatusInternalServerError, nil, err
 		}
-		return resp.StatusCode, err
+		return resp.StatusCode, resp.Header, err
 	}
 
 	defer resp.Body.Close()
@@ -189,9 +224,9 @@ func (cl *Client) invoke(req *http.Request, response interface{}) (int, error) {
 			status := &a.StatusObject{}
 			err = json.NewDecoder(resp.Body).Decode(&status)
 			if err != nil {
-				return resp.StatusCode, fmt.Errorf("status: %d", resp.StatusCode)
+				return resp.StatusCode, resp.Header, fmt.Errorf("status: %d", resp.StatusCode)
 			}
-			return status.Status, fmt.Errorf(status.Message)
+			return status.Status, resp.Header, fmt.Errorf(status.Message)
 		}
 	}
 
@@ -199,11 +234,11 @@ func (cl *Client) invoke(req *http.Request, response interface{}) (int, error) {
 	if response != nil {
 		err = json.NewDecoder(resp.Body).Decode(response)
 		if err != nil {
-			return http.StatusInternalServerError, err
+			return http.StatusInternalServerError, resp.Header, err
 		}
 	}
 
-	return resp.StatusCode, nil
+	return resp.StatusCode, resp.Header, nil
 }
 
 // FIXME this implementation does not work for VERY large files !
@@ -235,3 +270,38 @@ func (cl *Client) fileUploadRequest(uri, guid, path string) (*http.Request, erro
 
 	return req, err
 }
+
+// etag returns the last known ETag of a resource
+func (cl *Client) etag(guid string) string {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.Revisions[guid]
+}
+
+// setETag remembers the ETag of a resource, an empty ETag forgets it
+func (cl *Client) setETag(guid, etag string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if etag == "" {
+		delete(cl.Revisions, guid)
+		return
+	}
+	if cl.Revisions == nil {
+		cl.Revisions = make(map[string]string)
+	}
+	cl.Revisions[guid] = etag
+}
+
+// SetRevision remembers the revision of a resource, e.g. from a resource listing
+func (cl *Client) SetRevision(guid string, revision int) {
+	cl.setETag(guid, api.ETag(revision))
+}
+
+// ifMatch returns the If-Match header for updates of a resource
+func (cl *Client) ifMatch(guid string, force bool) http.Header {
+	header := http.Header{}
+	if etag := cl.etag(guid); etag != "" && !force {
+		header.Set("If-Match", etag)
+	}
+	return header
+}
@@ -2,32 +2,74 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 
 	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/pkg/api"
 )
 
 const (
 	// AuthenticationRoute is used to verify a token
 	authenticationRoute = "/_a/token"
 
+	// gcRoute route to call GCEndpoint
+	gcRoute = "/gc/%s"
+	// fsckRoute route to call FsckEndpoint
+	fsckRoute = "/_a/fsck/%s"
+	// usageRoute route to call UsageEndpoint
+	usageRoute = "/usage/%s"
+
 	// productionRoute route to call ProductionEndpoint
 	productionRoute = "/production"
 	// listProductionsRoute route to call ListProductionsEndpoint
 	listProductionsRoute = "/productions"
+	// deleteProductionRoute route to call DeleteProductionEndpoint
+	deleteProductionRoute = "/production/%s"
+	// exportProductionRoute route to call ExportProductionEndpoint
+	exportProductionRoute = "/production/%s/export"
+	// importFeedRoute route to call ImportFeedEndpoint
+	importFeedRoute = "/import"
+	// migrateRoute route to call MigrateEndpoint
+	migrateRoute = "/migrate"
 
 	// resourceRoute route to call ResourceEndpoint
 	getResourceRoute    = "/resource/%s/%s/%s"      // "/update/:prod/:kind/:id"
 	updateResourceRoute = "/resource/%s/%s/%s?f=%v" // "/update/:prod/:kind/:id"
 	listResourcesRoute  = "/resource/%s/%s"
-	deleteResourceRoute = "/resource/%s/%s/%s"
+	filterResourceRoute = "/resource/%s/%s?status=%s"
+	deleteResourceRoute = "/resource/%s/%s/%s?f=%v"
+
+	// statusRoute route to call StatusEndpoint
+	statusRoute = "/status/%s/%s"
+	// auditRoute route to call AuditEndpoint
+	auditRoute = "/audit/%s/%s"
+	// historyRoute route to call HistoryEndpoint
+	historyRoute = "/history/%s/%s"
+	// rollbackRoute route to call RollbackEndpoint
+	rollbackRoute = "/rollback/%s/%s"
 
 	// buildRoute route to call BuildEndpoint
 	buildRoute = "/build"
+	// listBuildsRoute route to call ListBuildsEndpoint
+	listBuildsRoute = "/builds/%s"
+	// restoreBuildRoute route to call RestoreBuildEndpoint
+	restoreBuildRoute = "/builds/%s/%s"
 	// uploadRoute route to UploadEndpoint
 	uploadRoute = "/upload"
+
+	// webhooksRoute route to call ListWebhooksEndpoint and CreateWebhookEndpoint
+	webhooksRoute = "/webhooks/%s"
+	// webhookRoute route to call UpdateWebhookEndpoint and DeleteWebhookEndpoint
+	webhookRoute = "/webhooks/%s/%s"
+	// webhookDeliveriesRoute route to call WebhookDeliveriesEndpoint
+	webhookDeliveriesRoute = "/webhooks/%s/%s/deliveries"
+	// webhookReplayRoute route to call ReplayDeliveryEndpoint
+	webhookReplayRoute = "/webhooks/%s/%s/replay"
 )
 
 // SetProduction sets the context of further operations
@@ -91,6 +133,148 @@ func (cl *Client) CreateProduction(name, title, summary string) (*a.Production,
 	return &resp, nil
 }
 
+// ImportFeed creates a new production from an existing podcast feed
+func (cl *Client) ImportFeed(url, name string) (*a.FeedImport, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	if url == "" {
+		return nil, fmt.Errorf("url must not be empty")
+	}
+
+	req := a.FeedImport{
+		URL:  url,
+		Name: name,
+	}
+
+	resp := a.FeedImport{}
+	_, err := cl.post(cl.Namespace+importFeedRoute, &req, &resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// MigrateFeed moves the production to another host or registers the feed's previous location
+func (cl *Client) MigrateFeed(m *a.FeedMigration) (*a.Production, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	m.GUID = cl.GUID
+	resp := a.Production{}
+	_, err := cl.post(cl.Namespace+migrateRoute, m, &resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteProduction deletes a production with all its resources, assets and feeds
+func (cl *Client) DeleteProduction(guid string) error {
+	if err := cl.HasToken(); err != nil {
+		return err
+	}
+
+	resp := a.StatusObject{}
+	_, _, err := cl.send("DELETE", cl.Namespace+fmt.Sprintf(deleteProductionRoute, guid), nil, nil, &resp)
+	return err
+}
+
+// ExportProduction writes a zip archive of everything stored for a production to w
+func (cl *Client) ExportProduction(guid string, w io.Writer) error {
+	if err := cl.HasToken(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cl.ServiceEndpoint+cl.Namespace+fmt.Sprintf(exportProductionRoute, guid), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cl.Token)
+	req.Header.Set("User-Agent", a.UserAgentString)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		status := a.StatusObject{}
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			return fmt.Errorf("error exporting '%s': %s", guid, resp.Status)
+		}
+		return fmt.Errorf(status.Message)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Fsck cross-checks the inventory of a production with its files and optionally repairs it
+func (cl *Client) Fsck(guid string, repair bool) (*a.FsckReport, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.FsckReport
+	var err error
+	if repair {
+		_, err = cl.post(fmt.Sprintf(fsckRoute, guid), nil, &resp)
+	} else {
+		_, err = cl.get(fmt.Sprintf(fsckRoute, guid), &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CollectGarbage removes assets no show or episode references, if they are older than 'grace' seconds.
+// A negative grace period uses the service's default. With dryRun == true, the assets are only listed.
+func (cl *Client) CollectGarbage(guid string, grace int64, dryRun bool) (*a.GCReport, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	route := cl.Namespace + fmt.Sprintf(gcRoute, guid)
+	if grace >= 0 {
+		route = fmt.Sprintf("%s?grace=%d", route, grace)
+	}
+
+	var resp a.GCReport
+	var err error
+	if dryRun {
+		_, err = cl.get(route, &resp)
+	} else {
+		_, err = cl.post(route, nil, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Usage retrieves the usage of a production and its owner, together with the owner's quota
+func (cl *Client) Usage(guid string) (*a.UsageReport, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.UsageReport
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(usageRoute, guid), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // Productions retrieves a list of productions
 func (cl *Client) Productions() (*a.ProductionList, error) {
 	if err := cl.HasToken(); err != nil {
@@ -112,11 +296,12 @@ func (cl *Client) CreateResource(kind, rsrcGUID string, force bool, rsrc interfa
 	}
 
 	resp := a.StatusObject{}
-	status, err := cl.post(cl.Namespace+fmt.Sprintf(updateResourceRoute, cl.GUID, kind, rsrcGUID, force), rsrc, &resp)
+	status, header, err := cl.send("POST", cl.Namespace+fmt.Sprintf(updateResourceRoute, cl.GUID, kind, rsrcGUID, force), nil, rsrc, &resp)
 
 	if err != nil {
 		return status, err
 	}
+	cl.setETag(rsrcGUID, header.Get("ETag"))
 	return status, nil
 }
 
@@ -126,29 +311,34 @@ func (cl *Client) GetResource(prod, kind, guid string, rsrc interface{}) error {
 		return err
 	}
 
-	status, err := cl.get(cl.Namespace+fmt.Sprintf(getResourceRoute, prod, kind, guid), rsrc)
-	if status == http.StatusBadRequest {
-		return fmt.Errorf("not found: '%s/%s-%s'", prod, kind, guid)
+	status, header, err := cl.send("GET", cl.Namespace+fmt.Sprintf(getResourceRoute, prod, kind, guid), nil, nil, rsrc)
+	if status == http.StatusBadRequest || status == http.StatusNotFound {
+		return fmt.Errorf("%w: '%s/%s-%s'", a.ErrNoSuchResource, prod, kind, guid)
 	}
 	if err != nil {
 		return err
 	}
+	if etag := header.Get("ETag"); etag != "" {
+		cl.setETag(guid, etag)
+	}
 
 	return nil
 }
 
-// UpdateResource invokes the ResourceEndpoint
+// UpdateResource invokes the ResourceEndpoint. The update fails with http.StatusPreconditionFailed
+// if the resource was modified since this client last read or wrote it, unless force == true.
 func (cl *Client) UpdateResource(kind, rsrcGUID string, force bool, rsrc interface{}) (int, error) {
 	if err := cl.HasTokenAndGUID(); err != nil {
 		return http.StatusBadRequest, err
 	}
 
 	resp := a.StatusObject{}
-	status, err := cl.put(cl.Namespace+fmt.Sprintf(updateResourceRoute, cl.GUID, kind, rsrcGUID, force), rsrc, &resp)
+	status, header, err := cl.send("PUT", cl.Namespace+fmt.Sprintf(updateResourceRoute, cl.GUID, kind, rsrcGUID, force), cl.ifMatch(rsrcGUID, force), rsrc, &resp)
 
 	if err != nil {
 		return status, err
 	}
+	cl.setETag(rsrcGUID, header.Get("ETag"))
 	return status, nil
 }
 
@@ -169,16 +359,92 @@ func (cl *Client) Resources(prod, kind string) (*a.ResourceList, error) {
 	return &resp, nil
 }
 
-// DeleteResource deletes a resources
-func (cl *Client) DeleteResource(prod, kind, guid string) (int, error) {
+// ResourcesWithStatus returns all episodes with lifecycle status 'status'
+func (cl *Client) ResourcesWithStatus(prod, status string) (*a.ResourceList, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.ResourceList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(filterResourceRoute, prod, a.ResourceEpisode, url.QueryEscape(status)), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChangeStatus changes the lifecycle status of an episode
+func (cl *Client) ChangeStatus(prod, guid, status string) (*a.Resource, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	req := a.StatusChange{Status: status}
+	resp := a.Resource{}
+	_, err := cl.post(cl.Namespace+fmt.Sprintf(statusRoute, prod, guid), &req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	cl.setETag(guid, api.ETag(resp.Revision))
+	return &resp, nil
+}
+
+// AuditEntries returns all status changes of a resource
+func (cl *Client) AuditEntries(prod, guid string) (*a.AuditList, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.AuditList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(auditRoute, prod, guid), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// History returns all revisions of a show or episode
+func (cl *Client) History(prod, guid string) (*a.RevisionList, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.RevisionList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(historyRoute, prod, guid), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Rollback restores a previous revision of a show or episode
+func (cl *Client) Rollback(prod, guid string, revision int) (*a.Revision, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	req := a.Rollback{Revision: revision}
+	resp := a.Revision{}
+	_, err := cl.post(cl.Namespace+fmt.Sprintf(rollbackRoute, prod, guid), &req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	cl.setETag(guid, api.ETag(resp.Revision))
+	return &resp, nil
+}
+
+// DeleteResource deletes a resources. As with UpdateResource, force == true skips the revision check.
+func (cl *Client) DeleteResource(prod, kind, guid string, force bool) (int, error) {
 	if err := cl.HasToken(); err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	status, err := cl.delete(cl.Namespace+fmt.Sprintf(deleteResourceRoute, prod, kind, guid), nil)
+	resp := a.StatusObject{}
+	status, _, err := cl.send("DELETE", cl.Namespace+fmt.Sprintf(deleteResourceRoute, prod, kind, guid, force), cl.ifMatch(guid, force), nil, &resp)
 	if err != nil {
 		return status, err
 	}
+	cl.setETag(guid, "")
 	return status, nil
 }
 
@@ -201,6 +467,56 @@ func (cl *Client) Build(guid string) (*a.Build, error) {
 	return &resp, nil
 }
 
+// Builds returns all feeds that were built for the production
+func (cl *Client) Builds(guid string) (*a.FeedSnapshotList, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	var resp a.FeedSnapshotList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(listBuildsRoute, guid), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RestoreBuild publishes the feed of a previous build again
+func (cl *Client) RestoreBuild(guid, buildID string) (*a.FeedSnapshot, error) {
+	if err := cl.HasToken(); err != nil {
+		return nil, err
+	}
+
+	resp := a.FeedSnapshot{}
+	_, err := cl.post(cl.Namespace+fmt.Sprintf(restoreBuildRoute, guid, buildID), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Download retrieves an asset from the CDN. 'location' is the asset's location in the inventory, i.e. 'production/asset'
+func (cl *Client) Download(location string, w io.Writer) error {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/c/%s", a.DefaultCDNEndpoint, location), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", a.UserAgentString)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error downloading '%s': %s", location, resp.Status)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
 // Upload invokes the UploadEndpoint
 func (cl *Client) Upload(path string, force bool) error {
 	if err := cl.HasTokenAndGUID(); err != nil {
@@ -231,3 +547,84 @@ func (cl *Client) Upload(path string, force bool) error {
 
 	return nil
 }
+
+// Webhooks lists the webhooks of the current production
+func (cl *Client) Webhooks() (*a.WebhookList, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	var resp a.WebhookList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(webhooksRoute, cl.GUID), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateWebhook subscribes a URL to events of the current production. Only the response includes the webhook's secret.
+func (cl *Client) CreateWebhook(url string, events []string) (*a.Webhook, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	req := a.Webhook{URL: url, Events: events}
+	var resp a.Webhook
+	_, err := cl.post(cl.Namespace+fmt.Sprintf(webhooksRoute, cl.GUID), &req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateWebhook changes the URL, the events or whether a webhook is active
+func (cl *Client) UpdateWebhook(w *a.Webhook) (*a.Webhook, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	var resp a.Webhook
+	_, err := cl.put(cl.Namespace+fmt.Sprintf(webhookRoute, cl.GUID, w.ID), w, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteWebhook removes a webhook and its deliveries
+func (cl *Client) DeleteWebhook(id string) error {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	_, err := cl.delete(cl.Namespace+fmt.Sprintf(webhookRoute, cl.GUID, id), nil)
+	return err
+}
+
+// WebhookDeliveries lists the recent deliveries of a webhook
+func (cl *Client) WebhookDeliveries(id string) (*a.WebhookDeliveryList, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	var resp a.WebhookDeliveryList
+	_, err := cl.get(cl.Namespace+fmt.Sprintf(webhookDeliveriesRoute, cl.GUID, id), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReplayDelivery delivers the event of a previous delivery again
+func (cl *Client) ReplayDelivery(id string) (*a.WebhookDelivery, error) {
+	if err := cl.HasTokenAndGUID(); err != nil {
+		return nil, err
+	}
+
+	var resp a.WebhookDelivery
+	_, err := cl.post(cl.Namespace+fmt.Sprintf(webhookReplayRoute, cl.GUID, id), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/podops/podops/internal/api"
+	p "github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/internal/ratelimit"
 	"github.com/podops/podops/pkg/auth"
 	"github.com/podops/podops/pkg/backend"
 )
@@ -21,48 +23,84 @@ const ShutdownDelay = 10
 var (
 	// the router instance
 	mux *echo.Echo
+	// flushes the remaining spans
+	shutdownTracing func(context.Context) error
 )
 
 func setup() *echo.Echo {
 	// Create a new router instance
 	e := echo.New()
+	e.IPExtractor = ratelimit.ExtractIP
 
-	// add and configure the middlewares
+	// add and configure the middlewares. Tracing comes first so that the logs carry the trace IDs.
+	e.Use(p.TracingMiddleware)
+	e.Use(p.LoggingMiddleware)
+	e.Use(p.MetricsMiddleware)
 	e.Use(middleware.Recover())
 
 	//e.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
-	// TODO: add/configure e.Use(middleware.Logger())
-	// TODO: e.Logger.SetLevel(log.INFO)
 
 	// default public endpoints without authentication
 	e.GET(api.VersionRoute, api.VersionEndpoint)
+	e.GET(api.MetricsRoute, p.MetricsEndpoint)
 
 	// task endpoints
 	tasks := e.Group(api.TaskNamespacePrefix)
 	tasks.POST(backend.ImportTask, backend.ImportTaskEndpoint)
+	tasks.GET(backend.ScheduleTask, backend.ScheduleTaskEndpoint)
+	tasks.POST(backend.WebhookTask, backend.WebhookTaskEndpoint)
+	tasks.POST(backend.NotifyTask, backend.NotifyTaskEndpoint)
+	tasks.POST(backend.IndexTask, backend.IndexTaskEndpoint)
+	tasks.POST(backend.WebSubVerifyTask, backend.WebSubVerifyTaskEndpoint)
+	tasks.POST(backend.WebSubDeliverTask, backend.WebSubDeliverTaskEndpoint)
 
 	// admin endpoints
 	admin := e.Group(api.AdminNamespacePrefix)
 	admin.POST(api.AuthenticationRoute, auth.CreateAuthorizationEndpoint)
 	admin.GET(api.AuthenticationRoute, auth.ValidateAuthorizationEndpoint)
+	admin.GET(api.FsckRoute, api.FsckEndpoint)
+	admin.POST(api.FsckRoute, api.FsckEndpoint)
+	admin.POST(api.QuotaRoute, api.QuotaEndpoint)
 
-	// the api endpoints
-	apiEndpoints := e.Group(api.NamespacePrefix)
+	// the api endpoints, rate limited per client. Builds, uploads and imports have a lower limit.
+	apiEndpoints := e.Group(api.NamespacePrefix, ratelimit.API.Middleware(ratelimit.ClientKey, nil))
+	expensive := ratelimit.Expensive.Middleware(ratelimit.ClientKey, nil)
 	apiEndpoints.GET(api.ListProductionsRoute, api.ListProductionsEndpoint)
 	apiEndpoints.POST(api.ProductionRoute, api.ProductionEndpoint)
+	apiEndpoints.DELETE(api.DeleteProductionRoute, api.DeleteProductionEndpoint)
+	apiEndpoints.GET(api.ExportProductionRoute, api.ExportProductionEndpoint)
+	apiEndpoints.POST(api.ImportFeedRoute, api.ImportFeedEndpoint, expensive)
+	apiEndpoints.POST(api.MigrateRoute, api.MigrateEndpoint)
 	apiEndpoints.GET(api.GetResourceRoute, api.GetResourceEndpoint)
 	apiEndpoints.GET(api.ListResourcesRoute, api.ListResourcesEndpoint)
 	apiEndpoints.POST(api.UpdateResourceRoute, api.UpdateResourceEndpoint)
 	apiEndpoints.PUT(api.UpdateResourceRoute, api.UpdateResourceEndpoint)
 	apiEndpoints.DELETE(api.DeleteResourceRoute, api.DeleteResourceEndpoint)
-	apiEndpoints.POST(api.BuildRoute, api.BuildEndpoint)
-	apiEndpoints.POST(api.UploadRoute, api.UploadEndpoint)
+	apiEndpoints.POST(api.StatusRoute, api.StatusEndpoint)
+	apiEndpoints.GET(api.AuditRoute, api.AuditEndpoint)
+	apiEndpoints.GET(api.HistoryRoute, api.HistoryEndpoint)
+	apiEndpoints.POST(api.RollbackRoute, api.RollbackEndpoint)
+	apiEndpoints.POST(api.BuildRoute, api.BuildEndpoint, expensive)
+	apiEndpoints.GET(api.GCRoute, api.GCEndpoint)
+	apiEndpoints.POST(api.GCRoute, api.GCEndpoint)
+	apiEndpoints.GET(api.UsageRoute, api.UsageEndpoint)
+	apiEndpoints.GET(api.ListBuildsRoute, api.ListBuildsEndpoint)
+	apiEndpoints.POST(api.RestoreBuildRoute, api.RestoreBuildEndpoint)
+	apiEndpoints.POST(api.UploadRoute, api.UploadEndpoint, expensive)
+	apiEndpoints.GET(api.WebhooksRoute, api.ListWebhooksEndpoint)
+	apiEndpoints.POST(api.WebhooksRoute, api.CreateWebhookEndpoint)
+	apiEndpoints.PUT(api.WebhookRoute, api.UpdateWebhookEndpoint)
+	apiEndpoints.DELETE(api.WebhookRoute, api.DeleteWebhookEndpoint)
+	apiEndpoints.GET(api.WebhookDeliveriesRoute, api.WebhookDeliveriesEndpoint)
+	apiEndpoints.POST(api.WebhookReplayRoute, api.ReplayDeliveryEndpoint)
 
 	return e
 }
 
 func shutdown(*echo.Echo) {
-	// TODO: implement your own stuff here
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Printf("error flushing the traces: %v", err)
+	}
 }
 
 func init() {
@@ -78,6 +116,11 @@ func init() {
 		log.Fatal("error initializing the platform services")
 	}
 	platform.RegisterGlobally(client)
+
+	shutdownTracing, err = p.InitTracing(context.Background(), serviceName)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 func main() {
@@ -15,6 +15,8 @@ import (
 	"github.com/podops/podops/internal/api"
 	"github.com/podops/podops/internal/cdn"
 	p "github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/internal/ratelimit"
+	"github.com/podops/podops/pkg/backend"
 )
 
 // ShutdownDelay is the delay before exiting the process
@@ -24,11 +26,14 @@ var (
 	// the router instance
 	mux                *echo.Echo
 	staticFileLocation string
+	// flushes the remaining spans
+	shutdownTracing func(context.Context) error
 )
 
 func setup() *echo.Echo {
 	// Create a new router instance
 	e := echo.New()
+	e.IPExtractor = ratelimit.ExtractIP
 
 	// hack to get get rid of these 404 in the log
 	e.Pre(middleware.Rewrite(map[string]string{
@@ -36,21 +41,34 @@ func setup() *echo.Echo {
 	}))
 	// end hack
 
-	// add and configure the middlewares
+	// redirect requests for the previous location of imported feeds
+	e.HTTPErrorHandler = cdn.HTTPErrorHandler
+
+	// add and configure the middlewares. Tracing comes first so that the logs carry the trace IDs.
+	e.Use(p.TracingMiddleware)
+	e.Use(p.LoggingMiddleware)
+	e.Use(p.MetricsMiddleware)
 	e.Use(middleware.Recover())
-	e.Use(middleware.Gzip())
+	e.Use(ratelimit.CDN.Middleware(ratelimit.IPKey, nil))
+	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{Skipper: cdn.SkipGzip}))
 	e.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
 	//e.Use(middleware.CSRFWithConfig(middleware.DefaultCSRFConfig))
 	e.Use(p.PageViewMiddleware)
 
-	// TODO: add/configure e.Use(middleware.Logger())
-	// TODO: e.Logger.SetLevel(log.INFO)
+	// metrics
+	e.GET(api.MetricsRoute, p.MetricsEndpoint)
 
 	// frontend routes for feed, show & episode
 	e.GET(api.ShowRoute, cdn.RewriteShowHandler)
 	e.GET(api.EpisodeRoute, cdn.RewriteEpisodeHandler)
 	e.GET(api.FeedRoute, cdn.FeedEndpoint)
 
+	// public search
+	e.GET(api.SearchRoute, api.SearchEndpoint)
+
+	// WebSub hub
+	e.POST(api.HubRoute, api.HubEndpoint)
+
 	// cdn enpoints
 	content := e.Group(api.ContentNamespace)
 	content.GET(api.DefaultCDNRoute, cdn.RedirectCDNContentEndpoint)
@@ -58,7 +76,9 @@ func setup() *echo.Echo {
 
 	// grapghql
 	gql := e.Group(api.GraphqlNamespacePrefix)
-	gql.POST(api.GraphqlRoute, api.GetGraphqlEndpoint())
+	graphql := api.GetGraphqlEndpoint()
+	gql.POST(api.GraphqlRoute, graphql)
+	gql.GET(api.GraphqlRoute, graphql) // websocket subscriptions
 	gql.GET(api.GraphqlPlaygroundRoute, api.GetGraphqlPlaygroundEndpoint())
 
 	// add the routes last
@@ -68,7 +88,12 @@ func setup() *echo.Echo {
 }
 
 func shutdown(*echo.Echo) {
-	// TODO: implement your own stuff here
+	if err := backend.FlushEgress(context.Background()); err != nil {
+		log.Printf("error writing the egress: %v", err)
+	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Printf("error flushing the traces: %v", err)
+	}
 }
 
 func init() {
@@ -85,6 +110,11 @@ func init() {
 	}
 	platform.RegisterGlobally(client)
 
+	shutdownTracing, err = p.InitTracing(context.Background(), serviceName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	staticFileLocation = env.GetString("STATIC_FILE_LOCATION", "public")
 }
 
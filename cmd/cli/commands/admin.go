@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// FsckCommand cross-checks the inventory of a show with its files
+func FsckCommand(c *cli.Context) error {
+	if err := client.HasToken(); err != nil {
+		return err
+	}
+
+	name := c.Args().First()
+	if name == "" {
+		if client.GUID == "" {
+			return fmt.Errorf("no show selected. Use 'po set NAME' first")
+		}
+		name = client.GUID
+	}
+	prod, err := findProduction(name)
+	if err != nil {
+		return err
+	}
+
+	repair := c.Bool("repair")
+	report, err := client.Fsck(prod.GUID, repair)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Checked %d inventory entries and %d files of show '%s'.", report.Resources, report.Objects, prod.Name))
+	if len(report.Issues) == 0 {
+		fmt.Println("No issues found.")
+		return nil
+	}
+
+	repaired := 0
+	fmt.Println()
+	fmt.Println(fsckListing("ISSUE", "LOCATION", "DETAILS", ""))
+	for _, issue := range report.Issues {
+		status := ""
+		if issue.Repaired {
+			status = "(repaired)"
+			repaired++
+		}
+		fmt.Println(fsckListing(issue.Issue, issue.Location, issue.Message, status))
+	}
+
+	if repair {
+		fmt.Println(fmt.Sprintf("\nRepaired %d of %d issues.", repaired, len(report.Issues)))
+	} else {
+		fmt.Println(fmt.Sprintf("\nFound %d issues. Use 'po admin fsck --repair' to repair them.", len(report.Issues)))
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fupas/commons/pkg/util"
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	planCreate    = "create"
+	planUpdate    = "update"
+	planDelete    = "delete"
+	planUpload    = "upload"
+	planUnchanged = "unchanged"
+)
+
+type (
+	// planEntry is a single step needed to sync a local directory with the production
+	planEntry struct {
+		Action   string
+		Kind     string
+		Name     string
+		GUID     string
+		Path     string // the local file, if any
+		Resource interface{}
+	}
+)
+
+// ApplyCommand syncs all resources in a directory with the current production
+func ApplyCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	dir := "."
+	if c.NArg() > 0 {
+		dir = c.Args().First()
+	}
+	force := c.Bool("force")
+
+	resources, err := loadResources(dir)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		fmt.Println(fmt.Sprintf("No resources found in '%s'.", dir))
+		return nil
+	}
+	if c.Bool("remap") {
+		remapResources(resources, client.GUID)
+	}
+
+	plan, err := computePlan(dir, resources, force, c.Bool("prune"))
+	if err != nil {
+		return err
+	}
+
+	changes := printPlan(plan)
+	if changes == 0 {
+		fmt.Println("\nNothing to do.")
+		return nil
+	}
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	fmt.Println()
+	defer client.Store(defaultPathAndName) // keep the revisions of all resources written
+	for _, step := range plan {
+		if err := applyPlanEntry(dir, step, force); err != nil {
+			return fmt.Errorf("can not %s %s '%s': %w", step.Action, step.Kind, step.Name, err)
+		}
+		if step.Action != planUnchanged {
+			fmt.Println(fmt.Sprintf("%s %s '%s'", step.Action, step.Kind, step.Name))
+		}
+	}
+
+	fmt.Println(fmt.Sprintf("\nApplied %d changes to production '%s'.", changes, client.GUID))
+	return nil
+}
+
+// computePlan compares the local resources with the resources of the current production
+func computePlan(dir string, resources []*localResource, force, prune bool) ([]*planEntry, error) {
+	var assets, shows, episodes, deletes []*planEntry
+
+	l, err := client.Resources(client.GUID, a.ResourceALL)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]*a.Resource)
+	remoteAssets := make(map[string]*a.Resource)
+	for _, r := range l.Resources {
+		if r.Kind == a.ResourceAsset {
+			remoteAssets[r.Name] = r
+		} else {
+			remote[r.GUID] = r
+		}
+	}
+
+	local := make(map[string]bool)
+	uploads := make(map[string]bool)
+
+	for _, rsrc := range resources {
+		if parent := resourceParentGUID(rsrc.Resource); parent != client.GUID {
+			return nil, fmt.Errorf("%s: resource belongs to production '%s', not '%s'. Use --remap to copy it", rsrc.Path, parent, client.GUID)
+		}
+		local[rsrc.GUID] = true
+
+		// the assets referenced by the resource
+		for _, asset := range resourceAssets(rsrc.Resource) {
+			if asset.Rel != a.ResourceTypeLocal {
+				continue
+			}
+			path, found := findLocalAsset(dir, asset.URI)
+			if !found {
+				return nil, fmt.Errorf("%s: can not find local asset '%s'", rsrc.Path, asset.URI)
+			}
+			if uploads[path] {
+				continue
+			}
+			uploads[path] = true
+
+			name := filepath.Base(path)
+			action := planUnchanged
+			changed, err := assetChanged(filepath.Join(dir, path), remoteAssets[name])
+			if err != nil {
+				return nil, err
+			}
+			if force || changed {
+				action = planUpload
+			}
+			assets = append(assets, &planEntry{Action: action, Kind: a.ResourceAsset, Name: name, Path: path})
+		}
+
+		// the resource itself
+		step := &planEntry{
+			Action:   planCreate,
+			Kind:     rsrc.Kind,
+			Name:     resourceName(rsrc.Resource),
+			GUID:     rsrc.GUID,
+			Path:     rsrc.Path,
+			Resource: rsrc.Resource,
+		}
+		if _, ok := remote[rsrc.GUID]; ok {
+			step.Action = planUpdate
+			if !force {
+				current, err := getRemoteResource(client.GUID, rsrc.Kind, rsrc.GUID)
+				if err != nil {
+					return nil, err
+				}
+				if sameResource(rsrc.Resource, current) {
+					step.Action = planUnchanged
+				}
+			}
+		}
+		if rsrc.Kind == a.ResourceShow {
+			shows = append(shows, step)
+		} else {
+			episodes = append(episodes, step)
+		}
+	}
+
+	if len(shows) > 1 {
+		return nil, fmt.Errorf("found %d show resources, expected at most one", len(shows))
+	}
+
+	// episodes that only exist in the production. Shows are never pruned.
+	if prune {
+		for _, r := range l.Resources {
+			if r.Kind == a.ResourceEpisode && !local[r.GUID] {
+				client.SetRevision(r.GUID, r.Revision) // only delete what the plan has seen
+				deletes = append(deletes, &planEntry{Action: planDelete, Kind: r.Kind, Name: r.Name, GUID: r.GUID})
+			}
+		}
+	}
+
+	sort.Slice(episodes, func(i, j int) bool { return episodes[i].Name < episodes[j].Name })
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].Name < deletes[j].Name })
+
+	// assets have to be in place before the resources referencing them
+	plan := append(assets, shows...)
+	plan = append(plan, episodes...)
+	return append(plan, deletes...), nil
+}
+
+// printPlan lists all steps and returns the number of changes
+func printPlan(plan []*planEntry) int {
+	changes := 0
+
+	fmt.Println(planListing("ACTION", "KIND", "NAME", "GUID"))
+	for _, step := range plan {
+		fmt.Println(planListing(step.Action, step.Kind, step.Name, step.GUID))
+		if step.Action != planUnchanged {
+			changes++
+		}
+	}
+	return changes
+}
+
+func applyPlanEntry(dir string, step *planEntry, force bool) error {
+	switch step.Action {
+	case planUpload:
+		return client.Upload(filepath.Join(dir, step.Path), force)
+	case planCreate:
+		_, err := client.CreateResource(step.Kind, step.GUID, force, step.Resource)
+		return err
+	case planUpdate:
+		_, err := client.UpdateResource(step.Kind, step.GUID, force, step.Resource)
+		return err
+	case planDelete:
+		_, err := client.DeleteResource(client.GUID, step.Kind, step.GUID, force)
+		return err
+	}
+	return nil
+}
+
+// getRemoteResource retrieves a show or episode from production 'prod'
+func getRemoteResource(prod, kind, guid string) (interface{}, error) {
+	var rsrc interface{}
+
+	if kind == a.ResourceShow {
+		rsrc = &a.Show{}
+	} else if kind == a.ResourceEpisode {
+		rsrc = &a.Episode{}
+	} else {
+		return nil, fmt.Errorf("unsupported resource '%s'", kind)
+	}
+	if err := client.GetResource(prod, kind, guid, rsrc); err != nil {
+		return nil, err
+	}
+	return rsrc, nil
+}
+
+// sameResource compares the canonical .yaml representation of two resources
+func sameResource(r1, r2 interface{}) bool {
+	d1, err := yaml.Marshal(r1)
+	if err != nil {
+		return false
+	}
+	d2, err := yaml.Marshal(r2)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(d1, d2)
+}
+
+// assetChanged compares a local file with the asset inventory
+func assetChanged(path string, r *a.Resource) (bool, error) {
+	if r == nil {
+		return true, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if r.Checksum == "" {
+		// older inventory entries have no checksum
+		info, err := f.Stat()
+		if err != nil {
+			return false, err
+		}
+		return info.Size() != r.Size, nil
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) != r.Checksum, nil
+}
+
+func resourceName(rsrc interface{}) string {
+	switch r := rsrc.(type) {
+	case *a.Show:
+		return r.Metadata.Name
+	case *a.Episode:
+		return r.Metadata.Name
+	}
+	return ""
+}
+
+// remapResources moves resources exported from another production into production 'guid'. Episode GUIDs
+// are unique across all productions, the copies get new ones. They are derived from the old ones, applying
+// the same directory again updates the copies instead of creating more.
+func remapResources(resources []*localResource, guid string) {
+	for _, rsrc := range resources {
+		switch r := rsrc.Resource.(type) {
+		case *a.Show:
+			r.Metadata.Labels[a.LabelGUID] = guid
+			rsrc.GUID = guid
+		case *a.Episode:
+			if r.ParentGUID() == guid {
+				continue
+			}
+			r.Metadata.Labels[a.LabelGUID] = util.Fingerprint(guid + "/" + r.GUID())[:12]
+			r.Metadata.Labels[a.LabelParentGUID] = guid
+			rsrc.GUID = r.GUID()
+		}
+	}
+}
+
+func resourceParentGUID(rsrc interface{}) string {
+	switch r := rsrc.(type) {
+	case *a.Show:
+		return r.GUID()
+	case *a.Episode:
+		return r.ParentGUID()
+	}
+	return ""
+}
+
+func resourceAssets(rsrc interface{}) []a.Asset {
+	switch r := rsrc.(type) {
+	case *a.Show:
+		return []a.Asset{r.Image}
+	case *a.Episode:
+		return []a.Asset{r.Image, r.Enclosure}
+	}
+	return nil
+}
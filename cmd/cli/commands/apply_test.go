@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRemapResources(t *testing.T) {
+	dir := t.TempDir()
+
+	// the files 'po export' writes for production 'source'
+	show := a.DefaultShow("show", "title", "summary", "source", a.DefaultPortalEndpoint, a.DefaultCDNEndpoint)
+	episode := a.DefaultEpisode("episode1", "show", "episode1", "source", a.DefaultPortalEndpoint, a.DefaultCDNEndpoint)
+	for _, r := range []struct {
+		kind, guid string
+		rsrc       interface{}
+	}{{a.ResourceShow, "source", show}, {a.ResourceEpisode, "episode1", episode}} {
+		data, err := yaml.Marshal(r.rsrc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s-%s.yaml", r.kind, r.guid)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resources, err := loadResources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remapResources(resources, "target")
+
+	guids := make(map[string]string)
+	for _, rsrc := range resources {
+		if parent := resourceParentGUID(rsrc.Resource); parent != "target" {
+			t.Errorf("%s: expected parent 'target', got '%s'", rsrc.Path, parent)
+		}
+		guids[rsrc.Kind] = rsrc.GUID
+	}
+	if guids[a.ResourceShow] != "target" {
+		t.Errorf("expected show guid 'target', got '%s'", guids[a.ResourceShow])
+	}
+	if guids[a.ResourceEpisode] == "episode1" || guids[a.ResourceEpisode] == "" {
+		t.Errorf("expected a new episode guid, got '%s'", guids[a.ResourceEpisode])
+	}
+
+	// applying the same directory again updates the same copies
+	again, err := loadResources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remapResources(again, "target")
+	for _, rsrc := range again {
+		if rsrc.GUID != guids[rsrc.Kind] {
+			t.Errorf("expected guid '%s', got '%s'", guids[rsrc.Kind], rsrc.GUID)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
 	"net/http"
+	"os"
+	"sort"
 	"strings"
 
 	a "github.com/podops/podops/apiv1"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 )
 
 // NewProductionCommand requests a new show
@@ -43,6 +45,27 @@ func NewProductionCommand(c *cli.Context) error {
 	return nil
 }
 
+// ImportFeedCommand creates a new show from an existing podcast feed
+func ImportFeedCommand(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("missing feed URL")
+	}
+
+	resp, err := client.ImportFeed(c.Args().Get(0), c.Args().Get(1))
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Imported show '%s' with %d episodes. Media files are imported in the background.", resp.Name, resp.Episodes))
+
+	// update the client
+	client.GUID = resp.GUID
+	client.Store(defaultPathAndName)
+
+	return nil
+}
+
 // ListProductionsCommand retrieves all shows
 func ListProductionsCommand(c *cli.Context) error {
 
@@ -122,68 +145,132 @@ func SetProductionCommand(c *cli.Context) error {
 func ListResourcesCommand(c *cli.Context) error {
 
 	kind := strings.ToLower(c.Args().First())
+	format := c.String("output")
+	if err := validOutputFormat(format); err != nil {
+		return err
+	}
 
 	if c.NArg() < 2 {
 		// get a list of resources
-		l, err := client.Resources(client.GUID, kind)
-		if err != nil {
-			printError(c, err)
-			return nil
+		status := c.String("status")
+		if c.Bool("scheduled") {
+			status = a.EpisodeStatusScheduled
 		}
 
-		if len(l.Resources) == 0 {
-			fmt.Println("No resources to list.")
+		var l *a.ResourceList
+		var err error
+		if status != "" {
+			l, err = client.ResourcesWithStatus(client.GUID, status)
 		} else {
-			fmt.Println(assetListing("GUID", "NAME", "KIND"))
-			for _, details := range l.Resources {
-				fmt.Println(assetListing(details.GUID, details.Name, details.Kind))
-			}
+			l, err = client.Resources(client.GUID, kind)
 		}
-	} else {
-		// get a single resource
-		guid := c.Args().Get(1)
-
-		var rsrc interface{}
-		err := client.GetResource(client.GUID, kind, guid, &rsrc)
 		if err != nil {
 			printError(c, err)
 			return nil
 		}
-
-		// FIXME verify that rsrc.Kind == kind
-
-		data, err := yaml.Marshal(rsrc)
-		if err != nil {
-			return err
+		if status == a.EpisodeStatusScheduled {
+			// the next one first
+			sort.Slice(l.Resources, func(i, j int) bool { return l.Resources[i].Published < l.Resources[j].Published })
 		}
+		return printResourceList(format, l)
+	}
 
-		fmt.Printf("\n--- %s/%s-%s:\n\n%s\n\n", client.GUID, kind, guid, string(data))
+	// get a single resource
+	guid := c.Args().Get(1)
 
+	var rsrc interface{}
+	var err error
+	if kind == a.ResourceAsset {
+		rsrc = &a.Asset{}
+		err = client.GetResource(client.GUID, kind, guid, rsrc)
+	} else {
+		rsrc, err = getRemoteResource(client.GUID, kind, guid)
+	}
+	if err != nil {
+		printError(c, err)
+		return nil
 	}
+	client.Store(defaultPathAndName) // remember the revision for the next update
 
-	return nil
+	return printResource(format, fmt.Sprintf("%s/%s-%s", client.GUID, kind, guid), rsrc)
 }
 
 // DeleteResourcesCommand deletes a resource
 func DeleteResourcesCommand(c *cli.Context) error {
 
+	kind := strings.ToLower(c.Args().First())
+	if kind == a.ResourceShow {
+		return deleteProduction(c)
+	}
+
 	if c.NArg() != 2 {
 		return fmt.Errorf("wrong number of arguments: expected 2, got %d", c.NArg())
 	}
-
-	kind := strings.ToLower(c.Args().First())
 	guid := c.Args().Get(1)
 
-	status, err := client.DeleteResource(client.GUID, kind, guid)
+	status, err := client.DeleteResource(client.GUID, kind, guid, c.Bool("force"))
 	if status > http.StatusAccepted && err == nil {
 		fmt.Println(fmt.Sprintf("could not delete resource '%s/%s-%s'", client.GUID, kind, guid))
 		return nil
 	}
 	if err != nil {
+		err = revisionError(status, err)
 		printError(c, err)
 		return err
 	}
+	client.Store(defaultPathAndName)
 
 	fmt.Println(fmt.Sprintf("successfully delete resource '%s/%s-%s'", client.GUID, kind, guid))
 	return nil
 }
+
+// deleteProduction exports a show into an archive and deletes it with all its episodes, assets and feeds
+func deleteProduction(c *cli.Context) error {
+	if c.NArg() > 2 {
+		return fmt.Errorf("wrong number of arguments: expected at most 2, got %d", c.NArg())
+	}
+	if err := client.HasToken(); err != nil {
+		return err
+	}
+
+	name := c.Args().Get(1)
+	if name == "" {
+		if client.GUID == "" {
+			return fmt.Errorf("no show selected. Use 'po set NAME' first")
+		}
+		name = client.GUID
+	}
+	prod, err := findProduction(name)
+	if err != nil {
+		return err
+	}
+
+	// keep a copy of everything that is about to be deleted
+	path := fmt.Sprintf("%s-%s.zip", prod.Name, prod.GUID)
+	if err := exportArchive(prod, path); err != nil {
+		return fmt.Errorf("can not export show '%s', nothing was deleted: %w", prod.Name, err)
+	}
+	fmt.Println(fmt.Sprintf("Exported show '%s' to '%s'.", prod.Name, path))
+
+	if !c.Bool("yes") {
+		fmt.Print(fmt.Sprintf("This deletes show '%s' with all episodes, assets and feeds. Type the name of the show to confirm: ", prod.Name))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != prod.Name {
+			fmt.Println("Nothing was deleted.")
+			return nil
+		}
+	}
+
+	if err := client.DeleteProduction(prod.GUID); err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	if client.GUID == prod.GUID {
+		client.GUID = ""
+	}
+	client.Store(defaultPathAndName)
+
+	fmt.Println(fmt.Sprintf("Deleted show '%s'.", prod.Name))
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -26,12 +27,24 @@ const (
 	ShowCmdGroup = "\nContent Creation Commands"
 	// ShowMgmtCmdGroup groups advanced show commands
 	ShowMgmtCmdGroup = "\nContent Management Commands"
+	// AdminCmdGroup groups administrative commands
+	AdminCmdGroup = "\nAdministrative Commands"
 
 	// configNameAndPath is the name and location of the config file
 	configName = "config"
 	configPath = ".po"
 )
 
+type (
+	// localResource is a show or episode loaded from a local file
+	localResource struct {
+		Path     string
+		Kind     string
+		GUID     string
+		Resource interface{}
+	}
+)
+
 var (
 	client             *cl.Client
 	defaultPath        string
@@ -64,6 +77,14 @@ func NoOpCommand(c *cli.Context) error {
 	return cli.Exit(fmt.Sprintf("Command '%s' is not implemented", c.Command.Name), 0)
 }
 
+// revisionError explains how to resolve a failed revision check
+func revisionError(status int, err error) error {
+	if status == http.StatusPreconditionFailed || status == http.StatusPreconditionRequired {
+		return fmt.Errorf("%v. Use 'po get' to fetch the current revision, or --force to overwrite it", err)
+	}
+	return err
+}
+
 // printError formats a CLI error and prints it
 func printError(c *cli.Context, err error) {
 	msg := fmt.Sprintf("%s: %v", c.Command.Name, strings.ToLower(err.Error()))
@@ -98,6 +119,48 @@ func loadResource(path string) (interface{}, string, string, error) {
 	return r, kind, guid, nil
 }
 
+// loadResources reads all show and episode resources in directory 'dir' and its sub-directories
+func loadResources(dir string) ([]*localResource, error) {
+	var resources []*localResource
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+
+		r, kind, guid, err := loadResource(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		resources = append(resources, &localResource{
+			Path:     path,
+			Kind:     kind,
+			GUID:     guid,
+			Resource: r,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// findLocalAsset looks for the file referenced by a local asset. The URI might be relative
+// to the directory or include the production GUID. Returns the path relative to 'dir'.
+func findLocalAsset(dir, uri string) (string, bool) {
+	for _, p := range []string{uri, filepath.Base(uri)} {
+		if f, err := os.Stat(filepath.Join(dir, p)); err == nil && !f.IsDir() {
+			return p, true
+		}
+	}
+	return uri, false
+}
+
 // removeConfig removes the config file if one exists
 func removeConfig() error {
 	f, err := os.Stat(defaultPathAndName)
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fupas/commons/pkg/util"
 	a "github.com/podops/podops/apiv1"
@@ -74,6 +75,7 @@ func CreateCommand(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	client.Store(defaultPathAndName)
 
 	fmt.Println(fmt.Sprintf("created resource %s-%s", kind, guid))
 	return nil
@@ -93,10 +95,11 @@ func UpdateCommand(c *cli.Context) error {
 		return err
 	}
 
-	_, err = client.UpdateResource(kind, guid, force, r)
+	status, err := client.UpdateResource(kind, guid, force, r)
 	if err != nil {
-		return err
+		return revisionError(status, err)
 	}
+	client.Store(defaultPathAndName)
 
 	fmt.Println(fmt.Sprintf("Updated resource %s-%s", kind, guid))
 	return nil
@@ -116,6 +119,150 @@ func BuildCommand(c *cli.Context) error {
 	return nil
 }
 
+// FeedHistoryCommand lists all feeds that were built for the show
+func FeedHistoryCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	l, err := client.Builds(client.GUID)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	if len(l.Snapshots) == 0 {
+		fmt.Println("No builds to list.")
+		return nil
+	}
+
+	fmt.Println(buildListing("BUILD", "DATE", "EPISODES", "CHECKSUM", false))
+	for _, s := range l.Snapshots {
+		fmt.Println(buildListing(s.BuildID, time.Unix(s.Created, 0).UTC().Format("2006-01-02 15:04"), fmt.Sprintf("%d", s.Episodes), s.Checksum, s.BuildID == l.Active))
+	}
+	return nil
+}
+
+// FeedRollbackCommand publishes the feed of a previous build again
+func FeedRollbackCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	s, err := client.RestoreBuild(client.GUID, c.Args().First())
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Published the feed of build '%s' from %s.", s.BuildID, time.Unix(s.Created, 0).UTC().Format("2006-01-02 15:04")))
+	return nil
+}
+
+// GCCommand removes assets that no show or episode references
+func GCCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	grace := int64(-1) // the service's default
+	if c.IsSet("grace") {
+		grace = int64(c.Duration("grace").Seconds())
+	}
+	dryRun := c.Bool("dry-run")
+
+	report, err := client.CollectGarbage(client.GUID, grace, dryRun)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	if len(report.Assets) == 0 {
+		fmt.Println(fmt.Sprintf("All %d assets are in use.", report.Referenced))
+		return nil
+	}
+
+	cutoff := time.Now().Unix() - report.GracePeriod
+	fmt.Println(gcListing("GUID", "NAME", "SIZE", "UPDATED", ""))
+	for _, asset := range report.Assets {
+		note := ""
+		if asset.Updated > cutoff {
+			note = "(grace period)"
+		} else if asset.Collected {
+			note = "(deleted)"
+		}
+		fmt.Println(gcListing(asset.GUID, asset.Name, fmt.Sprintf("%d", asset.Size), time.Unix(asset.Updated, 0).UTC().Format("2006-01-02 15:04"), note))
+	}
+
+	if dryRun {
+		fmt.Println(fmt.Sprintf("\n%d unreferenced assets, %d bytes can be deleted. Run 'po gc' without --dry-run to delete them.", len(report.Assets), report.Size))
+	} else {
+		fmt.Println(fmt.Sprintf("\nDeleted %d bytes of unreferenced assets.", report.Size))
+	}
+	return nil
+}
+
+// UsageCommand shows the resource consumption of the current show and of all shows, compared to the quota
+func UsageCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	report, err := client.Usage(client.GUID)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	limit := func(q int64, format func(int64) string) string {
+		if q == 0 {
+			return "unlimited"
+		}
+		return format(q)
+	}
+	count := func(n int64) string { return fmt.Sprintf("%d", n) }
+	show, all, quota := report.Production, report.Owner, report.Quota
+
+	fmt.Println(usageListing("", "SHOW", "ALL SHOWS", "QUOTA"))
+	fmt.Println(usageListing("storage", formatBytes(show.Storage), formatBytes(all.Storage), limit(quota.Storage, formatBytes)))
+	fmt.Println(usageListing("egress ("+all.Month+")", formatBytes(show.Egress), formatBytes(all.Egress), limit(quota.Egress, formatBytes)))
+	fmt.Println(usageListing("episodes", count(show.Episodes), count(all.Episodes), limit(quota.Episodes, count)))
+	fmt.Println(usageListing("builds ("+all.Month+")", count(show.Builds), count(all.Builds), limit(quota.Builds, count)))
+
+	return nil
+}
+
+// MigrateCommand moves the show to another host or registers the feed's previous location
+func MigrateCommand(c *cli.Context) error {
+	m := a.FeedMigration{
+		NewFeedURL: c.String("to"),
+		OldFeedURL: c.String("from"),
+		Cancel:     c.Bool("cancel"),
+	}
+	if m.NewFeedURL == "" && m.OldFeedURL == "" && !m.Cancel {
+		return fmt.Errorf("expected one of --to, --from or --cancel")
+	}
+	if m.NewFeedURL != "" && m.Cancel {
+		return fmt.Errorf("--to and --cancel are mutually exclusive")
+	}
+
+	p, err := client.MigrateFeed(&m)
+	if err != nil {
+		return err
+	}
+
+	if p.State == a.ProductionStateMoved {
+		fmt.Println(fmt.Sprintf("Production '%s' moved to %s", p.GUID, p.NewFeedURL))
+	} else if m.Cancel {
+		fmt.Println(fmt.Sprintf("Production '%s' is no longer moved", p.GUID))
+	}
+	if p.OldFeedURL != "" {
+		fmt.Println(fmt.Sprintf("Requests for %s are redirected to production '%s'", p.OldFeedURL, p.GUID))
+	}
+	return nil
+}
+
 // UploadCommand uploads an asset from a file
 func UploadCommand(c *cli.Context) error {
 
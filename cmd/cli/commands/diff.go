@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+)
+
+// DiffCommand compares local resources with the resources of the current production.
+// The command exits with status 1 if there are differences.
+func DiffCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	path := "."
+	if c.NArg() > 0 {
+		path = c.Args().First()
+	}
+
+	var resources []*localResource
+
+	f, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if f.IsDir() {
+		resources, err = loadResources(path)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, kind, guid, err := loadResource(path)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, &localResource{Path: path, Kind: kind, GUID: guid, Resource: r})
+	}
+
+	n := 0
+	for _, rsrc := range resources {
+		remote, err := getRemoteResource(client.GUID, rsrc.Kind, rsrc.GUID)
+		if err != nil {
+			if !errors.Is(err, a.ErrNoSuchResource) {
+				return err
+			}
+			fmt.Println(fmt.Sprintf("\n--- %s/%s-%s (%s):\n\n  only exists locally", client.GUID, rsrc.Kind, rsrc.GUID, rsrc.Path))
+			n++
+			continue
+		}
+
+		diff := a.Diff(remote, rsrc.Resource)
+		if len(diff) == 0 {
+			continue
+		}
+
+		fmt.Println(fmt.Sprintf("\n--- %s/%s-%s (%s):\n", client.GUID, rsrc.Kind, rsrc.GUID, rsrc.Path))
+		for _, d := range diff {
+			fmt.Println("  " + d.String())
+		}
+		n++
+	}
+
+	if n > 0 {
+		return cli.Exit(fmt.Sprintf("\n%d of %d resources differ", n, len(resources)), 1)
+	}
+	fmt.Println("No differences.")
+	return nil
+}
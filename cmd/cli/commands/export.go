@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportCommand downloads all resources of a production into a local directory.
+// The result can be used with 'po apply' to update the production, or with 'po apply --remap' to copy it into another one.
+func ExportCommand(c *cli.Context) error {
+	if err := client.HasToken(); err != nil {
+		return err
+	}
+	if c.NArg() != 2 {
+		return fmt.Errorf("wrong number of arguments: expected 2, got %d", c.NArg())
+	}
+	name := c.Args().First()
+	dir := c.Args().Get(1)
+
+	prod, err := findProduction(name)
+	if err != nil {
+		return err
+	}
+	if c.Bool("archive") {
+		if err := exportArchive(prod, dir); err != nil {
+			return err
+		}
+		fmt.Println(fmt.Sprintf("Exported show '%s' to '%s'.", prod.Name, dir))
+		return nil
+	}
+
+	resources, err := client.Resources(prod.GUID, a.ResourceALL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	n := 0
+	for _, r := range resources.Resources {
+		if r.Kind == a.ResourceAsset {
+			if !c.Bool("assets") {
+				continue
+			}
+			path := filepath.Join(dir, r.Name)
+			if err := downloadAsset(r.Location, path); err != nil {
+				return err
+			}
+			fmt.Println(fmt.Sprintf("exported %s '%s' to %s", r.Kind, r.Name, path))
+			n++
+			continue
+		}
+
+		rsrc, err := getRemoteResource(prod.GUID, r.Kind, r.GUID)
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(rsrc)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.yaml", r.Kind, r.GUID))
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		fmt.Println(fmt.Sprintf("exported %s '%s' to %s", r.Kind, r.Name, path))
+		n++
+	}
+
+	if prod.GUID == client.GUID {
+		client.Store(defaultPathAndName) // remember the revisions for the next update
+	}
+	fmt.Println(fmt.Sprintf("\nExported %d resources of show '%s' to '%s'.", n, prod.Name, dir))
+	return nil
+}
+
+// exportArchive downloads a zip archive of everything stored for a production
+func exportArchive(prod *a.Production, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := client.ExportProduction(prod.GUID, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// findProduction looks up a production by its name or GUID
+func findProduction(name string) (*a.Production, error) {
+	l, err := client.Productions()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range l.Productions {
+		if p.Name == name || p.GUID == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("can not find show '%s'. Use 'po list' to list available shows", name)
+}
+
+func downloadAsset(location, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := client.Download(location, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+)
+
+// HistoryCommand lists all revisions of a show or episode
+func HistoryCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	r, err := findResource(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	l, err := client.History(client.GUID, r.GUID)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	if len(l.Revisions) == 0 {
+		fmt.Println(fmt.Sprintf("No revisions of %s '%s'.", r.Kind, r.Name))
+		return nil
+	}
+
+	fmt.Println(historyListing("REV", "DATE", "BY", false))
+	for _, rev := range l.Revisions {
+		fmt.Println(historyListing(fmt.Sprintf("%d", rev.Revision), time.Unix(rev.Timestamp, 0).UTC().Format("2006-01-02 15:04"), rev.ClientID, rev.Revision == l.Current))
+	}
+	return nil
+}
+
+// RollbackCommand restores a previous revision of a show or episode
+func RollbackCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	revision := c.Int("to")
+	if revision < 1 {
+		return fmt.Errorf("missing revision, use --to REV")
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	r, err := findResource(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	rev, err := client.Rollback(client.GUID, r.GUID, revision)
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	client.Store(defaultPathAndName)
+
+	fmt.Println(fmt.Sprintf("Restored revision %d of %s '%s' as revision %d.", revision, r.Kind, r.Name, rev.Revision))
+	return nil
+}
+
+// findResource looks up the show or an episode of the current production by its name or GUID
+func findResource(name string) (*a.Resource, error) {
+	l, err := client.Resources(client.GUID, a.ResourceALL)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range l.Resources {
+		if r.Kind == a.ResourceAsset {
+			continue
+		}
+		if r.GUID == name || r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", a.ErrNoSuchResource, name)
+}
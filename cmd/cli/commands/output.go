@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// OutputTable is the default, fixed-width listing
+	OutputTable = "table"
+	// OutputWide is a fixed-width listing with additional columns
+	OutputWide = "wide"
+	// OutputYAML prints resources as .yaml
+	OutputYAML = "yaml"
+	// OutputJSON prints resources as .json
+	OutputJSON = "json"
+	// OutputTemplate prints resources using a Go template, e.g. 'go-template={{.Name}}'
+	OutputTemplate = "go-template="
+)
+
+// validOutputFormat verifies the value of the '--output' flag
+func validOutputFormat(format string) error {
+	switch format {
+	case "", OutputTable, OutputWide, OutputYAML, OutputJSON:
+		return nil
+	}
+	if strings.HasPrefix(format, OutputTemplate) {
+		_, err := template.New("output").Parse(strings.TrimPrefix(format, OutputTemplate))
+		return err
+	}
+	return fmt.Errorf("unsupported output format '%s'. Use one of table|wide|yaml|json|go-template=...", format)
+}
+
+// printResourceList prints a list of resources in the requested format
+func printResourceList(format string, l *a.ResourceList) error {
+	switch {
+	case format == OutputYAML:
+		return printYAML(l)
+	case format == OutputJSON:
+		return printJSON(l)
+	case strings.HasPrefix(format, OutputTemplate):
+		for _, r := range l.Resources {
+			if err := printTemplate(format, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(l.Resources) == 0 {
+		fmt.Println("No resources to list.")
+		return nil
+	}
+	if format == OutputWide {
+		now := time.Now().Unix()
+		fmt.Println(wideAssetListing("GUID", "NAME", "KIND", "STATUS", "PUBLISHED", "SIZE", "TITLE"))
+		for _, r := range l.Resources {
+			status := ""
+			if r.Kind == a.ResourceEpisode {
+				status = r.StatusAt(now)
+			}
+			published := ""
+			if r.Published > 0 {
+				published = time.Unix(r.Published, 0).UTC().Format("2006-01-02 15:04")
+			}
+			size := ""
+			if r.Size > 0 {
+				size = fmt.Sprintf("%d", r.Size)
+			}
+			fmt.Println(wideAssetListing(r.GUID, r.Name, r.Kind, status, published, size, r.Title))
+		}
+		return nil
+	}
+
+	fmt.Println(assetListing("GUID", "NAME", "KIND"))
+	for _, r := range l.Resources {
+		fmt.Println(assetListing(r.GUID, r.Name, r.Kind))
+	}
+	return nil
+}
+
+// printResource prints a single resource in the requested format
+func printResource(format, title string, rsrc interface{}) error {
+	switch {
+	case format == OutputJSON:
+		return printJSON(rsrc)
+	case strings.HasPrefix(format, OutputTemplate):
+		return printTemplate(format, rsrc)
+	}
+
+	data, err := yaml.Marshal(rsrc)
+	if err != nil {
+		return err
+	}
+	if format == OutputYAML {
+		fmt.Print(string(data))
+		return nil
+	}
+	fmt.Printf("\n--- %s:\n\n%s\n\n", title, string(data))
+	return nil
+}
+
+func printYAML(doc interface{}) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func printJSON(doc interface{}) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printTemplate(format string, doc interface{}) error {
+	t, err := template.New("output").Parse(strings.TrimPrefix(format, OutputTemplate))
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(os.Stdout, doc); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
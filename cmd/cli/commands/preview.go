@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fupas/commons/pkg/util"
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// previewReloadInterval is the interval used to check for modified files
+	previewReloadInterval = time.Second
+	// previewAssetPrefix is the route to local assets
+	previewAssetPrefix = "/c/"
+)
+
+type (
+	// previewServer builds a feed from a local directory and serves it
+	previewServer struct {
+		dir     string
+		baseURL string
+
+		mu        sync.RWMutex
+		show      *a.Show
+		episodes  []*previewEpisode
+		feed      []byte
+		issues    []string
+		signature string
+	}
+
+	// previewEpisode is an episode and its status in the feed
+	previewEpisode struct {
+		Episode   *a.Episode
+		Status    string
+		Enclosure string
+	}
+)
+
+// PreviewCommand builds the feed of a local production directory and serves it on localhost
+func PreviewCommand(c *cli.Context) error {
+	dir := "."
+	if c.NArg() > 0 {
+		dir = c.Args().First()
+	}
+	if f, err := os.Stat(dir); err != nil || !f.IsDir() {
+		return fmt.Errorf("can not preview '%s': not a directory", dir)
+	}
+	port := c.Int("port")
+
+	srv := &previewServer{
+		dir:     dir,
+		baseURL: fmt.Sprintf("http://localhost:%d", port),
+	}
+	srv.reload()
+	go srv.watch(previewReloadInterval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", srv.indexHandler)
+	mux.HandleFunc("/feed.xml", srv.feedHandler)
+	mux.Handle(previewAssetPrefix, http.StripPrefix(previewAssetPrefix, http.FileServer(http.Dir(dir))))
+
+	fmt.Printf("Previewing '%s' at %s\nAccess the feed at %s/feed.xml\n", dir, srv.baseURL, srv.baseURL)
+	return http.ListenAndServe(fmt.Sprintf("localhost:%d", port), mux)
+}
+
+// watch polls the directory and rebuilds the feed whenever a file changes
+func (s *previewServer) watch(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+
+		sig, err := s.dirSignature()
+		if err != nil {
+			continue
+		}
+		s.mu.RLock()
+		changed := sig != s.signature
+		s.mu.RUnlock()
+
+		if changed {
+			s.reload()
+			fmt.Printf("Reloaded '%s' at %s\n", s.dir, time.Now().Format(time.Kitchen))
+		}
+	}
+}
+
+// dirSignature fingerprints names, sizes and modification times of all files in the directory
+func (s *previewServer) dirSignature() (string, error) {
+	var b strings.Builder
+
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return util.Checksum(b.String()), nil
+}
+
+// reload reads all resources from the directory and rebuilds the feed
+func (s *previewServer) reload() {
+	var show *a.Show
+	var episodes []*previewEpisode
+	var issues []string
+
+	sig, _ := s.dirSignature()
+
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("can not read file '%s': %v", path, err))
+			return nil
+		}
+		r, kind, _, err := a.LoadResource(data)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %v", path, err))
+			return nil
+		}
+
+		if kind == a.ResourceShow {
+			if show != nil {
+				issues = append(issues, fmt.Sprintf("%s: more than one show resource, ignoring it", path))
+				return nil
+			}
+			show = r.(*a.Show)
+		} else if kind == a.ResourceEpisode {
+			episodes = append(episodes, &previewEpisode{Episode: r.(*a.Episode), Status: previewStatus(r.(*a.Episode))})
+		}
+		return nil
+	})
+	if err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	sort.Slice(episodes, func(i, j int) bool {
+		return episodes[i].Episode.PublishDateTimestamp() > episodes[j].Episode.PublishDateTimestamp()
+	})
+
+	feed, buildIssues := s.build(show, episodes)
+	issues = append(issues, buildIssues...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.show = show
+	s.episodes = episodes
+	s.feed = feed
+	s.issues = issues
+	s.signature = sig
+}
+
+// build creates the feed XML the same way the backend does, but with local assets served by the preview server
+func (s *previewServer) build(show *a.Show, episodes []*previewEpisode) ([]byte, []string) {
+	var issues []string
+
+	if show == nil {
+		return nil, append(issues, "can not build feed without a show resource")
+	}
+
+	sh := *show
+	sh.Image = s.localAsset(sh.Image, &issues)
+	feed, err := a.TransformToPodcast(&sh)
+	if err != nil {
+		return nil, append(issues, err.Error())
+	}
+
+	n := 0
+	for _, pe := range episodes {
+		e := *pe.Episode
+		e.Image = s.localAsset(e.Image, &issues)
+		e.Enclosure = s.localAsset(e.Enclosure, &issues)
+		pe.Enclosure = e.Enclosure.ResolveURI(a.DefaultCDNEndpoint+"/c", e.ParentGUID())
+
+		if pe.Status != a.EpisodeStatusPublished {
+			continue
+		}
+
+		item, err := a.TransformToItem(&e)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("episode '%s': %v", e.Metadata.Name, err))
+			continue
+		}
+		if _, err := feed.AddItem(item); err != nil {
+			issues = append(issues, fmt.Sprintf("episode '%s': %v", e.Metadata.Name, err))
+			continue
+		}
+		if n == 0 {
+			tt, _ := time.Parse(time.RFC1123Z, e.PublishDate())
+			feed.AddPubDate(&tt)
+		}
+		n++
+	}
+	if n == 0 {
+		issues = append(issues, "can not build feed with zero episodes")
+	}
+
+	return feed.Bytes(), issues
+}
+
+// localAsset rewrites a local asset so that it points to the preview server.
+// Assets that will be imported are referenced at their source as they are not in the CDN yet.
+func (s *previewServer) localAsset(asset a.Asset, issues *[]string) a.Asset {
+	if asset.Rel == a.ResourceTypeImport {
+		asset.Rel = a.ResourceTypeExternal
+		return asset
+	}
+	if asset.Rel != a.ResourceTypeLocal {
+		return asset
+	}
+
+	path, found := findLocalAsset(s.dir, asset.URI)
+	if !found {
+		*issues = append(*issues, fmt.Sprintf("can not find local asset '%s'", asset.URI))
+	}
+
+	asset.URI = s.baseURL + previewAssetPrefix + filepath.ToSlash(path)
+	asset.Rel = a.ResourceTypeExternal
+	return asset
+}
+
+// previewStatus applies the same rules as the backend build to decide if an episode is part of the feed
+func previewStatus(e *a.Episode) string {
+	return e.StatusAt(util.Timestamp())
+}
+
+func (s *previewServer) feedHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.feed == nil {
+		http.Error(w, strings.Join(s.issues, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("content-type", "application/rss+xml; charset=utf-8")
+	w.Header().Set("cache-control", "no-cache")
+	w.Write(s.feed)
+}
+
+func (s *previewServer) indexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	w.Header().Set("cache-control", "no-cache")
+
+	err := previewTemplate.Execute(w, map[string]interface{}{
+		"Dir":      s.dir,
+		"Show":     s.show,
+		"Episodes": s.episodes,
+		"Issues":   s.issues,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>{{if .Show}}{{.Show.Description.Title}}{{else}}{{.Dir}}{{end}} - PodOps preview</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 0.3em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.issues { color: #b00; }
+.scheduled, .draft, .unpublished { color: #888; }
+</style>
+</head>
+<body>
+{{if .Show}}
+<h1>{{.Show.Description.Title}}</h1>
+<p>{{.Show.Description.Summary}}</p>
+{{else}}
+<h1>{{.Dir}}</h1>
+{{end}}
+<p><a href="/feed.xml">feed.xml</a></p>
+{{if .Issues}}
+<ul class="issues">{{range .Issues}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+<table>
+<tr><th>Season</th><th>Episode</th><th>Title</th><th>Date</th><th>Status</th><th>Enclosure</th></tr>
+{{range .Episodes}}
+<tr class="{{.Status}}">
+<td>{{index .Episode.Metadata.Labels "season"}}</td>
+<td>{{index .Episode.Metadata.Labels "episode"}}</td>
+<td>{{.Episode.Description.Title}}</td>
+<td>{{.Episode.PublishDate}}</td>
+<td>{{.Status}}</td>
+<td><a href="{{.Enclosure}}">{{.Episode.Enclosure.URI}}</a></td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+)
+
+// PublishCommand publishes a draft or unpublished episode
+func PublishCommand(c *cli.Context) error {
+	return changeStatus(c, a.EpisodeStatusPublished)
+}
+
+// UnpublishCommand removes an episode from the feed
+func UnpublishCommand(c *cli.Context) error {
+	return changeStatus(c, a.EpisodeStatusUnpublished)
+}
+
+// StatusCommand shows the lifecycle status of an episode and its history
+func StatusCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	r, err := findEpisode(c.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("Episode '%s' is %s.", r.Name, r.StatusAt(time.Now().Unix())))
+
+	l, err := client.AuditEntries(client.GUID, r.GUID)
+	if err != nil {
+		return err
+	}
+	if len(l.Entries) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(auditListing("DATE", "FROM", "TO", "BY"))
+	for _, e := range l.Entries {
+		fmt.Println(auditListing(time.Unix(e.Timestamp, 0).UTC().Format("2006-01-02 15:04"), e.From, e.To, e.ClientID))
+	}
+	return nil
+}
+
+func changeStatus(c *cli.Context, status string) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	r, err := findEpisode(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	r, err = client.ChangeStatus(client.GUID, r.GUID, status)
+	if err != nil {
+		return err
+	}
+	client.Store(defaultPathAndName)
+
+	fmt.Println(fmt.Sprintf("Episode '%s' is %s.", r.Name, r.StatusAt(time.Now().Unix())))
+	return nil
+}
+
+// findEpisode looks up an episode of the current production by its name or GUID
+func findEpisode(name string) (*a.Resource, error) {
+	l, err := client.Resources(client.GUID, a.ResourceEpisode)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range l.Resources {
+		if r.GUID == name || r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: episode '%s'", a.ErrNoSuchResource, name)
+}
@@ -21,3 +21,63 @@ func productionListing(guid, name, title string, current bool) string {
 func assetListing(guid, name, kind string) string {
 	return fmt.Sprintf("  %-20s%-50s%s", guid, name, kind)
 }
+
+func wideAssetListing(guid, name, kind, status, published, size, title string) string {
+	return fmt.Sprintf("  %-20s%-50s%-10s%-13s%-18s%-12s%s", guid, name, kind, status, published, size, title)
+}
+
+func planListing(action, kind, name, guid string) string {
+	return fmt.Sprintf("  %-12s%-10s%-50s%s", action, kind, name, guid)
+}
+
+func auditListing(date, from, to, by string) string {
+	return fmt.Sprintf("  %-18s%-13s%-13s%s", date, from, to, by)
+}
+
+func buildListing(id, date, episodes, checksum string, current bool) string {
+	if current {
+		return fmt.Sprintf("* %-20s%-18s%-10s%s", id, date, episodes, checksum)
+	}
+	return fmt.Sprintf("  %-20s%-18s%-10s%s", id, date, episodes, checksum)
+}
+
+func gcListing(guid, name, size, updated, note string) string {
+	return fmt.Sprintf("  %-20s%-50s%-12s%-18s%s", guid, name, size, updated, note)
+}
+
+func usageListing(metric, show, all, quota string) string {
+	return fmt.Sprintf("  %-20s%-14s%-14s%s", metric, show, all, quota)
+}
+
+// formatBytes formats 'b' bytes as a human readable size, e.g. 1.5 GB
+func formatBytes(b int64) string {
+	const unit = 1000
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "kMGTPE"[exp])
+}
+
+func fsckListing(issue, location, details, status string) string {
+	return fmt.Sprintf("  %-12s%-50s%s %s", issue, location, details, status)
+}
+
+func historyListing(rev, date, by string, current bool) string {
+	if current {
+		return fmt.Sprintf("* %-6s%-18s%s", rev, date, by)
+	}
+	return fmt.Sprintf("  %-6s%-18s%s", rev, date, by)
+}
+
+func webhookListing(id, status, events, url string) string {
+	return fmt.Sprintf("  %-20s%-10s%-40s%s", id, status, events, url)
+}
+
+func deliveryListing(id, date, event, attempts, status string) string {
+	return fmt.Sprintf("  %-20s%-18s%-20s%-10s%s", id, date, event, attempts, status)
+}
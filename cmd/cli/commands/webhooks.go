@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/urfave/cli/v2"
+)
+
+// WebhooksListCommand lists the webhooks of the current show
+func WebhooksListCommand(c *cli.Context) error {
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	l, err := client.Webhooks()
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	if len(l.Webhooks) == 0 {
+		fmt.Println("No webhooks to list.")
+		return nil
+	}
+
+	fmt.Println(webhookListing("ID", "STATUS", "EVENTS", "URL"))
+	for _, w := range l.Webhooks {
+		status := "active"
+		if !w.Active {
+			status = "disabled"
+		}
+		events := "all"
+		if len(w.Events) > 0 {
+			events = strings.Join(w.Events, ",")
+		}
+		fmt.Println(webhookListing(w.ID, status, events, w.URL))
+	}
+	return nil
+}
+
+// WebhooksAddCommand subscribes a URL to events of the current show
+func WebhooksAddCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	w, err := client.CreateWebhook(c.Args().First(), c.StringSlice("event"))
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Added webhook '%s'.", w.ID))
+	fmt.Println(fmt.Sprintf("Its secret is '%s'. Keep it safe, it is not shown again.", w.Secret))
+	return nil
+}
+
+// WebhooksRemoveCommand removes a webhook and its deliveries
+func WebhooksRemoveCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	if err := client.DeleteWebhook(c.Args().First()); err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Removed webhook '%s'.", c.Args().First()))
+	return nil
+}
+
+// WebhooksEnableCommand resumes the deliveries to a webhook
+func WebhooksEnableCommand(c *cli.Context) error {
+	return setWebhookActive(c, true)
+}
+
+// WebhooksDisableCommand pauses the deliveries to a webhook
+func WebhooksDisableCommand(c *cli.Context) error {
+	return setWebhookActive(c, false)
+}
+
+// WebhooksDeliveriesCommand lists the recent deliveries of a webhook
+func WebhooksDeliveriesCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	l, err := client.WebhookDeliveries(c.Args().First())
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	if len(l.Deliveries) == 0 {
+		fmt.Println("No deliveries to list.")
+		return nil
+	}
+
+	fmt.Println(deliveryListing("ID", "DATE", "EVENT", "ATTEMPTS", "STATUS"))
+	for _, d := range l.Deliveries {
+		fmt.Println(deliveryListing(d.ID, time.Unix(d.Created, 0).UTC().Format("2006-01-02 15:04"), d.Event, fmt.Sprintf("%d", d.Attempts), deliveryStatus(d)))
+	}
+	return nil
+}
+
+// WebhooksReplayCommand delivers the event of a previous delivery again
+func WebhooksReplayCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	d, err := client.ReplayDelivery(c.Args().First())
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+
+	fmt.Println(fmt.Sprintf("Replaying '%s' as delivery '%s'.", d.Event, d.ID))
+	return nil
+}
+
+func setWebhookActive(c *cli.Context, active bool) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("wrong number of arguments: expected 1, got %d", c.NArg())
+	}
+	if err := client.HasTokenAndGUID(); err != nil {
+		return err
+	}
+
+	l, err := client.Webhooks()
+	if err != nil {
+		printError(c, err)
+		return nil
+	}
+	id := c.Args().First()
+	for _, w := range l.Webhooks {
+		if w.ID != id {
+			continue
+		}
+		w.Active = active
+		if _, err := client.UpdateWebhook(w); err != nil {
+			printError(c, err)
+			return nil
+		}
+		if active {
+			fmt.Println(fmt.Sprintf("Enabled webhook '%s'.", id))
+		} else {
+			fmt.Println(fmt.Sprintf("Disabled webhook '%s'.", id))
+		}
+		return nil
+	}
+
+	printError(c, a.ErrNoSuchWebhook)
+	return nil
+}
+
+// deliveryStatus summarizes the outcome of a delivery
+func deliveryStatus(d *a.WebhookDelivery) string {
+	switch {
+	case d.Delivered:
+		return fmt.Sprintf("delivered (%d)", d.Status)
+	case d.NextAttempt > 0 && d.Attempts == 0:
+		return "pending"
+	case d.NextAttempt > 0:
+		return fmt.Sprintf("retrying at %s: %s", time.Unix(d.NextAttempt, 0).UTC().Format("2006-01-02 15:04"), d.Error)
+	default:
+		return fmt.Sprintf("failed: %s", d.Error)
+	}
+}
@@ -61,6 +61,13 @@ func setupCommands() []*cli.Command {
 			Action:    cmd.NewProductionCommand,
 			Flags:     newShowFlags(),
 		},
+		{
+			Name:      "import-feed",
+			Usage:     "Setup a new show/production from an existing podcast feed",
+			UsageText: "import-feed URL [NAME]",
+			Category:  cmd.BasicCmdGroup,
+			Action:    cmd.ImportFeedCommand,
+		},
 		{
 			Name:      "template",
 			Usage:     "Create a resource template with default values",
@@ -70,6 +77,22 @@ func setupCommands() []*cli.Command {
 			Flags:     templateFlags(),
 		},
 
+		// Administrative commands
+		{
+			Name:     "admin",
+			Usage:    "Administrative commands",
+			Category: cmd.AdminCmdGroup,
+			Subcommands: []*cli.Command{
+				{
+					Name:      "fsck",
+					Usage:     "Check the inventory of a show for missing, orphaned or mismatched files",
+					UsageText: fsckUsageText,
+					Action:    cmd.FsckCommand,
+					Flags:     fsckFlags(),
+				},
+			},
+		},
+
 		// Settings
 		{
 			Name:      "auth",
@@ -91,7 +114,7 @@ func setupCommands() []*cli.Command {
 			UsageText: getUsageText,
 			Category:  cmd.ShowCmdGroup,
 			Action:    cmd.ListResourcesCommand,
-			//Flags:     createFlags(),
+			Flags:     getFlags(),
 		},
 		{
 			Name:      "create",
@@ -109,6 +132,21 @@ func setupCommands() []*cli.Command {
 			Action:    cmd.UpdateCommand,
 			Flags:     createFlags(),
 		},
+		{
+			Name:      "apply",
+			Usage:     "Sync all resources in a directory with the show/production",
+			UsageText: applyUsageText,
+			Category:  cmd.ShowCmdGroup,
+			Action:    cmd.ApplyCommand,
+			Flags:     applyFlags(),
+		},
+		{
+			Name:      "diff",
+			Usage:     "Compare local resources with the show/production",
+			UsageText: "diff [FILENAME|DIR]",
+			Category:  cmd.ShowCmdGroup,
+			Action:    cmd.DiffCommand,
+		},
 		{
 			Name:      "upload",
 			Usage:     "Upload an asset from a file",
@@ -117,6 +155,14 @@ func setupCommands() []*cli.Command {
 			Action:    cmd.UploadCommand,
 			Flags:     createFlags(),
 		},
+		{
+			Name:      "preview",
+			Usage:     "Preview the feed of a local directory",
+			UsageText: previewUsageText,
+			Category:  cmd.ShowCmdGroup,
+			Action:    cmd.PreviewCommand,
+			Flags:     previewFlags(),
+		},
 		{
 			Name:      "build",
 			Usage:     "Start a new build",
@@ -124,12 +170,151 @@ func setupCommands() []*cli.Command {
 			Category:  cmd.ShowMgmtCmdGroup,
 			Action:    cmd.BuildCommand,
 		},
+		{
+			Name:      "feed",
+			Usage:     "List previous builds of the feed, publish a previous build again",
+			UsageText: feedUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Subcommands: []*cli.Command{
+				{
+					Name:      "history",
+					Usage:     "List all builds of the feed",
+					UsageText: "po feed history",
+					Action:    cmd.FeedHistoryCommand,
+				},
+				{
+					Name:      "rollback",
+					Usage:     "Publish the feed of a previous build",
+					UsageText: "po feed rollback BUILD_ID",
+					Action:    cmd.FeedRollbackCommand,
+				},
+			},
+		},
+		{
+			Name:      "webhooks",
+			Usage:     "Manage webhooks, list and replay their deliveries",
+			UsageText: webhooksUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Subcommands: []*cli.Command{
+				{
+					Name:      "list",
+					Usage:     "List all webhooks of the show",
+					UsageText: "po webhooks list",
+					Action:    cmd.WebhooksListCommand,
+				},
+				{
+					Name:      "add",
+					Usage:     "Send events of the show to a URL",
+					UsageText: "po webhooks add [--event EVENT ...] URL",
+					Action:    cmd.WebhooksAddCommand,
+					Flags:     webhooksAddFlags(),
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove a webhook and its deliveries",
+					UsageText: "po webhooks remove ID",
+					Action:    cmd.WebhooksRemoveCommand,
+				},
+				{
+					Name:      "enable",
+					Usage:     "Resume sending events to a webhook",
+					UsageText: "po webhooks enable ID",
+					Action:    cmd.WebhooksEnableCommand,
+				},
+				{
+					Name:      "disable",
+					Usage:     "Pause sending events to a webhook",
+					UsageText: "po webhooks disable ID",
+					Action:    cmd.WebhooksDisableCommand,
+				},
+				{
+					Name:      "deliveries",
+					Usage:     "List the recent deliveries of a webhook",
+					UsageText: "po webhooks deliveries ID",
+					Action:    cmd.WebhooksDeliveriesCommand,
+				},
+				{
+					Name:      "replay",
+					Usage:     "Deliver the event of a previous delivery again",
+					UsageText: "po webhooks replay DELIVERY_ID",
+					Action:    cmd.WebhooksReplayCommand,
+				},
+			},
+		},
+		{
+			Name:      "publish",
+			Usage:     "Publish a draft or unpublished episode",
+			UsageText: "po publish NAME",
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.PublishCommand,
+		},
+		{
+			Name:      "unpublish",
+			Usage:     "Remove an episode from the feed",
+			UsageText: "po unpublish NAME",
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.UnpublishCommand,
+		},
+		{
+			Name:      "status",
+			Usage:     "Show the status of an episode and who changed it",
+			UsageText: "po status NAME",
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.StatusCommand,
+		},
+		{
+			Name:      "gc",
+			Usage:     "Delete assets that no show or episode references",
+			UsageText: gcUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.GCCommand,
+			Flags:     gcFlags(),
+		},
+		{
+			Name:      "usage",
+			Usage:     "Show the storage, egress, episodes and builds used and the quota",
+			UsageText: "po usage",
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.UsageCommand,
+		},
+		{
+			Name:      "history",
+			Usage:     "List all revisions of the show or an episode",
+			UsageText: "po history NAME",
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.HistoryCommand,
+		},
+		{
+			Name:      "rollback",
+			Usage:     "Restore a previous revision of the show or an episode",
+			UsageText: rollbackUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.RollbackCommand,
+			Flags:     rollbackFlags(),
+		},
+		{
+			Name:      "migrate",
+			Usage:     "Move the show/production to another host, or redirect its previous feed",
+			UsageText: migrateUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.MigrateCommand,
+			Flags:     migrateFlags(),
+		},
+		{
+			Name:      "export",
+			Usage:     "Export a show/production into a directory",
+			UsageText: exportUsageText,
+			Category:  cmd.ShowMgmtCmdGroup,
+			Action:    cmd.ExportCommand,
+			Flags:     exportFlags(),
+		},
 		{
 			Name:      "delete",
 			Usage:     "Delete a resource",
-			UsageText: "po delete [show|episode] NAME",
+			UsageText: deleteUsageText,
 			Category:  cmd.ShowMgmtCmdGroup,
 			Action:    cmd.DeleteResourcesCommand,
+			Flags:     deleteFlags(),
 		},
 	}
 	return c
@@ -173,6 +358,154 @@ func createFlags() []cli.Flag {
 	return f
 }
 
+func deleteFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "force",
+			Usage:   "Delete the resource even if it was modified since it was last read",
+			Aliases: []string{"f"},
+		},
+		&cli.BoolFlag{
+			Name:  "yes",
+			Usage: "Do not ask for confirmation before deleting a show",
+		},
+	}
+	return f
+}
+
+func getFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "Output format: table|wide|yaml|json|go-template=...",
+			Aliases: []string{"o"},
+			Value:   cmd.OutputTable,
+		},
+		&cli.BoolFlag{
+			Name:  "scheduled",
+			Usage: "List only episodes with a publish date in the future",
+		},
+		&cli.StringFlag{
+			Name:  "status",
+			Usage: "List only episodes with status draft|scheduled|published|unpublished",
+		},
+	}
+	return f
+}
+
+func exportFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "assets",
+			Usage:   "Download all assets",
+			Aliases: []string{"a"},
+		},
+		&cli.BoolFlag{
+			Name:  "archive",
+			Usage: "Export everything stored for the show into a single .zip file",
+		},
+	}
+	return f
+}
+
+func migrateFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "The feed's new URL",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "The feed's previous URL",
+		},
+		&cli.BoolFlag{
+			Name:  "cancel",
+			Usage: "Cancel a move",
+		},
+	}
+	return f
+}
+
+func webhooksAddFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "event",
+			Usage:   "Only send this event, repeat for several events. Sends all events if omitted.",
+			Aliases: []string{"e"},
+		},
+	}
+	return f
+}
+
+func gcFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only list the unreferenced assets, do not delete them",
+		},
+		&cli.DurationFlag{
+			Name:  "grace",
+			Usage: "Keep unreferenced assets changed within this period, e.g. 24h (default: 168h)",
+		},
+	}
+	return f
+}
+
+func fsckFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "repair",
+			Usage: "Repair the inventory",
+		},
+	}
+	return f
+}
+
+func rollbackFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.IntFlag{
+			Name:  "to",
+			Usage: "The revision to restore",
+		},
+	}
+	return f
+}
+
+func applyFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "force",
+			Usage:   "Force update/upload of all resources",
+			Aliases: []string{"f"},
+		},
+		&cli.BoolFlag{
+			Name:  "prune",
+			Usage: "Delete episodes that do not exist in the directory",
+		},
+		&cli.BoolFlag{
+			Name:  "remap",
+			Usage: "Copy resources exported from another show into the current show",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only print the changes, do not apply them",
+		},
+	}
+	return f
+}
+
+func previewFlags() []cli.Flag {
+	f := []cli.Flag{
+		&cli.IntFlag{
+			Name:    "port",
+			Usage:   "Port of the preview server",
+			Aliases: []string{"p"},
+			Value:   8080,
+		},
+	}
+	return f
+}
+
 func templateFlags() []cli.Flag {
 	f := []cli.Flag{
 		/*
@@ -229,5 +562,120 @@ To see the full list of supported commands, run 'po help'`
 	 po get [show|episode]
 
 	 # Show details about a resource
-	 po get [show|episode] NAME`
+	 po get [show|episode] NAME
+
+	 # Use a different output format
+	 po get -o [table|wide|yaml|json] [show|episode]
+
+	 # Use a Go template
+	 po get -o go-template='{{.Name}}' episode
+
+	 # List episodes that are scheduled for publishing
+	 po get -o wide --scheduled episode
+
+	 # List episodes by status
+	 po get --status [draft|scheduled|published|unpublished] episode`
+
+	exportUsageText = `export NAME DIR
+
+	 # Export all shows/episodes to DIR
+	 po export NAME DIR
+
+	 # Export all shows/episodes and all assets
+	 po export --assets NAME DIR
+
+	 # Export everything, including revisions and previous feeds, into a .zip
+	 po export --archive NAME FILE`
+
+	deleteUsageText = `delete [show|episode] NAME
+
+	 # Delete an episode
+	 po delete episode NAME
+
+	 # Delete the current show with all episodes, assets and feeds.
+	 # A .zip archive of the show is exported first.
+	 po delete show
+
+	 # Delete a show without confirmation
+	 po delete --yes show NAME`
+
+	migrateUsageText = `migrate [--to URL|--from URL|--cancel]
+
+	 # Move the show to another host. The feed redirects to URL.
+	 po migrate --to URL
+
+	 # Undo the move
+	 po migrate --cancel
+
+	 # Redirect requests for the feed's previous URL to the show
+	 po migrate --from URL`
+
+	feedUsageText = `feed [history|rollback BUILD_ID]
+
+	 # List all builds of the feed
+	 po feed history
+
+	 # Publish the feed of a previous build. The next build replaces it again.
+	 po feed rollback BUILD_ID`
+
+	webhooksUsageText = `webhooks [list|add|remove|enable|disable|deliveries|replay]
+
+	 # Send all events of the show to a URL. Every request is signed with the webhook's
+	 # secret, see header X-Podops-Signature.
+	 po webhooks add https://example.com/hook
+
+	 # Only send some events: episode.created, episode.published, build.succeeded,
+	 # build.failed or asset.imported
+	 po webhooks add --event build.succeeded --event build.failed URL
+
+	 # List the recent deliveries of a webhook and deliver one of them again
+	 po webhooks deliveries ID
+	 po webhooks replay DELIVERY_ID`
+
+	gcUsageText = `gc [--dry-run] [--grace DURATION]
+
+	 # List the assets that are not used by the show or any episode
+	 po gc --dry-run
+
+	 # Delete them, including assets uploaded within the last hour
+	 po gc --grace 1h`
+
+	fsckUsageText = `po admin fsck [NAME]
+
+	 # Check the current show
+	 po admin fsck
+
+	 # Add orphaned files to the inventory, remove entries without files
+	 # and update entries that do not match their files
+	 po admin fsck --repair NAME`
+
+	rollbackUsageText = `rollback --to REV NAME
+
+	 # List the revisions of the show or an episode
+	 po history NAME
+
+	 # Restore revision REV. This creates a new revision.
+	 po rollback --to REV NAME`
+
+	applyUsageText = `apply [DIR]
+
+	 # Sync the current directory with the show/production
+	 po apply
+
+	 # Show what would change, without applying it
+	 po apply --dry-run DIR
+
+	 # Sync and delete episodes that only exist in the show/production
+	 po apply --prune DIR
+
+	 # Copy a show exported with 'po export' into the current show/production
+	 po apply --remap DIR`
+
+	previewUsageText = `preview [DIR]
+
+	 # Preview the show and episodes in the current directory
+	 po preview
+
+	 # Preview a directory on a different port
+	 po preview --port 9000 DIR`
 )
@@ -0,0 +1,74 @@
+package main
+
+// A stub receiver for local testing of feed notifications. It answers podping-style pings
+// (GET /?url=FEED&reason=update), confirms WebSub subscriptions by echoing hub.challenge
+// and logs the feeds the hub delivers, verifying their signature if a secret is given.
+//
+//	go run ./cmd/receiver -port 8090 -secret SECRET
+//
+// Configure it with e.g. PODPING_ENDPOINTS=http://localhost:8090/ping and subscribe to a feed with
+//
+//	curl -d hub.mode=subscribe -d hub.topic=FEED -d hub.callback=http://localhost:8090/websub -d hub.secret=SECRET HUB
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/podops/podops/pkg/backend"
+)
+
+var secret string
+
+func main() {
+	port := flag.Int("port", 8090, "The port to listen on")
+	flag.StringVar(&secret, "secret", "", "The hub.secret used to subscribe, verifies the signature of deliveries")
+	flag.Parse()
+
+	http.HandleFunc("/ping", pingHandler)
+	http.HandleFunc("/websub", websubHandler)
+
+	log.Printf("Receiving pings at http://localhost:%d/ping and WebSub deliveries at http://localhost:%d/websub", *port, *port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+}
+
+// pingHandler logs a podping-style notification
+func pingHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("url") == "" {
+		http.Error(w, "missing parameter 'url'", http.StatusBadRequest)
+		return
+	}
+	log.Printf("ping: url=%s reason=%s medium=%s", q.Get("url"), q.Get("reason"), q.Get("medium"))
+	fmt.Fprint(w, "Success!")
+}
+
+// websubHandler confirms subscriptions and logs deliveries
+func websubHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		log.Printf("verify: mode=%s topic=%s lease=%s", q.Get("hub.mode"), q.Get("hub.topic"), q.Get("hub.lease_seconds"))
+		fmt.Fprint(w, q.Get("hub.challenge"))
+
+	case http.MethodPost:
+		feed, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		signature := r.Header.Get(backend.HubSignatureHeader)
+		if secret != "" && signature != backend.Sign(secret, feed) {
+			log.Printf("delivery: invalid signature '%s'", signature)
+			http.Error(w, "invalid signature", http.StatusBadRequest)
+			return
+		}
+		log.Printf("delivery: %d bytes, link=%s", len(feed), r.Header.Get("Link"))
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
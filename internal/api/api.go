@@ -27,6 +27,9 @@ const (
 	// VersionRoute route to VersionEndpoint
 	VersionRoute = "/version"
 
+	// MetricsRoute route to the Prometheus metrics of a service
+	MetricsRoute = "/metrics"
+
 	// AuthenticationRoute is used to create and verify a token
 	AuthenticationRoute = "/token"
 
@@ -36,6 +39,18 @@ const (
 	// ListProductionsRoute route to ListProductionsEndpoint
 	ListProductionsRoute = "/productions"
 
+	// DeleteProductionRoute route to DeleteProductionEndpoint
+	DeleteProductionRoute = "/production/:id"
+
+	// ExportProductionRoute route to ExportProductionEndpoint
+	ExportProductionRoute = "/production/:id/export"
+
+	// ImportFeedRoute route to ImportFeedEndpoint
+	ImportFeedRoute = "/import"
+
+	// MigrateRoute route to MigrateEndpoint
+	MigrateRoute = "/migrate"
+
 	// GetResourceRoute route to ResourceEndpoint
 	GetResourceRoute = "/resource/:prod/:kind/:id"
 
@@ -48,12 +63,54 @@ const (
 	// DeleteResourceRoute route to ResourceEndpoint
 	DeleteResourceRoute = "/resource/:prod/:kind/:id"
 
+	// StatusRoute route to StatusEndpoint
+	StatusRoute = "/status/:prod/:id"
+
+	// AuditRoute route to AuditEndpoint
+	AuditRoute = "/audit/:prod/:id"
+
+	// HistoryRoute route to HistoryEndpoint
+	HistoryRoute = "/history/:prod/:id"
+
+	// RollbackRoute route to RollbackEndpoint
+	RollbackRoute = "/rollback/:prod/:id"
+
+	// GCRoute route to GCEndpoint GET,POST
+	GCRoute = "/gc/:prod"
+
+	// FsckRoute route to FsckEndpoint GET,POST
+	FsckRoute = "/fsck/:prod"
+
+	// UsageRoute route to UsageEndpoint
+	UsageRoute = "/usage/:prod"
+
+	// QuotaRoute route to QuotaEndpoint
+	QuotaRoute = "/quota"
+
 	// BuildRoute route to BuildEndpoint
 	BuildRoute = "/build"
 
+	// ListBuildsRoute route to ListBuildsEndpoint
+	ListBuildsRoute = "/builds/:prod"
+
+	// RestoreBuildRoute route to RestoreBuildEndpoint
+	RestoreBuildRoute = "/builds/:prod/:id"
+
 	// UploadRoute route to UploadEndpoint
 	UploadRoute = "/upload/:prod"
 
+	// WebhooksRoute route to ListWebhooksEndpoint GET and CreateWebhookEndpoint POST
+	WebhooksRoute = "/webhooks/:prod"
+
+	// WebhookRoute route to UpdateWebhookEndpoint PUT and DeleteWebhookEndpoint DELETE
+	WebhookRoute = "/webhooks/:prod/:id"
+
+	// WebhookDeliveriesRoute route to WebhookDeliveriesEndpoint
+	WebhookDeliveriesRoute = "/webhooks/:prod/:id/deliveries"
+
+	// WebhookReplayRoute route to ReplayDeliveryEndpoint, :id is the delivery
+	WebhookReplayRoute = "/webhooks/:prod/:id/replay"
+
 	// ShowRoute route to show.json
 	ShowRoute = "/s/:name"
 
@@ -66,6 +123,12 @@ const (
 	// DefaultCDNRoute route to /:guid/:asset
 	DefaultCDNRoute = "/:guid/:asset"
 
+	// SearchRoute route to SearchEndpoint
+	SearchRoute = "/search"
+
+	// HubRoute route to HubEndpoint, the WebSub hub
+	HubRoute = "/hub"
+
 	// GraphqlRoute route to GraphqlEndpoint
 	GraphqlRoute = "/query"
 
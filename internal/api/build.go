@@ -3,8 +3,8 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
-	"github.com/fupas/commons/pkg/util"
 	"github.com/labstack/echo/v4"
 	a "github.com/podops/podops/apiv1"
 	"github.com/podops/podops/internal/platform"
@@ -35,22 +35,26 @@ func BuildEndpoint(c echo.Context) error {
 	if p == nil {
 		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid guid '%s'", req.GUID))
 	}
+	if err := backend.CheckQuota(ctx, p.Owner, 0, 0, 1); err != nil {
+		return api.ErrorResponse(c, quotaStatus(err, http.StatusInternalServerError), err)
+	}
 
 	// FIXME make this async, make validateOnly a flag
+	// Build also updates the PRODUCTION record
 	if err := backend.Build(ctx, req.GUID, false); err != nil {
 		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("error building feed '%s': %v", req.GUID, err))
 	}
 
-	// update the PRODUCTION record
-	p.BuildDate = util.Timestamp()
-	if err := backend.UpdateProduction(ctx, p); err != nil {
-		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	p, err = backend.GetProduction(ctx, req.GUID)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusNotFound, err)
 	}
 
 	resp := a.Build{
 		GUID:         req.GUID,
 		FeedURL:      fmt.Sprintf("%s/c/%s/feed.xml", a.DefaultCDNEndpoint, req.GUID),
 		FeedAliasURL: fmt.Sprintf("%s/s/%s/feed.xml", a.DefaultPortalEndpoint, p.Name),
+		BuildID:      p.BuildID,
 	}
 
 	// track api access for billing etc
@@ -58,3 +62,98 @@ func BuildEndpoint(c echo.Context) error {
 
 	return api.StandardResponse(c, http.StatusCreated, &resp)
 }
+
+// ListBuildsEndpoint returns all feeds that were built for a production
+func ListBuildsEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+
+	l, err := backend.ListFeedSnapshots(appengine.NewContext(c.Request()), prod)
+	if err != nil {
+		if err == a.ErrNoSuchProduction {
+			return api.ErrorResponse(c, http.StatusNotFound, err)
+		}
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "build_list", prod, 1)
+
+	return api.StandardResponse(c, http.StatusOK, l)
+}
+
+// RestoreBuildEndpoint publishes the feed of a previous build again
+func RestoreBuildEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	buildID := c.Param("id")
+	if buildID == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+
+	snapshot, err := backend.RestoreFeed(appengine.NewContext(c.Request()), prod, buildID)
+	if err != nil {
+		if err == a.ErrNoSuchProduction {
+			return api.ErrorResponse(c, http.StatusNotFound, err)
+		}
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "build_restore", fmt.Sprintf("%s/%s", prod, buildID), 1)
+
+	return api.StandardResponse(c, http.StatusOK, snapshot)
+}
+
+// GCEndpoint lists the assets no show or episode references. POST removes them.
+func GCEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	grace := a.GCGracePeriod
+	if g := c.QueryParam("grace"); g != "" {
+		n, err := strconv.ParseInt(g, 10, 64)
+		if err != nil || n < 0 {
+			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid grace period '%s'", g))
+		}
+		grace = n
+	}
+	ctx := appengine.NewContext(c.Request())
+
+	p, err := backend.GetProduction(ctx, prod)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+	clientID, _ := auth.GetClientID(c)
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	dryRun := c.Request().Method != "POST"
+	report, err := backend.CollectGarbage(ctx, prod, grace, dryRun)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "gc", fmt.Sprintf("%s/%v", prod, dryRun), 1)
+
+	return api.StandardResponse(c, http.StatusOK, report)
+}
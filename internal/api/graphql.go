@@ -1,25 +1,78 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 
+	"github.com/podops/podops/internal/dataloader"
 	"github.com/podops/podops/internal/gql/graph"
 	"github.com/podops/podops/internal/gql/graph/generated"
 	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/auth"
 )
 
 // GetGraphqlEndpoint maps the Graphql handler to gin
 func GetGraphqlEndpoint() echo.HandlerFunc {
-	h := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: graph.CreateResolver()}))
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: graph.CreateResolver()}))
+
+	// subscriptions authenticate when the connection is initialized
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketInit,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true }, // same as the CORS policy
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
+
+	// batches and results of the dataloaders are scoped to a request
+	h := dataloader.Middleware(srv)
 
 	return func(e echo.Context) error {
-		h.ServeHTTP(e.Response(), e.Request())
+		req := e.Request()
+		// queries are public, mutations need the client that the token belongs to
+		if auth.GetBearerToken(e) != "" {
+			if clientID, err := auth.GetClientID(e); err == nil {
+				req = req.WithContext(auth.WithClientID(req.Context(), clientID))
+			}
+		}
+		h.ServeHTTP(e.Response(), req)
 		return nil
 	}
 }
 
+// websocketInit adds the client to the context of a subscription if the init payload carries a token
+func websocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+	token := strings.TrimPrefix(payload.Authorization(), "Bearer ")
+	if token == "" {
+		return ctx, nil // public queries
+	}
+	clientID, err := auth.ClientIDFromToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return auth.WithClientID(ctx, clientID), nil
+}
+
 // GetGraphqlPlaygroundEndpoint maps the Playground handler to gin
 func GetGraphqlPlaygroundEndpoint() echo.HandlerFunc {
 	h := playground.Handler("GraphQL", api.GraphqlNamespacePrefix+api.GraphqlRoute)
@@ -70,3 +70,166 @@ func ListProductionsEndpoint(c echo.Context) error {
 
 	return api.StandardResponse(c, http.StatusOK, &a.ProductionList{Productions: productions})
 }
+
+// ImportFeedEndpoint creates a new production from an existing podcast feed
+func ImportFeedEndpoint(c echo.Context) error {
+	var req *a.FeedImport = new(a.FeedImport)
+
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	err := c.Bind(req)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	if req.URL == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("missing feed url"))
+	}
+
+	// an empty name is derived from the feed's title
+	showName := strings.ToLower(strings.TrimSpace(req.Name))
+	if showName != "" && !a.ValidResourceName(showName) {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid name '%s'", showName))
+	}
+
+	clientID, _ := auth.GetClientID(c)
+	resp, err := backend.ImportFeed(appengine.NewContext(c.Request()), req.URL, showName, clientID)
+	if err != nil {
+		return api.ErrorResponse(c, quotaStatus(err, http.StatusBadRequest), err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "prod_import", resp.GUID, 1)
+
+	return api.StandardResponse(c, http.StatusCreated, resp)
+}
+
+// MigrateEndpoint moves a production to another host or registers the feed's previous location
+func MigrateEndpoint(c echo.Context) error {
+	var req *a.FeedMigration = new(a.FeedMigration)
+
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	err := c.Bind(req)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	ctx := appengine.NewContext(c.Request())
+
+	p, err := backend.GetProduction(ctx, req.GUID)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+	clientID, _ := auth.GetClientID(c)
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	if err := backend.MigrateProduction(ctx, p, req); err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "prod_migrate", p.GUID, 1)
+
+	return api.StandardResponse(c, http.StatusOK, p)
+}
+
+// DeleteProductionEndpoint deletes a production and all its resources, assets and feeds
+func DeleteProductionEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+	ctx := appengine.NewContext(c.Request())
+
+	p, err := backend.GetProduction(ctx, guid)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+	clientID, _ := auth.GetClientID(c)
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	if err := backend.DeleteProduction(ctx, guid); err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "prod_delete", guid, 1)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ExportProductionEndpoint returns a zip archive of everything stored for a production
+func ExportProductionEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+	ctx := appengine.NewContext(c.Request())
+
+	p, err := backend.GetProduction(ctx, guid)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+	clientID, _ := auth.GetClientID(c)
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "prod_export", guid, 1)
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s-%s.zip\"", p.Name, p.GUID))
+	c.Response().WriteHeader(http.StatusOK)
+
+	// the response is streamed, errors can only be reported by truncating the archive
+	return backend.ExportProduction(ctx, p, c.Response())
+}
+
+// FsckEndpoint cross-checks the inventory of a production with its files. POST repairs the inconsistencies.
+func FsckEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	ctx := appengine.NewContext(c.Request())
+
+	p, err := backend.GetProduction(ctx, prod)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+	clientID, _ := auth.GetClientID(c)
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	repair := c.Request().Method == "POST"
+	report, err := backend.CheckProduction(ctx, prod, repair)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "prod_fsck", fmt.Sprintf("%s/%v", prod, repair), 1)
+
+	return api.StandardResponse(c, http.StatusOK, report)
+}
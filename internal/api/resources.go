@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/fupas/commons/pkg/util"
 	"github.com/labstack/echo/v4"
 	a "github.com/podops/podops/apiv1"
 	"github.com/podops/podops/internal/platform"
@@ -35,7 +37,8 @@ func GetResourceEndpoint(c echo.Context) error {
 
 	// FIXME prod, kind are ignored, assumption is that guid is globally unique ...
 
-	resource, err := backend.GetResourceContent(appengine.NewContext(c.Request()), guid)
+	ctx := appengine.NewContext(c.Request())
+	resource, err := backend.GetResourceContent(ctx, guid)
 	if err != nil {
 		return api.ErrorResponse(c, http.StatusBadRequest, err)
 	}
@@ -46,6 +49,9 @@ func GetResourceEndpoint(c echo.Context) error {
 	if resource == nil {
 		return api.StandardResponse(c, http.StatusNotFound, nil)
 	}
+	if r, _ := backend.GetResource(ctx, guid); r != nil {
+		c.Response().Header().Set("ETag", api.ETag(r.Revision))
+	}
 	return api.StandardResponse(c, http.StatusOK, resource)
 
 }
@@ -69,6 +75,9 @@ func ListResourcesEndpoint(c echo.Context) error {
 	if err != nil {
 		return api.ErrorResponse(c, http.StatusBadRequest, err)
 	}
+	if status := c.QueryParam("status"); status != "" {
+		l = backend.FilterResources(l, status)
+	}
 
 	// track api access for billing etc
 	platform.TrackEvent(c.Request(), "api", "rsrc_list", fmt.Sprintf("%s/%s", prod, kind), 1)
@@ -100,44 +109,50 @@ func UpdateResourceEndpoint(c echo.Context) error {
 		forceFlag = true
 	}
 
-	var payload interface{}
 	ctx := appengine.NewContext(c.Request())
-	location := fmt.Sprintf("%s/%s-%s.yaml", prod, kind, guid)
 
+	// the revision the client expects, compared again when the resource is written
+	var expected *int
+	if c.Request().Method == "PUT" && !forceFlag {
+		rev, status, err := checkRevision(c, guid)
+		if err != nil {
+			return api.ErrorResponse(c, status, err)
+		}
+		expected = rev
+	}
+
+	createFlag := true // POST
+	action := "rsrc_create"
+
+	if c.Request().Method == "PUT" {
+		createFlag = false
+		action = "rsrc_update"
+	}
+	clientID, _ := auth.GetClientID(c)
+
+	var rev *a.Revision
 	if kind == a.ResourceShow {
 		var show *a.Show = new(a.Show)
 
 		if err := c.Bind(show); err != nil {
 			return api.ErrorResponse(c, http.StatusInternalServerError, err)
 		}
-		payload = &show
 
 		if prod != show.GUID() {
 			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf(":prod and GUID do not match. expected '%s', got '%s'", prod, show.GUID()))
 		}
 
-		// update the PRODUCTION entry based on resource
-		p, err := backend.GetProduction(ctx, show.GUID())
+		r, err := backend.StoreShow(ctx, show, clientID, createFlag, forceFlag, expected)
 		if err != nil {
-			return api.ErrorResponse(c, http.StatusNotFound, err)
-		}
-
-		// the attributes we copy from the .yaml
-		p.Title = show.Description.Title
-		p.Summary = show.Description.Summary
-		p.Updated = util.Timestamp()
-
-		if err := backend.UpdateProduction(ctx, p); err != nil {
-			return api.ErrorResponse(c, http.StatusBadRequest, err)
-		}
-
-		if err := backend.EnsureAsset(ctx, show.GUID(), &show.Image); err != nil {
-			return api.ErrorResponse(c, http.StatusBadRequest, err)
-		}
-
-		if err := backend.UpdateShow(ctx, location, show); err != nil {
+			if err == a.ErrNoSuchProduction {
+				return api.ErrorResponse(c, http.StatusNotFound, err)
+			}
+			if errors.Is(err, a.ErrRevisionConflict) {
+				return api.ErrorResponse(c, http.StatusPreconditionFailed, err)
+			}
 			return api.ErrorResponse(c, http.StatusBadRequest, err)
 		}
+		rev = r
 
 	} else if kind == a.ResourceEpisode {
 		var episode *a.Episode = new(a.Episode)
@@ -145,38 +160,34 @@ func UpdateResourceEndpoint(c echo.Context) error {
 		if err := c.Bind(episode); err != nil {
 			return api.ErrorResponse(c, http.StatusInternalServerError, err)
 		}
-		payload = &episode
 
 		if prod != episode.ParentGUID() {
 			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf(":prod and GUID do not match. expected '%s', got '%s'", prod, episode.ParentGUID()))
 		}
-
-		// ensure images and media files
-		if err := backend.EnsureAsset(ctx, episode.ParentGUID(), &episode.Image); err != nil {
-			return api.ErrorResponse(c, http.StatusBadRequest, err)
+		if guid != episode.GUID() {
+			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf(":id and GUID do not match. expected '%s', got '%s'", guid, episode.GUID()))
 		}
 
-		if err := backend.EnsureAsset(ctx, episode.ParentGUID(), &episode.Enclosure); err != nil {
-			return api.ErrorResponse(c, http.StatusBadRequest, err)
+		if createFlag {
+			if status, err := checkQuota(ctx, prod, 0, 1); err != nil {
+				return api.ErrorResponse(c, status, err)
+			}
 		}
 
-		if err := backend.UpdateEpisode(ctx, location, episode); err != nil {
+		r, err := backend.StoreEpisode(ctx, episode, clientID, createFlag, forceFlag, expected)
+		if err != nil {
+			if errors.Is(err, a.ErrRevisionConflict) {
+				return api.ErrorResponse(c, http.StatusPreconditionFailed, err)
+			}
 			return api.ErrorResponse(c, http.StatusBadRequest, err)
 		}
+		rev = r
+
 	} else {
 		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("unsupported kind '%s'", kind))
 	}
 
-	createFlag := true // POST
-	action := "rsrc_create"
-
-	if c.Request().Method == "PUT" {
-		createFlag = false
-		action = "rsrc_update"
-	}
-	if err := backend.WriteResourceContent(ctx, location, createFlag, forceFlag, payload); err != nil {
-		return api.ErrorResponse(c, http.StatusBadRequest, err)
-	}
+	c.Response().Header().Set("ETag", api.ETag(rev.Revision))
 
 	// track api access for billing etc
 	platform.TrackEvent(c.Request(), "api", action, fmt.Sprintf("%s/%s/%s", prod, kind, guid), 1)
@@ -203,9 +214,21 @@ func DeleteResourceEndpoint(c echo.Context) error {
 		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
 	}
 
+	var expected *int
+	if c.QueryParam("f") != "true" {
+		rev, status, err := checkRevision(c, guid)
+		if err != nil {
+			return api.ErrorResponse(c, status, err)
+		}
+		expected = rev
+	}
+
 	// FIXME prod, kind are ignored, assumption is that guid is globally unique ...
 
-	if err := backend.DeleteResource(appengine.NewContext(c.Request()), guid); err != nil {
+	if err := backend.DeleteResource(appengine.NewContext(c.Request()), guid, expected); err != nil {
+		if errors.Is(err, a.ErrRevisionConflict) {
+			return api.ErrorResponse(c, http.StatusPreconditionFailed, err)
+		}
 		return api.ErrorResponse(c, http.StatusBadRequest, err)
 	}
 
@@ -214,3 +237,179 @@ func DeleteResourceEndpoint(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// StatusEndpoint changes the lifecycle status of an episode
+func StatusEndpoint(c echo.Context) error {
+	var req *a.StatusChange = new(a.StatusChange)
+
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+	if err := c.Bind(req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	clientID, _ := auth.GetClientID(c)
+	r, err := backend.ChangeEpisodeStatus(appengine.NewContext(c.Request()), prod, guid, req.Status, clientID)
+	if err != nil {
+		if err == a.ErrNoSuchResource {
+			return api.ErrorResponse(c, http.StatusNotFound, err)
+		}
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "rsrc_status", fmt.Sprintf("%s/%s/%s", prod, guid, req.Status), 1)
+
+	return api.StandardResponse(c, http.StatusOK, r)
+}
+
+// AuditEndpoint returns all status changes of a resource
+func AuditEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+
+	entries, err := backend.ListAuditEntries(appengine.NewContext(c.Request()), guid)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "rsrc_audit", fmt.Sprintf("%s/%s", prod, guid), 1)
+
+	return api.StandardResponse(c, http.StatusOK, &a.AuditList{Entries: entries})
+}
+
+// HistoryEndpoint returns all revisions of a show or episode
+func HistoryEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+
+	l, err := backend.ListRevisions(appengine.NewContext(c.Request()), guid)
+	if err != nil {
+		if err == a.ErrNoSuchResource {
+			return api.ErrorResponse(c, http.StatusNotFound, err)
+		}
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "rsrc_history", fmt.Sprintf("%s/%s", prod, guid), 1)
+
+	return api.StandardResponse(c, http.StatusOK, l)
+}
+
+// RollbackEndpoint restores a previous revision of a show or episode
+func RollbackEndpoint(c echo.Context) error {
+	var req *a.Rollback = new(a.Rollback)
+
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod"))
+	}
+	guid := c.Param("id")
+	if guid == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':id"))
+	}
+	if err := c.Bind(req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	clientID, _ := auth.GetClientID(c)
+	rev, err := backend.RollbackResource(appengine.NewContext(c.Request()), guid, req.Revision, clientID)
+	if err != nil {
+		if err == a.ErrNoSuchResource {
+			return api.ErrorResponse(c, http.StatusNotFound, err)
+		}
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "rsrc_rollback", fmt.Sprintf("%s/%s/%d", prod, guid, req.Revision), 1)
+
+	return api.StandardResponse(c, http.StatusOK, rev)
+}
+
+// checkRevision verifies the If-Match header against the current revision of a show or episode.
+// It returns the matched revision, nil if there is nothing to compare with or the header is '*'.
+func checkRevision(c echo.Context, guid string) (*int, int, error) {
+	r, err := backend.GetResource(appengine.NewContext(c.Request()), guid)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if r == nil || r.Kind == a.ResourceAsset {
+		return nil, http.StatusOK, nil // nothing to compare with, assets have no revisions
+	}
+
+	match := c.Request().Header.Get("If-Match")
+	if match == "" {
+		return nil, http.StatusPreconditionRequired, fmt.Errorf("missing If-Match header, current revision is %d", r.Revision)
+	}
+	if !api.MatchETag(match, r.Revision) {
+		c.Response().Header().Set("ETag", api.ETag(r.Revision))
+		return nil, http.StatusPreconditionFailed, fmt.Errorf("%s '%s' has been modified, current revision is %d", r.Kind, r.Name, r.Revision)
+	}
+	if strings.TrimSpace(match) == "*" {
+		return nil, http.StatusOK, nil
+	}
+	return &r.Revision, http.StatusOK, nil
+}
+
+// checkQuota verifies that the owner of production 'prod' can add 'storage' bytes and 'episodes' episodes
+func checkQuota(ctx context.Context, prod string, storage, episodes int64) (int, error) {
+	p, err := backend.GetProduction(ctx, prod)
+	if err != nil {
+		return http.StatusNotFound, err
+	}
+	if p == nil {
+		return http.StatusNotFound, a.ErrNoSuchProduction
+	}
+	if err := backend.CheckQuota(ctx, p.Owner, storage, episodes, 0); err != nil {
+		return quotaStatus(err, http.StatusInternalServerError), err
+	}
+	return http.StatusOK, nil
+}
+
+// quotaStatus maps quota errors to 402 and 403, all other errors to 'status'
+func quotaStatus(err error, status int) int {
+	if errors.Is(err, a.ErrQuotaExceeded) {
+		return http.StatusPaymentRequired
+	}
+	if errors.Is(err, a.ErrEpisodeLimit) {
+		return http.StatusForbidden
+	}
+	return status
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/backend"
+	"google.golang.org/appengine"
+)
+
+const (
+	// defaultSearchResults is used if 'first' is missing
+	defaultSearchResults = 20
+	// maxSearchResults limits 'first'
+	maxSearchResults = 100
+)
+
+// SearchEndpoint searches the published shows and episodes
+//
+// Query parameters: q (the words to search for), kind (show, episode or both, comma separated),
+// first (the number of results) and after (the cursor of the previous page).
+func SearchEndpoint(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid request, expected parameter 'q'"))
+	}
+
+	var kinds []string
+	if kind := c.QueryParam("kind"); kind != "" {
+		for _, k := range strings.Split(kind, ",") {
+			k = strings.TrimSpace(k)
+			if k != a.ResourceShow && k != a.ResourceEpisode {
+				return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid kind '%s'", k))
+			}
+			kinds = append(kinds, k)
+		}
+	}
+
+	first := defaultSearchResults
+	if f := c.QueryParam("first"); f != "" {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || n > maxSearchResults {
+			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid parameter 'first': expected 0..%d, got '%s'", maxSearchResults, f))
+		}
+		first = n
+	}
+
+	ctx := appengine.NewContext(c.Request())
+
+	results, err := backend.Search(ctx, query, kinds, c.QueryParam("after"), first)
+	if err != nil {
+		if err == a.ErrInvalidCursor {
+			return api.ErrorResponse(c, http.StatusBadRequest, err)
+		}
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "search", query, 1)
+
+	return api.StandardResponse(c, http.StatusOK, results)
+}
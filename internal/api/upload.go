@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -33,6 +34,16 @@ func UploadEndpoint(c echo.Context) error {
 	}
 
 	ctx := appengine.NewContext(c.Request())
+
+	// the exact size is only known after the upload, assume at least one byte
+	size := c.Request().ContentLength
+	if size <= 0 {
+		size = 1
+	}
+	if status, err := checkQuota(ctx, prod, size, 0); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
 	for {
 		p, err := mr.NextPart()
 		if err == io.EOF {
@@ -64,7 +75,9 @@ func UploadEndpoint(c echo.Context) error {
 			duration := int64(0) // FIXME implement it
 
 			// update the inventory
-			backend.UpdateAssetResource(ctx, p.FileName(), util.Checksum(location), a.ResourceAsset, prod, location, attr.ContentType, attr.Size, duration)
+			if err := backend.UpdateAssetResource(ctx, p.FileName(), util.Checksum(location), a.ResourceAsset, prod, location, attr.ContentType, hex.EncodeToString(attr.MD5), attr.Size, duration); err != nil {
+				return api.ErrorResponse(c, http.StatusInternalServerError, err)
+			}
 		}
 	}
 
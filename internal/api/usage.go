@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/auth"
+	"github.com/podops/podops/pkg/backend"
+	"google.golang.org/appengine"
+)
+
+// UsageEndpoint returns the usage of a production and its owner, together with the owner's quota
+func UsageEndpoint(c echo.Context) error {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod'"))
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	clientID, _ := auth.GetClientID(c)
+
+	p, err := backend.GetProduction(ctx, prod)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusNotFound, err)
+	}
+	if p == nil || p.Owner != clientID {
+		return api.ErrorResponse(c, http.StatusNotFound, a.ErrNoSuchProduction)
+	}
+
+	report, err := backend.GetUsageReport(ctx, p)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "usage", prod, 1)
+
+	return api.StandardResponse(c, http.StatusOK, report)
+}
+
+// QuotaEndpoint sets the quota of an owner
+func QuotaEndpoint(c echo.Context) error {
+	var req *a.Quota = new(a.Quota)
+
+	// this endpoint is secured by the master token, quotas are not managed by their owners
+	bearer := auth.GetBearerToken(c)
+	if bearer == "" || bearer != env.GetString("MASTER_KEY", "") {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	if err := c.Bind(req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	if req.Owner == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("missing owner"))
+	}
+	if req.Storage < 0 || req.Egress < 0 || req.Episodes < 0 || req.Builds < 0 {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid quota, expected values >= 0"))
+	}
+
+	if err := backend.UpdateQuota(appengine.NewContext(c.Request()), req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "quota", req.Owner, 1)
+
+	return api.StandardResponse(c, http.StatusCreated, req)
+}
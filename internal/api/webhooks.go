@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/auth"
+	"github.com/podops/podops/pkg/backend"
+	"google.golang.org/appengine"
+)
+
+// ListWebhooksEndpoint returns the webhooks of a production
+func ListWebhooksEndpoint(c echo.Context) error {
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	hooks, err := backend.ListWebhooks(ctx, prod)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhooks", prod, 1)
+
+	return api.StandardResponse(c, http.StatusOK, &a.WebhookList{Webhooks: hooks})
+}
+
+// CreateWebhookEndpoint subscribes a URL to the events of a production. Only the response includes the webhook's secret.
+func CreateWebhookEndpoint(c echo.Context) error {
+	var req *a.Webhook = new(a.Webhook)
+
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+	if err := c.Bind(req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	req.GUID = prod
+
+	hook, err := backend.CreateWebhook(ctx, req)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhook_create", prod, 1)
+
+	return api.StandardResponse(c, http.StatusCreated, hook)
+}
+
+// UpdateWebhookEndpoint changes the URL, the events or whether a webhook is active
+func UpdateWebhookEndpoint(c echo.Context) error {
+	var req *a.Webhook = new(a.Webhook)
+
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+	if err := c.Bind(req); err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	req.GUID = prod
+	req.ID = c.Param("id")
+
+	hook, err := backend.UpdateWebhook(ctx, req)
+	if err != nil {
+		return api.ErrorResponse(c, webhookStatus(err), err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhook_update", prod, 1)
+
+	return api.StandardResponse(c, http.StatusOK, hook)
+}
+
+// DeleteWebhookEndpoint removes a webhook and its deliveries
+func DeleteWebhookEndpoint(c echo.Context) error {
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	if err := backend.DeleteWebhook(ctx, prod, c.Param("id")); err != nil {
+		return api.ErrorResponse(c, webhookStatus(err), err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhook_delete", prod, 1)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// WebhookDeliveriesEndpoint returns the recent deliveries of a webhook
+func WebhookDeliveriesEndpoint(c echo.Context) error {
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	deliveries, err := backend.ListDeliveries(ctx, prod, c.Param("id"))
+	if err != nil {
+		return api.ErrorResponse(c, webhookStatus(err), err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhook_deliveries", prod, 1)
+
+	return api.StandardResponse(c, http.StatusOK, &a.WebhookDeliveryList{Deliveries: deliveries})
+}
+
+// ReplayDeliveryEndpoint delivers the event of a previous delivery again
+func ReplayDeliveryEndpoint(c echo.Context) error {
+	ctx, prod, status, err := webhookProduction(c)
+	if err != nil {
+		return api.ErrorResponse(c, status, err)
+	}
+
+	d, err := backend.ReplayDelivery(ctx, prod, c.Param("id"))
+	if err != nil {
+		return api.ErrorResponse(c, webhookStatus(err), err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "api", "webhook_replay", prod, 1)
+
+	return api.StandardResponse(c, http.StatusCreated, d)
+}
+
+// webhookProduction authorizes the request and verifies that the client owns production ':prod'
+func webhookProduction(c echo.Context) (context.Context, string, int, error) {
+	if status, err := auth.Authorized(c, "ROLES"); err != nil {
+		return nil, "", status, err
+	}
+
+	prod := c.Param("prod")
+	if prod == "" {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("invalid route, expected ':prod'")
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	clientID, _ := auth.GetClientID(c)
+
+	p, err := backend.GetProduction(ctx, prod)
+	if err != nil {
+		return nil, "", http.StatusNotFound, err
+	}
+	if p == nil || p.Owner != clientID {
+		return nil, "", http.StatusNotFound, a.ErrNoSuchProduction
+	}
+	return ctx, prod, http.StatusOK, nil
+}
+
+func webhookStatus(err error) int {
+	if err == a.ErrNoSuchWebhook {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/backend"
+	"google.golang.org/appengine"
+)
+
+// HubEndpoint is the WebSub hub that the feeds advertise. Subscribers POST a form with hub.mode,
+// hub.topic (the feed URL), hub.callback and optionally hub.lease_seconds and hub.secret.
+// The request is accepted right away, the subscription is active once the subscriber verified its intent.
+func HubEndpoint(c echo.Context) error {
+	var lease int64
+	if l := c.FormValue("hub.lease_seconds"); l != "" {
+		n, err := strconv.ParseInt(l, 10, 64)
+		if err != nil {
+			return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid hub.lease_seconds '%s'", l))
+		}
+		lease = n
+	}
+
+	mode := c.FormValue("hub.mode")
+	topic := c.FormValue("hub.topic")
+	ctx := appengine.NewContext(c.Request())
+
+	if err := backend.Subscribe(ctx, mode, topic, c.FormValue("hub.callback"), c.FormValue("hub.secret"), lease); err != nil {
+		return api.ErrorResponse(c, http.StatusBadRequest, err)
+	}
+
+	// track api access for billing etc
+	platform.TrackEvent(c.Request(), "websub", mode, topic, 1)
+
+	return c.NoContent(http.StatusAccepted)
+}
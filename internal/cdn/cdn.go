@@ -12,8 +12,13 @@ https://cloud.google.com/cdn/docs/
 */
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/fupas/commons/pkg/env"
@@ -28,18 +33,37 @@ import (
 
 const (
 	cacheControl = "public, max-age=1800"
+
+	// sizeCacheTTL is how long the size of an asset is cached for the egress accounting
+	sizeCacheTTL = 10 * time.Minute
+	// maxCachedSizes limits the memory used by the cache
+	maxCachedSizes = 10000
+)
+
+type (
+	// cachedSize is the size of an asset in the CDN bucket
+	cachedSize struct {
+		size    int64
+		checked time.Time
+	}
 )
 
 var (
 	staticFileLocation string
 	showPagePath       string
 	episodePagePath    string
-	bkt                *storage.BucketHandle
+
+	sizes   = make(map[string]cachedSize) // asset location -> size
+	sizesMu sync.RWMutex
 )
 
 func init() {
 	staticFileLocation = env.GetString("STATIC_FILE_LOCATION", "./public")
-	bkt = platform.Storage().Bucket(a.BucketCDN)
+}
+
+// bucket returns the CDN bucket. Storage is only initialized in the running service.
+func bucket() *storage.BucketHandle {
+	return platform.Storage().Bucket(a.BucketCDN)
 }
 
 // RewriteShowHandler rewrites requests from /s/:name to /s/_id.html
@@ -64,30 +88,6 @@ func RewriteEpisodeHandler(c echo.Context) error {
 	return nil
 }
 
-// FeedEndpoint handles request for feed.xml by redirecting to the public storage bucket
-func FeedEndpoint(c echo.Context) error {
-
-	name := c.Param("name")
-	if name == "" {
-		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':name'"))
-	}
-	prod, err := backend.FindProductionByName(appengine.NewContext(c.Request()), name)
-	if err != nil {
-		return api.ErrorResponse(c, http.StatusInternalServerError, err)
-	}
-
-	if prod == nil {
-		return api.ErrorResponse(c, http.StatusNotFound, fmt.Errorf("can not find '%s/feed.xml'", name))
-	}
-
-	redirectTo := fmt.Sprintf("%s/%s/feed.xml", a.StorageEndpoint, prod.GUID)
-
-	// track the event
-	p.TrackEvent(c.Request(), "cdn", "feed", prod.GUID, 1)
-
-	return c.Redirect(http.StatusTemporaryRedirect, redirectTo)
-}
-
 // RedirectCDNContentEndpoint serves request for content by redirecting to the public Cloud Storage bucket.
 // HEAD, GET are supported operations.
 func RedirectCDNContentEndpoint(c echo.Context) error {
@@ -107,10 +107,16 @@ func RedirectCDNContentEndpoint(c echo.Context) error {
 	}
 	rsrc := fmt.Sprintf("%s/%s", guid, asset)
 
+	if asset == "feed.xml" {
+		return serveFeed(c, "c/"+guid, func(ctx context.Context) (*a.Production, error) {
+			return backend.GetProduction(ctx, guid)
+		})
+	}
+
 	// handle HEAD request
 	if m == "HEAD" {
 		// get object attributes, can be cached ...
-		obj := bkt.Object(rsrc)
+		obj := bucket().Object(rsrc)
 		attr, err := obj.Attrs(appengine.NewContext(c.Request()))
 
 		if err == storage.ErrObjectNotExist {
@@ -132,8 +138,102 @@ func RedirectCDNContentEndpoint(c echo.Context) error {
 
 	// track the event
 	p.TrackEvent(c.Request(), "cdn", "asset", rsrc, 1)
+	trackEgress(c, guid, rsrc)
 
 	// let the storage cdn handle the request
 	redirectTo := fmt.Sprintf("%s/%s", a.StorageEndpoint, rsrc)
 	return c.Redirect(http.StatusTemporaryRedirect, redirectTo)
 }
+
+// HTTPErrorHandler redirects requests for the previous location of an imported feed to the
+// production's feed. All other errors are handled by echo's default error handler.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if he, ok := err.(*echo.HTTPError); ok && he.Code == http.StatusNotFound && c.Request().Method == "GET" {
+		feedURL := fmt.Sprintf("https://%s%s", c.Request().Host, c.Request().URL.Path)
+
+		prod, _ := backend.FindProductionByFeedURL(appengine.NewContext(c.Request()), feedURL)
+		if prod != nil {
+			p.TrackEvent(c.Request(), "cdn", "feed_redirect", prod.GUID, 1)
+			if err := c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("%s/s/%s/feed.xml", a.DefaultPortalEndpoint, prod.Name)); err == nil {
+				return
+			}
+		}
+	}
+	c.Echo().DefaultHTTPErrorHandler(err, c)
+}
+
+// trackEgress adds the bytes served for 'rsrc' to the production's monthly egress. Errors are
+// only reported, accounting must never block the delivery of content.
+func trackEgress(c echo.Context, guid, rsrc string) {
+	size, err := assetSize(appengine.NewContext(c.Request()), rsrc)
+	if err != nil {
+		if err != storage.ErrObjectNotExist {
+			p.ReportError(err)
+		}
+		return
+	}
+	backend.TrackEgress(guid, rangeSize(c.Request().Header.Get("range"), size))
+}
+
+// assetSize returns the size of an asset, cached to avoid a request to Storage for every download
+func assetSize(ctx context.Context, rsrc string) (int64, error) {
+	sizesMu.RLock()
+	cached, ok := sizes[rsrc]
+	sizesMu.RUnlock()
+
+	if ok && time.Since(cached.checked) < sizeCacheTTL {
+		return cached.size, nil
+	}
+
+	attr, err := bucket().Object(rsrc).Attrs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sizesMu.Lock()
+	defer sizesMu.Unlock()
+
+	if _, ok := sizes[rsrc]; !ok && len(sizes) >= maxCachedSizes {
+		for k := range sizes {
+			delete(sizes, k) // evict any size
+			break
+		}
+	}
+	sizes[rsrc] = cachedSize{size: attr.Size, checked: time.Now()}
+	return attr.Size, nil
+}
+
+// rangeSize returns the number of bytes requested by a 'Range: bytes=start-end' header
+func rangeSize(r string, size int64) int64 {
+	if !strings.HasPrefix(r, "bytes=") || strings.Contains(r, ",") {
+		return size // no range or multiple ranges, assume everything
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r, "bytes="), "-", 2)
+	if len(parts) != 2 {
+		return size
+	}
+	start, err1 := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	end, err2 := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+
+	switch {
+	case err1 != nil && err2 == nil: // bytes=-N, the last N bytes
+		if end < size {
+			return end
+		}
+		return size
+	case err1 == nil && err2 != nil: // bytes=N-, everything from N
+		if start < size {
+			return size - start
+		}
+		return 0
+	case err1 == nil && err2 == nil:
+		if end >= size {
+			end = size - 1
+		}
+		if start > end {
+			return 0
+		}
+		return end - start + 1
+	}
+	return size
+}
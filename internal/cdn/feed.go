@@ -0,0 +1,254 @@
+package cdn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	p "github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/api"
+	"github.com/podops/podops/pkg/backend"
+	"google.golang.org/appengine"
+)
+
+const (
+	// feedCacheControl lets clients cache a feed for a short time, after that they revalidate with If-None-Match
+	feedCacheControl = "public, max-age=300"
+	// feedRevalidate is how long a cached feed is served before its production is checked for a new build.
+	// Builds in this instance invalidate the cache right away.
+	feedRevalidate = 30 * time.Second
+	// maxCachedFeeds limits the memory used by the cache
+	maxCachedFeeds = 1000
+)
+
+type (
+	// cachedFeed is a published feed, ready to be served
+	cachedFeed struct {
+		guid     string
+		buildID  string
+		moved    string // the new location of a moved production
+		data     []byte
+		gzipped  []byte
+		etag     string
+		gzipETag string // the compressed feed is a different representation
+		modified time.Time
+		checked  time.Time
+	}
+
+	// feedCache keeps feeds in memory, by route, e.g. 's/NAME' or 'c/GUID'
+	feedCache struct {
+		mu    sync.RWMutex
+		feeds map[string]*cachedFeed
+	}
+)
+
+var feeds = &feedCache{feeds: make(map[string]*cachedFeed)}
+
+func init() {
+	// invalidate the cache when a production changes in this instance, e.g. after a build
+	backend.OnChange(func(ctx context.Context, kind, guid string) {
+		if kind == a.ResourceShow {
+			feeds.remove(guid)
+		}
+	})
+}
+
+// FeedEndpoint serves the feed.xml of a production by its name
+func FeedEndpoint(c echo.Context) error {
+	name := c.Param("name")
+	if name == "" {
+		return api.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid route, expected ':name'"))
+	}
+
+	return serveFeed(c, "s/"+name, func(ctx context.Context) (*a.Production, error) {
+		return backend.FindProductionByName(ctx, name)
+	})
+}
+
+// SkipGzip excludes the feeds from the gzip middleware, they are compressed when they are cached
+func SkipGzip(c echo.Context) bool {
+	return c.Path() == api.FeedRoute || (c.Path() == api.ContentNamespace+api.DefaultCDNRoute && c.Param("asset") == "feed.xml")
+}
+
+// serveFeed serves a feed from the cache, with support for conditional and compressed requests
+func serveFeed(c echo.Context, key string, lookup func(context.Context) (*a.Production, error)) error {
+	feed, err := feeds.get(appengine.NewContext(c.Request()), key, lookup)
+	if err != nil {
+		return api.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	if feed == nil {
+		return api.ErrorResponse(c, http.StatusNotFound, fmt.Errorf("can not find '%s/feed.xml'", key))
+	}
+	if feed.moved != "" {
+		p.TrackEvent(c.Request(), "cdn", "feed_moved", feed.guid, 1)
+		return c.Redirect(http.StatusMovedPermanently, feed.moved)
+	}
+
+	data, etag := feed.data, feed.etag
+	gzipped := strings.Contains(c.Request().Header.Get("Accept-Encoding"), "gzip")
+	if gzipped {
+		data, etag = feed.gzipped, feed.gzipETag
+	}
+
+	h := c.Response().Header()
+	h.Set("ETag", etag)
+	h.Set("Last-Modified", feed.modified.UTC().Format(http.TimeFormat))
+	h.Set("Cache-Control", feedCacheControl)
+	h.Set("Vary", "Accept-Encoding")
+
+	// track the event
+	p.TrackEvent(c.Request(), "cdn", "feed", feed.guid, 1)
+
+	if notModified(c.Request(), etag, feed.modified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	if gzipped {
+		h.Set("Content-Encoding", "gzip")
+	}
+	if c.Request().Method != "HEAD" {
+		backend.TrackEgress(feed.guid, int64(len(data)))
+	}
+	return c.Blob(http.StatusOK, "application/rss+xml; charset=UTF-8", data)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since if there is no If-None-Match (RFC 7232, section 6)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/") // weak comparison
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// get returns the cached feed, reading it again if the production was built since it was cached. It returns nil if there is no feed.
+func (fc *feedCache) get(ctx context.Context, key string, lookup func(context.Context) (*a.Production, error)) (*cachedFeed, error) {
+	fc.mu.RLock()
+	feed := fc.feeds[key]
+	fc.mu.RUnlock()
+
+	if feed != nil && time.Since(feed.checked) < feedRevalidate {
+		return feed, nil
+	}
+
+	prod, err := lookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if prod == nil {
+		fc.remove(key)
+		return nil, nil
+	}
+
+	if feed != nil && feed.guid == prod.GUID && feed.buildID == prod.BuildID && feed.moved == movedTo(prod) {
+		// still current, avoid reading it again
+		fresh := *feed
+		fresh.checked = time.Now()
+		feed = &fresh
+	} else {
+		feed, err = readFeed(ctx, prod)
+		if err != nil || feed == nil {
+			return nil, err
+		}
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if _, ok := fc.feeds[key]; !ok && len(fc.feeds) >= maxCachedFeeds {
+		for k := range fc.feeds {
+			delete(fc.feeds, k) // evict any feed
+			break
+		}
+	}
+	fc.feeds[key] = feed
+	return feed, nil
+}
+
+// remove drops a feed by its key or the GUID of its production
+func (fc *feedCache) remove(key string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for k, feed := range fc.feeds {
+		if k == key || feed.guid == key {
+			delete(fc.feeds, k)
+		}
+	}
+}
+
+// readFeed reads the published feed of a production, nil if there is none
+func readFeed(ctx context.Context, prod *a.Production) (*cachedFeed, error) {
+	feed := &cachedFeed{
+		guid:    prod.GUID,
+		buildID: prod.BuildID,
+		checked: time.Now(),
+	}
+	if feed.moved = movedTo(prod); feed.moved != "" {
+		return feed, nil
+	}
+
+	reader, err := bucket().Object(fmt.Sprintf("%s/feed.xml", prod.GUID)).NewReader(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	feed.data, err = ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same checksum the build recorded
+	sum := md5.Sum(feed.data)
+	feed.etag = fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:]))
+	feed.gzipETag = fmt.Sprintf("\"%s-gz\"", hex.EncodeToString(sum[:]))
+
+	feed.modified = time.Unix(prod.BuildDate, 0)
+	if prod.BuildDate == 0 {
+		feed.modified = reader.Attrs.LastModified
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(feed.data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	feed.gzipped = buf.Bytes()
+
+	return feed, nil
+}
+
+// movedTo returns the new location of a moved production
+func movedTo(prod *a.Production) string {
+	if prod.State == a.ProductionStateMoved {
+		return prod.NewFeedURL
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package cdn
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	etag := `"0123456789abcdef"`
+	modified := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header, value string
+		want          bool
+	}{
+		{"If-None-Match", etag, true},
+		{"If-None-Match", `"other", ` + etag, true},
+		{"If-None-Match", "W/" + etag, true},
+		{"If-None-Match", "*", true},
+		{"If-None-Match", `"other"`, false},
+		{"If-Modified-Since", modified.Format(http.TimeFormat), true},
+		{"If-Modified-Since", modified.Add(time.Hour).Format(http.TimeFormat), true},
+		{"If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat), false},
+		{"If-Modified-Since", "yesterday", false},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/s/show/feed.xml", nil)
+		r.Header.Set(tt.header, tt.value)
+		if got := notModified(r, etag, modified); got != tt.want {
+			t.Errorf("%s: %s = %v, want %v", tt.header, tt.value, got, tt.want)
+		}
+	}
+
+	// If-None-Match takes precedence
+	r, _ := http.NewRequest("GET", "/s/show/feed.xml", nil)
+	r.Header.Set("If-None-Match", `"other"`)
+	r.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	if notModified(r, etag, modified) {
+		t.Error("expected If-None-Match to take precedence")
+	}
+}
+
+func TestFeedCacheRemove(t *testing.T) {
+	fc := &feedCache{feeds: map[string]*cachedFeed{
+		"s/show": {guid: "abc"},
+		"c/abc":  {guid: "abc"},
+		"c/def":  {guid: "def"},
+	}}
+	fc.remove("abc")
+	if len(fc.feeds) != 1 || fc.feeds["c/def"] == nil {
+		t.Errorf("expected only 'c/def' to remain, got %v", fc.feeds)
+	}
+}
@@ -5,58 +5,249 @@ This is a not type safe data loaders for go, inspired by https://github.com/face
 
 The intended use is in graphql servers, to reduce the number of queries being sent to e.g. a database.
 
+Keys requested within a short wait period are collected and fetched together with one call of the
+BatchFetchFunc. Batches and results are scoped to a request: wrap the request's context with WithScope,
+or the handler with Middleware, and every key is fetched at most once per request. Results are also
+kept in a shared cache with TTL. Call Clear whenever the underlying data changes.
+
 */
 
 import (
 	"context"
+	"net/http"
+	"sync"
 	"time"
 
 	cache "github.com/OrlovEvgeny/go-mcache"
 )
 
 const (
-	// DefaultTTL is the default TTL used if nothing else is specified
-	DefaultTTL = time.Minute * 10
+	// DefaultTTL is the default TTL used if nothing else is specified. Clear only reaches the cache of
+	// the instance that made the change, the TTL limits how long other instances serve stale data.
+	DefaultTTL = time.Minute
+	// DefaultWait is the time a batch collects keys before it is fetched
+	DefaultWait = time.Millisecond * 2
+	// DefaultMaxBatch is the maximum number of keys fetched at once, e.g. the Datastore limits GetMulti to 1000 keys
+	DefaultMaxBatch = 100
 )
 
 type (
 	// FetchFunc abstracts the process of loading a resource
 	FetchFunc func(context.Context, string) (interface{}, error)
 
+	// BatchFetchFunc abstracts the process of loading several resources at once.
+	// It returns one result and one error per key, in the order of the keys.
+	BatchFetchFunc func(context.Context, []string) ([]interface{}, []error)
+
 	// Loader holds cached resources. The cache is a simple in-memory cache with TTL.
 	Loader struct {
-		fetch        FetchFunc
+		fetch        BatchFetchFunc
 		c            *cache.CacheDriver
 		expiresAfter time.Duration
+		wait         time.Duration
+		maxBatch     int
+
+		mu       sync.Mutex
+		clears   uint64            // incremented by every Clear
+		cleared  map[string]uint64 // the value of clears when a key was cleared while batches were in flight
+		inFlight int
+	}
+
+	// result is the future result of a key
+	result struct {
+		data interface{}
+		err  error
+		done chan struct{}
+	}
+
+	// batch collects the keys that are fetched together
+	batch struct {
+		keys    []string
+		results []*result
+	}
+
+	// scope holds the batches and results of a request
+	scope struct {
+		mu      sync.Mutex
+		results map[*Loader]map[string]*result
+		batches map[*Loader]*batch
 	}
+
+	scopeKey struct{}
 )
 
-// NewLoader initializes the loader
+// NewLoader initializes a loader that fetches one key at a time
 func NewLoader(f FetchFunc, ttl time.Duration) *Loader {
+	return NewBatchLoader(func(ctx context.Context, keys []string) ([]interface{}, []error) {
+		data := make([]interface{}, len(keys))
+		errs := make([]error, len(keys))
+		for i, key := range keys {
+			data[i], errs[i] = f(ctx, key)
+		}
+		return data, errs
+	}, ttl)
+}
+
+// NewBatchLoader initializes a loader that fetches all keys of a batch at once
+func NewBatchLoader(f BatchFetchFunc, ttl time.Duration) *Loader {
 	return &Loader{
 		fetch:        f,
 		c:            cache.New(),
 		expiresAfter: ttl,
+		wait:         DefaultWait,
+		maxBatch:     DefaultMaxBatch,
 	}
 }
 
+// WithScope returns a context that scopes batches and results to e.g. a request
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{
+		results: make(map[*Loader]map[string]*result),
+		batches: make(map[*Loader]*batch),
+	})
+}
+
+// Middleware scopes batches and results to the request
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithScope(r.Context())))
+	})
+}
+
 // Load returns either a cached instance or calls the fetch function to retriece the requested instance
 func (l *Loader) Load(ctx context.Context, key string) (interface{}, error) {
+	r := l.load(ctx, key)
+	<-r.done
+	return r.data, r.err
+}
+
+// LoadMany returns the instances of all keys, fetching the missing ones in as few batches as possible
+func (l *Loader) LoadMany(ctx context.Context, keys []string) ([]interface{}, []error) {
+	results := make([]*result, len(keys))
+	for i, key := range keys {
+		results[i] = l.load(ctx, key)
+	}
+
+	data := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+	for i, r := range results {
+		<-r.done
+		data[i], errs[i] = r.data, r.err
+	}
+	return data, errs
+}
+
+// Clear removes a cached instance, the next Load calls the fetch function again. Batches that are
+// in flight do not cache the instance, they might have read it before the change.
+func (l *Loader) Clear(ctx context.Context, key string) {
+	l.mu.Lock()
+	l.clears++
+	if l.inFlight > 0 {
+		if l.cleared == nil {
+			l.cleared = make(map[string]uint64)
+		}
+		l.cleared[key] = l.clears
+	}
+	l.c.Remove(key)
+	l.mu.Unlock()
+
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.mu.Lock()
+		delete(s.results[l], key)
+		s.mu.Unlock()
+	}
+}
 
+func (l *Loader) load(ctx context.Context, key string) *result {
 	if data, ok := l.c.Get(key); ok {
-		return data, nil
+		return &result{data: data, done: closed()}
+	}
+
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		// nothing to batch with
+		r := &result{done: make(chan struct{})}
+		l.dispatch(ctx, &batch{keys: []string{key}, results: []*result{r}})
+		return r
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.results[l][key]; ok {
+		return r
+	}
+	if s.results[l] == nil {
+		s.results[l] = make(map[string]*result)
 	}
+	r := &result{done: make(chan struct{})}
+	s.results[l][key] = r
+
+	b := s.batches[l]
+	if b == nil {
+		b = &batch{}
+		s.batches[l] = b
+		time.AfterFunc(l.wait, func() {
+			s.mu.Lock()
+			if s.batches[l] != b {
+				s.mu.Unlock()
+				return // already dispatched because it was full
+			}
+			delete(s.batches, l)
+			s.mu.Unlock()
 
-	data, err := l.fetch(ctx, key)
+			l.dispatch(ctx, b)
+		})
+	}
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
 
-	if err != nil {
-		return nil, err
+	if len(b.keys) >= l.maxBatch {
+		delete(s.batches, l)
+		go l.dispatch(ctx, b)
 	}
-	if data != nil {
-		if err := l.c.Set(key, data, l.expiresAfter); err != nil {
-			return nil, err
+	return r
+}
+
+// dispatch fetches all keys of a batch and resolves their results. Keys that were cleared after
+// the batch started are not cached.
+func (l *Loader) dispatch(ctx context.Context, b *batch) {
+	l.mu.Lock()
+	started := l.clears
+	l.inFlight++
+	l.mu.Unlock()
+
+	data, errs := l.fetch(ctx, b.keys)
+
+	l.mu.Lock()
+	for i, r := range b.results {
+		if i < len(data) {
+			r.data = data[i]
+		}
+		if i < len(errs) {
+			r.err = errs[i]
 		}
-		return data, nil
+		if r.err != nil {
+			r.data = nil
+		} else if r.data != nil && l.cleared[b.keys[i]] <= started {
+			if err := l.c.Set(b.keys[i], r.data, l.expiresAfter); err != nil {
+				r.data, r.err = nil, err
+			}
+		}
+	}
+	l.inFlight--
+	if l.inFlight == 0 {
+		l.cleared = nil
+	}
+	l.mu.Unlock()
+
+	for _, r := range b.results {
+		close(r.done)
 	}
-	return nil, nil
+}
+
+func closed() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
 }
@@ -0,0 +1,117 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type counter struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (c *counter) fetch(ctx context.Context, keys []string) ([]interface{}, []error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, keys)
+	c.mu.Unlock()
+
+	data := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		if key == "missing" {
+			errs[i] = fmt.Errorf("'%s' not found", key)
+			continue
+		}
+		data[i] = "value-" + key
+	}
+	return data, errs
+}
+
+func TestLoadManyBatches(t *testing.T) {
+	c := &counter{}
+	l := NewBatchLoader(c.fetch, time.Minute)
+	ctx := WithScope(context.Background())
+
+	data, errs := l.LoadMany(ctx, []string{"a", "b", "a", "missing"})
+	if len(c.batches) != 1 || len(c.batches[0]) != 3 {
+		t.Fatalf("expected one batch of 3 keys, got %v", c.batches)
+	}
+	if data[0] != "value-a" || data[1] != "value-b" || data[2] != "value-a" {
+		t.Errorf("unexpected results %v", data)
+	}
+	if errs[3] == nil || data[3] != nil {
+		t.Errorf("expected an error for 'missing', got %v, %v", data[3], errs[3])
+	}
+
+	// served from the cache
+	if v, err := l.Load(context.Background(), "b"); err != nil || v != "value-b" {
+		t.Errorf("Load() = %v, %v", v, err)
+	}
+	if len(c.batches) != 1 {
+		t.Errorf("expected no further fetch, got %v", c.batches)
+	}
+}
+
+func TestLoadConcurrent(t *testing.T) {
+	c := &counter{}
+	l := NewBatchLoader(c.fetch, time.Minute)
+	l.wait = 50 * time.Millisecond // plenty of time to start all goroutines
+	ctx := WithScope(context.Background())
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			l.Load(ctx, key)
+		}(key)
+	}
+	wg.Wait()
+
+	if len(c.batches) != 1 {
+		t.Errorf("expected one batch, got %v", c.batches)
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := &counter{}
+	l := NewBatchLoader(c.fetch, time.Minute)
+	ctx := WithScope(context.Background())
+
+	l.Load(ctx, "a")
+	l.Clear(ctx, "a")
+	l.Load(ctx, "a")
+
+	if len(c.batches) != 2 {
+		t.Errorf("expected a second fetch after Clear, got %v", c.batches)
+	}
+}
+
+func TestClearDuringFetch(t *testing.T) {
+	c := &counter{}
+	fetching := make(chan struct{})
+	resume := make(chan struct{})
+	l := NewBatchLoader(func(ctx context.Context, keys []string) ([]interface{}, []error) {
+		close(fetching)
+		<-resume
+		return c.fetch(ctx, keys)
+	}, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		l.Load(context.Background(), "a")
+		close(done)
+	}()
+
+	<-fetching
+	l.Clear(context.Background(), "a")
+	close(resume)
+	<-done
+
+	if _, ok := l.c.Get("a"); ok {
+		t.Error("expected a key cleared during the fetch not to be cached")
+	}
+}
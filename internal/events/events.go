@@ -0,0 +1,91 @@
+package events
+
+/*
+This is a simple in-process event bus.
+
+Events published on a topic are delivered to all current subscribers of the topic. Delivery is best-effort:
+a subscriber that does not keep up misses events instead of blocking the publisher, and events published
+by other instances are never seen. Subscribers that need every state should also poll its persisted copy.
+
+*/
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// bufferSize is the number of events a subscriber can lag behind
+	bufferSize = 16
+)
+
+type (
+	// Bus delivers events to the subscribers of a topic
+	Bus struct {
+		mu          sync.RWMutex
+		subscribers map[string]map[chan interface{}]bool
+	}
+)
+
+var (
+	// DefaultBus is used by Publish and Subscribe
+	DefaultBus = NewBus()
+)
+
+// NewBus initializes an event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string]map[chan interface{}]bool),
+	}
+}
+
+// Publish sends an event to all subscribers of the topic on the default bus
+func Publish(topic string, event interface{}) {
+	DefaultBus.Publish(topic, event)
+}
+
+// Subscribe returns the events published on the topic on the default bus, until ctx is done
+func Subscribe(ctx context.Context, topic string) <-chan interface{} {
+	return DefaultBus.Subscribe(ctx, topic)
+}
+
+// Publish sends an event to all subscribers of the topic
+func (b *Bus) Publish(topic string, event interface{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+			// the subscriber is too slow, drop the event
+		}
+	}
+}
+
+// Subscribe returns the events published on the topic until ctx is done, the channel is closed then
+func (b *Bus) Subscribe(ctx context.Context, topic string) <-chan interface{} {
+	ch := make(chan interface{}, bufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan interface{}]bool)
+	}
+	b.subscribers[topic][ch] = true
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.subscribers[topic], ch)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+		b.mu.Unlock()
+
+		close(ch)
+	}()
+
+	return ch
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := b.Subscribe(ctx, "build/abc")
+	other := b.Subscribe(ctx, "build/xyz")
+
+	b.Publish("build/abc", "started")
+
+	select {
+	case e := <-ch:
+		if e != "started" {
+			t.Errorf("expected 'started', got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+	select {
+	case e := <-other:
+		t.Errorf("unexpected event %v", e)
+	default:
+	}
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := b.Subscribe(ctx, "import/abc")
+	for i := 0; i < bufferSize*2; i++ {
+		b.Publish("import/abc", i) // must not block
+	}
+	if len(ch) != bufferSize {
+		t.Errorf("expected %d buffered events, got %d", bufferSize, len(ch))
+	}
+}
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/gql/graph/model"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/backend"
+)
+
+// This file will not be regenerated automatically.
+//
+// It holds the helpers of the connection resolvers.
+
+const (
+	// defaultPageSize is used if 'first' is missing
+	defaultPageSize = 20
+	// maxPageSize limits 'first'
+	maxPageSize = 100
+)
+
+// pageSize validates the 'first' argument of a connection
+func pageSize(first *int) (int, error) {
+	if first == nil {
+		return defaultPageSize, nil
+	}
+	if *first < 0 || *first > maxPageSize {
+		return 0, newError(ErrCodeBadRequest, fmt.Errorf("invalid argument 'first': expected 0..%d, got %d", maxPageSize, *first))
+	}
+	return *first, nil
+}
+
+// loadShows loads the shows of all productions in one batch
+func (r *Resolver) loadShows(ctx context.Context, productions []*a.Production) ([]*model.Show, error) {
+	guids := make([]string, len(productions))
+	for i, p := range productions {
+		guids[i] = p.GUID
+	}
+	data, errs := r.ShowLoader.LoadMany(ctx, guids)
+
+	shows := make([]*model.Show, len(data))
+	for i := range data {
+		if errs[i] != nil {
+			platform.ReportError(errs[i])
+			return nil, errs[i]
+		}
+		shows[i] = data[i].(*model.Show)
+	}
+	return shows, nil
+}
+
+// loadEpisodes loads the episodes of all GUIDs in one batch
+func (r *Resolver) loadEpisodes(ctx context.Context, guids []string) ([]*model.Episode, error) {
+	data, errs := r.EpisodeLoader.LoadMany(ctx, guids)
+
+	episodes := make([]*model.Episode, len(data))
+	for i := range data {
+		if errs[i] != nil {
+			platform.ReportError(errs[i])
+			return nil, errs[i]
+		}
+		episodes[i] = data[i].(*model.Episode)
+	}
+	return episodes, nil
+}
+
+// pageInfo describes a page of a connection, 'cursors' are the cursors of its edges
+func pageInfo(cursors []string, after *string, more bool) *model.PageInfo {
+	info := model.PageInfo{
+		HasNextPage:     more,
+		HasPreviousPage: after != nil && *after != "",
+	}
+	if len(cursors) > 0 {
+		info.StartCursor = &cursors[0]
+		info.EndCursor = &cursors[len(cursors)-1]
+	}
+	return &info
+}
+
+// episodeOrder maps the sort order to the backend's
+func episodeOrder(order *model.EpisodeOrder) string {
+	if order == nil {
+		return backend.OrderPublishedDesc
+	}
+	switch *order {
+	case model.EpisodeOrderPublishedAsc:
+		return backend.OrderPublishedAsc
+	case model.EpisodeOrderEpisodeDesc:
+		return backend.OrderEpisodeDesc
+	case model.EpisodeOrderEpisodeAsc:
+		return backend.OrderEpisodeAsc
+	}
+	return backend.OrderPublishedDesc
+}
+
+// showOrder maps the sort order to the backend's
+func showOrder(order *model.ShowOrder) string {
+	if order != nil && *order == model.ShowOrderName {
+		return backend.OrderName
+	}
+	return backend.OrderRecent
+}
+
+// episodeFilter maps the filter to the backend's
+func episodeFilter(in *model.EpisodeFilter) (*backend.EpisodeFilter, error) {
+	filter := backend.EpisodeFilter{}
+	if in == nil {
+		return &filter, nil
+	}
+
+	if in.Season != nil {
+		filter.Season = *in.Season
+	}
+	if in.Type != nil {
+		filter.EpisodeType = *in.Type
+	}
+	filter.Explicit = in.Explicit
+
+	timestamp := func(name string, ts *string) (int64, error) {
+		if ts == nil {
+			return 0, nil
+		}
+		t, err := strconv.ParseInt(*ts, 10, 64)
+		if err != nil {
+			return 0, newError(ErrCodeBadRequest, fmt.Errorf("invalid timestamp '%s' in filter '%s'", *ts, name))
+		}
+		return t, nil
+	}
+	var err error
+	if filter.PublishedAfter, err = timestamp("publishedAfter", in.PublishedAfter); err != nil {
+		return nil, err
+	}
+	if filter.PublishedBefore, err = timestamp("publishedBefore", in.PublishedBefore); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// searchKinds validates the 'kinds' argument of a search
+func searchKinds(kinds []string) error {
+	for _, k := range kinds {
+		if k != a.ResourceShow && k != a.ResourceEpisode {
+			return newError(ErrCodeBadRequest, fmt.Errorf("invalid kind '%s': expected '%s' or '%s'", k, a.ResourceShow, a.ResourceEpisode))
+		}
+	}
+	return nil
+}
+
+// searchResult maps a search result to the model
+func searchResult(r *a.SearchResult) *model.SearchResult {
+	result := model.SearchResult{
+		GUID:    r.GUID,
+		Kind:    r.Kind,
+		Name:    r.Name,
+		Show:    r.Show,
+		Title:   r.Title,
+		Summary: r.Summary,
+		Score:   r.Score,
+		Highlights: &model.SearchHighlights{
+			Title:   optional(r.Highlights["title"]),
+			Summary: optional(r.Highlights["summary"]),
+			Text:    optional(r.Highlights["text"]),
+		},
+	}
+	if r.Published > 0 {
+		published := strconv.FormatInt(r.Published, 10)
+		result.Published = &published
+	}
+	return &result
+}
+
+// optional maps empty strings to null
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
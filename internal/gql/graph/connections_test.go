@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/podops/podops/internal/gql/graph/model"
+	"github.com/podops/podops/pkg/backend"
+)
+
+func TestPageSize(t *testing.T) {
+	size := func(n int) *int { return &n }
+
+	tests := []struct {
+		first *int
+		want  int
+		err   bool
+	}{
+		{nil, defaultPageSize, false},
+		{size(5), 5, false},
+		{size(maxPageSize), maxPageSize, false},
+		{size(maxPageSize + 1), 0, true},
+		{size(-1), 0, true},
+	}
+	for _, tt := range tests {
+		got, err := pageSize(tt.first)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("pageSize(%v) = %d, %v, want %d", tt.first, got, err, tt.want)
+		}
+	}
+}
+
+func TestEpisodeFilter(t *testing.T) {
+	season := 2
+	after := "1609459200"
+	f, err := episodeFilter(&model.EpisodeFilter{Season: &season, PublishedAfter: &after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Season != 2 || f.PublishedAfter != 1609459200 || f.PublishedBefore != 0 || f.Explicit != nil {
+		t.Errorf("unexpected filter %+v", f)
+	}
+
+	invalid := "yesterday"
+	if _, err := episodeFilter(&model.EpisodeFilter{PublishedBefore: &invalid}); err == nil {
+		t.Error("expected an error for an invalid timestamp")
+	}
+
+	order := model.EpisodeOrderEpisodeAsc
+	if o := episodeOrder(&order); o != backend.OrderEpisodeAsc {
+		t.Errorf("expected order '%s', got '%s'", backend.OrderEpisodeAsc, o)
+	}
+	if o := episodeOrder(nil); o != backend.OrderPublishedDesc {
+		t.Errorf("expected order '%s', got '%s'", backend.OrderPublishedDesc, o)
+	}
+}
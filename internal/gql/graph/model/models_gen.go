@@ -2,6 +2,26 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type AssetInput struct {
+	URI  string  `json:"uri"`
+	Rel  *string `json:"rel"`
+	Type *string `json:"type"`
+	Size *int    `json:"size"`
+}
+
+type Build struct {
+	GUID         string `json:"guid"`
+	BuildID      string `json:"buildID"`
+	FeedURL      string `json:"feedURL"`
+	FeedAliasURL string `json:"feedAliasURL"`
+}
+
 type Category struct {
 	Name        string  `json:"name"`
 	Subcategory *string `json:"subcategory"`
@@ -18,6 +38,7 @@ type Episode struct {
 	Name        string              `json:"name"`
 	Created     string              `json:"created"`
 	Published   string              `json:"published"`
+	Status      string              `json:"status"`
 	Labels      *Labels             `json:"labels"`
 	Description *EpisodeDescription `json:"description"`
 	Image       string              `json:"image"`
@@ -25,6 +46,11 @@ type Episode struct {
 	Production  *Production         `json:"production"`
 }
 
+type EpisodeConnection struct {
+	Edges    []*EpisodeEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
 type EpisodeDescription struct {
 	Title       string  `json:"title"`
 	Summary     string  `json:"summary"`
@@ -33,6 +59,37 @@ type EpisodeDescription struct {
 	Duration    int     `json:"duration"`
 }
 
+type EpisodeEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Episode `json:"node"`
+}
+
+type EpisodeFilter struct {
+	Season          *int    `json:"season"`
+	Type            *string `json:"type"`
+	Explicit        *bool   `json:"explicit"`
+	PublishedAfter  *string `json:"publishedAfter"`
+	PublishedBefore *string `json:"publishedBefore"`
+}
+
+type EpisodeInput struct {
+	Production  string       `json:"production"`
+	GUID        *string      `json:"guid"`
+	Revision    *int         `json:"revision"`
+	Force       *bool        `json:"force"`
+	Name        string       `json:"name"`
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Description *string      `json:"description"`
+	Link        *string      `json:"link"`
+	Published   *string      `json:"published"`
+	Duration    *int         `json:"duration"`
+	Status      *string      `json:"status"`
+	Image       *AssetInput  `json:"image"`
+	Enclosure   *AssetInput  `json:"enclosure"`
+	Labels      *LabelsInput `json:"labels"`
+}
+
 type Labels struct {
 	Block    string `json:"block"`
 	Explicit string `json:"explicit"`
@@ -43,17 +100,86 @@ type Labels struct {
 	Season   int    `json:"season"`
 }
 
+type LabelsInput struct {
+	Block    *string `json:"block"`
+	Explicit *string `json:"explicit"`
+	Type     *string `json:"type"`
+	Complete *string `json:"complete"`
+	Language *string `json:"language"`
+	Episode  *int    `json:"episode"`
+	Season   *int    `json:"season"`
+}
+
+type NewProduction struct {
+	Name    string  `json:"name"`
+	Title   *string `json:"title"`
+	Summary *string `json:"summary"`
+}
+
 type Owner struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
 
+type OwnerInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
 type Production struct {
 	GUID  string `json:"guid"`
 	Name  string `json:"name"`
 	Title string `json:"title"`
 }
 
+type Progress struct {
+	Kind       string  `json:"kind"`
+	Production string  `json:"production"`
+	Resource   *string `json:"resource"`
+	Stage      string  `json:"stage"`
+	Current    int     `json:"current"`
+	Total      int     `json:"total"`
+	Error      *string `json:"error"`
+	BuildID    *string `json:"buildID"`
+	Timestamp  string  `json:"timestamp"`
+}
+
+type SearchConnection struct {
+	Edges     []*SearchEdge `json:"edges"`
+	PageInfo  *PageInfo     `json:"pageInfo"`
+	Truncated bool          `json:"truncated"`
+}
+
+type SearchEdge struct {
+	Cursor string        `json:"cursor"`
+	Node   *SearchResult `json:"node"`
+}
+
+type SearchHighlights struct {
+	Title   *string `json:"title"`
+	Summary *string `json:"summary"`
+	Text    *string `json:"text"`
+}
+
+type SearchResult struct {
+	GUID       string            `json:"guid"`
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Show       string            `json:"show"`
+	Title      string            `json:"title"`
+	Summary    string            `json:"summary"`
+	Published  *string           `json:"published"`
+	Score      float64           `json:"score"`
+	Highlights *SearchHighlights `json:"highlights"`
+}
+
 type Show struct {
 	GUID        string           `json:"guid"`
 	Name        string           `json:"name"`
@@ -65,6 +191,11 @@ type Show struct {
 	Episodes    []*Episode       `json:"episodes"`
 }
 
+type ShowConnection struct {
+	Edges    []*ShowEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
 type ShowDescription struct {
 	Title     string      `json:"title"`
 	Summary   string      `json:"summary"`
@@ -74,3 +205,110 @@ type ShowDescription struct {
 	Copyright string      `json:"copyright"`
 	Owner     *Owner      `json:"owner"`
 }
+
+type ShowEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Show  `json:"node"`
+}
+
+type ShowInput struct {
+	GUID        string       `json:"guid"`
+	Revision    *int         `json:"revision"`
+	Force       *bool        `json:"force"`
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Link        *string      `json:"link"`
+	Category    *string      `json:"category"`
+	Subcategory []string     `json:"subcategory"`
+	Author      *string      `json:"author"`
+	Copyright   *string      `json:"copyright"`
+	Owner       *OwnerInput  `json:"owner"`
+	Image       *AssetInput  `json:"image"`
+	Labels      *LabelsInput `json:"labels"`
+}
+
+type EpisodeOrder string
+
+const (
+	EpisodeOrderPublishedDesc EpisodeOrder = "PUBLISHED_DESC"
+	EpisodeOrderPublishedAsc  EpisodeOrder = "PUBLISHED_ASC"
+	EpisodeOrderEpisodeDesc   EpisodeOrder = "EPISODE_DESC"
+	EpisodeOrderEpisodeAsc    EpisodeOrder = "EPISODE_ASC"
+)
+
+var AllEpisodeOrder = []EpisodeOrder{
+	EpisodeOrderPublishedDesc,
+	EpisodeOrderPublishedAsc,
+	EpisodeOrderEpisodeDesc,
+	EpisodeOrderEpisodeAsc,
+}
+
+func (e EpisodeOrder) IsValid() bool {
+	switch e {
+	case EpisodeOrderPublishedDesc, EpisodeOrderPublishedAsc, EpisodeOrderEpisodeDesc, EpisodeOrderEpisodeAsc:
+		return true
+	}
+	return false
+}
+
+func (e EpisodeOrder) String() string {
+	return string(e)
+}
+
+func (e *EpisodeOrder) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EpisodeOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid episodeOrder", str)
+	}
+	return nil
+}
+
+func (e EpisodeOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ShowOrder string
+
+const (
+	ShowOrderRecent ShowOrder = "RECENT"
+	ShowOrderName   ShowOrder = "NAME"
+)
+
+var AllShowOrder = []ShowOrder{
+	ShowOrderRecent,
+	ShowOrderName,
+}
+
+func (e ShowOrder) IsValid() bool {
+	switch e {
+	case ShowOrderRecent, ShowOrderName:
+		return true
+	}
+	return false
+}
+
+func (e ShowOrder) String() string {
+	return string(e)
+}
+
+func (e *ShowOrder) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ShowOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid showOrder", str)
+	}
+	return nil
+}
+
+func (e ShowOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
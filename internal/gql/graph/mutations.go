@@ -0,0 +1,217 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/gql/graph/model"
+	"github.com/podops/podops/pkg/auth"
+	"github.com/podops/podops/pkg/backend"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// This file will not be regenerated automatically.
+//
+// It holds the error codes of all resolvers and the helpers of the mutation resolvers.
+
+const (
+	// ErrCodeUnauthenticated the request has no valid token
+	ErrCodeUnauthenticated = "UNAUTHENTICATED"
+	// ErrCodeNotFound the production or resource does not exist or belongs to someone else
+	ErrCodeNotFound = "NOT_FOUND"
+	// ErrCodeValidation the input does not describe a valid resource, see extension 'issues'
+	ErrCodeValidation = "VALIDATION_FAILED"
+	// ErrCodeConflict the resource was changed since the revision in the input
+	ErrCodeConflict = "CONFLICT"
+	// ErrCodeRevisionRequired the resource exists, the input needs its revision or force: true
+	ErrCodeRevisionRequired = "REVISION_REQUIRED"
+	// ErrCodeQuotaExceeded the owner used up the storage, egress or build quota
+	ErrCodeQuotaExceeded = "QUOTA_EXCEEDED"
+	// ErrCodeEpisodeLimit the owner can not add more episodes
+	ErrCodeEpisodeLimit = "EPISODE_LIMIT"
+	// ErrCodeRateLimited the client started too many builds, try again later
+	ErrCodeRateLimited = "RATE_LIMITED"
+	// ErrCodeBadRequest all other errors
+	ErrCodeBadRequest = "BAD_REQUEST"
+)
+
+// newError returns an error with extension 'code'
+func newError(code string, err error) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    err.Error(),
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// backendError maps errors returned by pkg/backend to an error with extension 'code'
+func backendError(err error) *gqlerror.Error {
+	switch {
+	case errors.Is(err, a.ErrQuotaExceeded):
+		return newError(ErrCodeQuotaExceeded, err)
+	case errors.Is(err, a.ErrEpisodeLimit):
+		return newError(ErrCodeEpisodeLimit, err)
+	case errors.Is(err, a.ErrNoSuchProduction), errors.Is(err, a.ErrNoSuchResource):
+		return newError(ErrCodeNotFound, err)
+	case errors.Is(err, a.ErrRevisionConflict):
+		return newError(ErrCodeConflict, err)
+	}
+	return newError(ErrCodeBadRequest, err)
+}
+
+// validationError lists all issues found by the validator in extension 'issues'
+func validationError(v *a.Validator) *gqlerror.Error {
+	issues := make([]string, len(v.Issues))
+	for i, issue := range v.Issues {
+		issues[i] = issue.Txt
+	}
+	return &gqlerror.Error{
+		Message: fmt.Sprintf("invalid %s: %d issues", v.Name, len(issues)),
+		Extensions: map[string]interface{}{
+			"code":   ErrCodeValidation,
+			"issues": issues,
+		},
+	}
+}
+
+// authorizedProduction returns the production if it belongs to the authenticated client
+func authorizedProduction(ctx context.Context, guid string) (*a.Production, string, error) {
+	clientID, err := auth.ClientIDFromContext(ctx)
+	if err != nil {
+		return nil, "", newError(ErrCodeUnauthenticated, err)
+	}
+	p, err := backend.GetProduction(ctx, guid)
+	if err != nil {
+		return nil, "", backendError(err)
+	}
+	if p == nil || p.Owner != clientID {
+		return nil, "", newError(ErrCodeNotFound, a.ErrNoSuchProduction)
+	}
+	return p, clientID, nil
+}
+
+// checkRevision verifies that resource 'guid' is still at 'revision', like an If-Match header does.
+// Changing an existing resource requires its revision, unless 'force' is true. The revision to compare
+// again when the resource is written is returned, nil if there is nothing to compare.
+func checkRevision(ctx context.Context, guid string, revision *int, force *bool) (*int, error) {
+	if force != nil && *force {
+		return nil, nil
+	}
+	r, err := backend.GetResource(ctx, guid)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	if r == nil {
+		return nil, nil // nothing to compare with
+	}
+	if revision == nil {
+		err := newError(ErrCodeRevisionRequired, fmt.Errorf("'%s' exists, expected its revision or force: true, current revision is %d", guid, r.Revision))
+		err.Extensions["revision"] = r.Revision
+		return nil, err
+	}
+	if r.Revision != *revision {
+		err := newError(ErrCodeConflict, fmt.Errorf("'%s' was changed, expected revision %d, found %d", guid, *revision, r.Revision))
+		err.Extensions["revision"] = r.Revision
+		return nil, err
+	}
+	return revision, nil
+}
+
+// applyShowInput copies the attributes of the input to the show
+func applyShowInput(show *a.Show, in *model.ShowInput) {
+	show.Description.Title = in.Title
+	show.Description.Summary = in.Summary
+	if in.Link != nil {
+		show.Description.Link = a.Asset{URI: *in.Link}
+	}
+	if in.Category != nil {
+		show.Description.Category = a.Category{Name: *in.Category, SubCategory: in.Subcategory}
+	} else if in.Subcategory != nil {
+		show.Description.Category.SubCategory = in.Subcategory
+	}
+	if in.Author != nil {
+		show.Description.Author = *in.Author
+	}
+	if in.Copyright != nil {
+		show.Description.Copyright = *in.Copyright
+	}
+	if in.Owner != nil {
+		show.Description.Owner = a.Owner{Name: in.Owner.Name, Email: in.Owner.Email}
+	}
+	if in.Image != nil {
+		show.Image = assetFromInput(in.Image)
+	}
+	applyLabelsInput(show.Metadata.Labels, in.Labels)
+}
+
+// applyEpisodeInput copies the attributes of the input to the episode
+func applyEpisodeInput(episode *a.Episode, in *model.EpisodeInput) error {
+	episode.Metadata.Name = in.Name
+	episode.Description.Title = in.Title
+	episode.Description.Summary = in.Summary
+	if in.Description != nil {
+		episode.Description.EpisodeText = *in.Description
+	}
+	if in.Link != nil {
+		episode.Description.Link = a.Asset{URI: *in.Link}
+	}
+	if in.Duration != nil {
+		episode.Description.Duration = *in.Duration
+	}
+	if in.Published != nil {
+		ts, err := strconv.ParseInt(*in.Published, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp '%s'", *in.Published)
+		}
+		episode.Metadata.Labels[a.LabelDate] = time.Unix(ts, 0).UTC().Format(time.RFC1123Z)
+	}
+	if in.Status != nil {
+		episode.Status = *in.Status
+	}
+	if in.Image != nil {
+		episode.Image = assetFromInput(in.Image)
+	}
+	episode.Enclosure = assetFromInput(in.Enclosure)
+	applyLabelsInput(episode.Metadata.Labels, in.Labels)
+
+	return nil
+}
+
+func applyLabelsInput(labels map[string]string, in *model.LabelsInput) {
+	if in == nil {
+		return
+	}
+	set := func(key string, value *string) {
+		if value != nil {
+			labels[key] = *value
+		}
+	}
+	set(a.LabelBlock, in.Block)
+	set(a.LabelExplicit, in.Explicit)
+	set(a.LabelType, in.Type)
+	set(a.LabelComplete, in.Complete)
+	set(a.LabelLanguage, in.Language)
+	if in.Episode != nil {
+		labels[a.LabelEpisode] = strconv.Itoa(*in.Episode)
+	}
+	if in.Season != nil {
+		labels[a.LabelSeason] = strconv.Itoa(*in.Season)
+	}
+}
+
+func assetFromInput(in *model.AssetInput) a.Asset {
+	asset := a.Asset{URI: in.URI, Rel: a.ResourceTypeExternal}
+	if in.Rel != nil {
+		asset.Rel = *in.Rel
+	}
+	if in.Type != nil {
+		asset.Type = *in.Type
+	}
+	if in.Size != nil {
+		asset.Size = *in.Size
+	}
+	return asset
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/gql/graph/model"
+)
+
+func TestApplyEpisodeInput(t *testing.T) {
+	episode := a.DefaultEpisode("episode1", "show", "guid", "parent", a.DefaultPortalEndpoint, a.DefaultCDNEndpoint)
+
+	published := "1609459200"
+	season := 2
+	in := model.EpisodeInput{
+		Name:      "episode1",
+		Title:     "title",
+		Summary:   "summary",
+		Published: &published,
+		Enclosure: &model.AssetInput{URI: "https://example.com/episode1.mp3"},
+		Labels:    &model.LabelsInput{Season: &season},
+	}
+
+	if err := applyEpisodeInput(episode, &in); err != nil {
+		t.Fatal(err)
+	}
+	if episode.Description.Title != "title" {
+		t.Errorf("expected title 'title', got '%s'", episode.Description.Title)
+	}
+	if ts := episode.PublishDateTimestamp(); ts != 1609459200 {
+		t.Errorf("expected publish date 1609459200, got %d", ts)
+	}
+	if season := episode.Metadata.Labels[a.LabelSeason]; season != "2" {
+		t.Errorf("expected season '2', got '%s'", season)
+	}
+	if episode.Enclosure.Rel != a.ResourceTypeExternal {
+		t.Errorf("expected enclosure rel '%s', got '%s'", a.ResourceTypeExternal, episode.Enclosure.Rel)
+	}
+	if episode.GUID() != "guid" {
+		t.Errorf("expected the guid to be unchanged, got '%s'", episode.GUID())
+	}
+
+	invalid := "yesterday"
+	in.Published = &invalid
+	if err := applyEpisodeInput(episode, &in); err == nil {
+		t.Error("expected an error for an invalid timestamp")
+	}
+}
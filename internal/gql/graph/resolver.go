@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 
+	"github.com/fupas/commons/pkg/util"
 	a "github.com/podops/podops/apiv1"
 	"github.com/podops/podops/internal/dataloader"
 	"github.com/podops/podops/internal/gql/graph/model"
@@ -15,37 +17,138 @@ import (
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-// Resolver holds the loaders
+// Resolver holds the loaders. ShowLoader is keyed by the production's GUID, EpisodeLoader by the episode's GUID.
 type Resolver struct {
 	ShowLoader    *dataloader.Loader
 	EpisodeLoader *dataloader.Loader
 }
 
-// LoadShow load a show
-func LoadShow(ctx context.Context, key string) (interface{}, error) {
-	p, err := backend.FindProductionByName(ctx, key)
+// LoadShows loads the shows of all production GUIDs
+func LoadShows(ctx context.Context, keys []string) ([]interface{}, []error) {
+	data := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+
+	productions, err := backend.GetProductions(ctx, keys)
+	if err != nil {
+		return data, fill(errs, err)
+	}
+	resources, err := backend.GetResources(ctx, keys)
 	if err != nil {
-		return nil, err
+		return data, fill(errs, err)
 	}
-	if p == nil {
-		return nil, fmt.Errorf("show '%s' not found", key)
+	content := readResources(ctx, resources)
+
+	for i, key := range keys {
+		p, r := productions[i], resources[i]
+		if p == nil || r == nil {
+			errs[i] = fmt.Errorf("show '%s' not found", key)
+			continue
+		}
+		if content[i].err != nil {
+			errs[i] = content[i].err
+			continue
+		}
+		show, ok := content[i].rsrc.(*a.Show)
+		if !ok {
+			errs[i] = fmt.Errorf("'%s' is not a show", key)
+			continue
+		}
+		data[i] = newShow(p, r, show)
 	}
+	return data, errs
+}
+
+// LoadEpisodes loads the episodes of all GUIDs
+func LoadEpisodes(ctx context.Context, keys []string) ([]interface{}, []error) {
+	data := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
 
-	r, err := backend.GetResource(ctx, p.GUID)
+	resources, err := backend.GetResources(ctx, keys)
 	if err != nil {
-		return nil, err
+		return data, fill(errs, err)
 	}
-
-	s, err := backend.GetResourceContent(ctx, p.GUID)
+	content := readResources(ctx, resources)
+
+	// the productions of all episodes, most likely just one
+	var parents []string
+	seen := make(map[string]bool)
+	for _, r := range resources {
+		if r != nil && !seen[r.ParentGUID] {
+			seen[r.ParentGUID] = true
+			parents = append(parents, r.ParentGUID)
+		}
+	}
+	ps, err := backend.GetProductions(ctx, parents)
 	if err != nil {
-		return nil, err
+		return data, fill(errs, err)
+	}
+	productions := make(map[string]*a.Production)
+	for _, p := range ps {
+		if p != nil {
+			productions[p.GUID] = p
+		}
 	}
-	show := s.(*a.Show) // BUG this s can be NIL
 
+	for i, key := range keys {
+		r := resources[i]
+		if r == nil || productions[r.ParentGUID] == nil {
+			errs[i] = fmt.Errorf("episode '%s' not found", key)
+			continue
+		}
+		if content[i].err != nil {
+			errs[i] = content[i].err
+			continue
+		}
+		episode, ok := content[i].rsrc.(*a.Episode)
+		if !ok {
+			errs[i] = fmt.Errorf("'%s' is not an episode", key)
+			continue
+		}
+		data[i] = newEpisode(productions[r.ParentGUID], r, episode)
+	}
+	return data, errs
+}
+
+type content struct {
+	rsrc interface{}
+	err  error
+}
+
+// readResources reads the .yaml files of all resources in parallel
+func readResources(ctx context.Context, resources []*a.Resource) []content {
+	contents := make([]content, len(resources))
+
+	var wg sync.WaitGroup
+	for i, r := range resources {
+		if r == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, location string) {
+			defer wg.Done()
+			contents[i].rsrc, _, _, contents[i].err = backend.ReadResource(ctx, location)
+		}(i, r.Location)
+	}
+	wg.Wait()
+
+	return contents
+}
+
+// fill sets all errors to err
+func fill(errs []error, err error) []error {
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func newShow(p *a.Production, r *a.Resource, show *a.Show) *model.Show {
 	category := make([]*model.Category, 1)
 	category[0] = &model.Category{
-		Name:        show.Description.Category.Name,
-		Subcategory: &show.Description.Category.SubCategory[0],
+		Name: show.Description.Category.Name,
+	}
+	if len(show.Description.Category.SubCategory) > 0 {
+		category[0].Subcategory = &show.Description.Category.SubCategory[0]
 	}
 
 	labels := &model.Labels{
@@ -78,30 +181,10 @@ func LoadShow(ctx context.Context, key string) (interface{}, error) {
 		},
 		Image: r.Image,
 		// Episodes are loaded by hthe schema.resolver implementation in order make use of the dataloader
-	}, nil
-}
-
-// LoadEpisode load a show
-func LoadEpisode(ctx context.Context, key string) (interface{}, error) {
-	r, err := backend.GetResource(ctx, key)
-	if err != nil {
-		return nil, err
-	}
-	if r == nil {
-		return nil, fmt.Errorf("episode '%s' not found", key)
-	}
-
-	e, err := backend.GetResourceContent(ctx, r.GUID)
-	if err != nil {
-		return nil, err
-	}
-	episode := e.(*a.Episode)
-
-	p, err := backend.GetProduction(ctx, r.ParentGUID)
-	if err != nil {
-		return nil, err
 	}
+}
 
+func newEpisode(p *a.Production, r *a.Resource, episode *a.Episode) *model.Episode {
 	n, _ := strconv.ParseInt(episode.Metadata.Labels[a.LabelEpisode], 10, 64)
 	season, _ := strconv.ParseInt(episode.Metadata.Labels[a.LabelSeason], 10, 64)
 	labels := &model.Labels{
@@ -119,6 +202,7 @@ func LoadEpisode(ctx context.Context, key string) (interface{}, error) {
 		Name:      episode.Metadata.Name,
 		Created:   strconv.FormatInt(r.Created, 10),
 		Published: strconv.FormatInt(r.Published, 10),
+		Status:    episode.StatusAt(util.Timestamp()),
 		Labels:    labels,
 		Description: &model.EpisodeDescription{
 			Title:       episode.Description.Title,
@@ -138,13 +222,27 @@ func LoadEpisode(ctx context.Context, key string) (interface{}, error) {
 			Name:  p.Name,
 			Title: p.Title,
 		},
-	}, nil
+	}
 }
 
 // CreateResolver returns a resolver for loading shows and episodes
 func CreateResolver() *Resolver {
-	return &Resolver{
-		ShowLoader:    dataloader.NewLoader(LoadShow, dataloader.DefaultTTL),
-		EpisodeLoader: dataloader.NewLoader(LoadEpisode, dataloader.DefaultTTL),
+	r := &Resolver{
+		ShowLoader:    dataloader.NewBatchLoader(LoadShows, dataloader.DefaultTTL),
+		EpisodeLoader: dataloader.NewBatchLoader(LoadEpisodes, dataloader.DefaultTTL),
+	}
+	// drop cached shows and episodes whenever they change
+	backend.OnChange(r.invalidate)
+
+	return r
+}
+
+// invalidate removes a changed show or episode from the loaders
+func (r *Resolver) invalidate(ctx context.Context, kind, guid string) {
+	switch kind {
+	case a.ResourceShow:
+		r.ShowLoader.Clear(ctx, guid)
+	case a.ResourceEpisode:
+		r.EpisodeLoader.Clear(ctx, guid)
 	}
 }
@@ -5,24 +5,217 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
 	"github.com/fupas/commons/pkg/util"
 	ds "github.com/fupas/platform/pkg/platform"
 	a "github.com/podops/podops/apiv1"
 	"github.com/podops/podops/internal/gql/graph/generated"
 	"github.com/podops/podops/internal/gql/graph/model"
 	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/internal/ratelimit"
+	"github.com/podops/podops/pkg/auth"
 	"github.com/podops/podops/pkg/backend"
 )
 
+func (r *mutationResolver) CreateProduction(ctx context.Context, input model.NewProduction) (*model.Production, error) {
+	clientID, err := auth.ClientIDFromContext(ctx)
+	if err != nil {
+		return nil, newError(ErrCodeUnauthenticated, err)
+	}
+
+	// validate and normalize the name
+	showName := strings.ToLower(strings.TrimSpace(input.Name))
+	if !a.ValidResourceName(showName) {
+		return nil, newError(ErrCodeValidation, fmt.Errorf("invalid name '%s'", showName))
+	}
+	title := "podcast title"
+	if input.Title != nil {
+		title = *input.Title
+	}
+	summary := "podcast summary"
+	if input.Summary != nil {
+		summary = *input.Summary
+	}
+
+	p, err := backend.CreateProduction(ctx, showName, title, summary, clientID)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	location := fmt.Sprintf("%s/show-%s.yaml", p.GUID, p.GUID)
+	if err := backend.UpdateResource(ctx, p.Name, p.GUID, a.ResourceShow, p.GUID, location); err != nil {
+		return nil, backendError(err)
+	}
+
+	return &model.Production{
+		GUID:  p.GUID,
+		Name:  p.Name,
+		Title: p.Title,
+	}, nil
+}
+
+func (r *mutationResolver) UpsertShow(ctx context.Context, input model.ShowInput) (*model.Show, error) {
+	p, clientID, err := authorizedProduction(ctx, input.GUID)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := checkRevision(ctx, p.GUID, input.Revision, input.Force)
+	if err != nil {
+		return nil, err
+	}
+
+	// start from the current show, or from the defaults if the show was never written
+	show := a.DefaultShow(p.Name, input.Title, input.Summary, p.GUID, a.DefaultPortalEndpoint, a.DefaultCDNEndpoint)
+	current, err := backend.GetResourceContent(ctx, p.GUID)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, backendError(err)
+	}
+	if s, ok := current.(*a.Show); ok {
+		show = s
+	}
+	applyShowInput(show, &input)
+
+	if v := show.Validate(a.NewValidator(a.ResourceShow)); !v.IsValid() {
+		return nil, validationError(v)
+	}
+	if _, err := backend.StoreShow(ctx, show, clientID, false, true, expected); err != nil {
+		return nil, backendError(err)
+	}
+	data, err := r.ShowLoader.Load(ctx, p.GUID)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	return data.(*model.Show), nil
+}
+
+func (r *mutationResolver) UpsertEpisode(ctx context.Context, input model.EpisodeInput) (*model.Episode, error) {
+	p, clientID, err := authorizedProduction(ctx, input.Production)
+	if err != nil {
+		return nil, err
+	}
+
+	var episode *a.Episode
+	var expected *int
+	create := input.GUID == nil || *input.GUID == ""
+
+	if create {
+		if err := backend.CheckQuota(ctx, p.Owner, 0, 1, 0); err != nil {
+			return nil, backendError(err)
+		}
+		episodes, err := backend.ListResources(ctx, p.GUID, a.ResourceEpisode)
+		if err != nil {
+			return nil, backendError(err)
+		}
+
+		id, _ := util.ShortUUID()
+		episode = a.DefaultEpisode(input.Name, p.Name, strings.ToLower(id), p.GUID, a.DefaultPortalEndpoint, a.DefaultCDNEndpoint)
+		episode.Metadata.Labels[a.LabelEpisode] = strconv.Itoa(len(episodes) + 1)
+	} else {
+		rsrc, err := backend.GetResource(ctx, *input.GUID)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		if rsrc == nil || rsrc.Kind != a.ResourceEpisode || rsrc.ParentGUID != p.GUID {
+			return nil, newError(ErrCodeNotFound, a.ErrNoSuchResource)
+		}
+		if expected, err = checkRevision(ctx, rsrc.GUID, input.Revision, input.Force); err != nil {
+			return nil, err
+		}
+		current, err := backend.GetResourceContent(ctx, rsrc.GUID)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		episode = current.(*a.Episode)
+	}
+
+	if err := applyEpisodeInput(episode, &input); err != nil {
+		return nil, newError(ErrCodeValidation, err)
+	}
+	if v := episode.Validate(a.NewValidator(a.ResourceEpisode)); !v.IsValid() {
+		return nil, validationError(v)
+	}
+	if _, err := backend.StoreEpisode(ctx, episode, clientID, create, !create, expected); err != nil {
+		return nil, backendError(err)
+	}
+	data, err := r.EpisodeLoader.Load(ctx, episode.GUID())
+	if err != nil {
+		return nil, backendError(err)
+	}
+	return data.(*model.Episode), nil
+}
+
+func (r *mutationResolver) DeleteEpisode(ctx context.Context, production string, guid string, revision *int, force *bool) (bool, error) {
+	p, _, err := authorizedProduction(ctx, production)
+	if err != nil {
+		return false, err
+	}
+
+	rsrc, err := backend.GetResource(ctx, guid)
+	if err != nil {
+		return false, backendError(err)
+	}
+	if rsrc == nil || rsrc.Kind != a.ResourceEpisode || rsrc.ParentGUID != p.GUID {
+		return false, newError(ErrCodeNotFound, a.ErrNoSuchResource)
+	}
+	expected, err := checkRevision(ctx, guid, revision, force)
+	if err != nil {
+		return false, err
+	}
+	if err := backend.DeleteResource(ctx, guid, expected); err != nil {
+		return false, backendError(err)
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) StartBuild(ctx context.Context, production string) (*model.Build, error) {
+	p, clientID, err := authorizedProduction(ctx, production)
+	if err != nil {
+		return nil, err
+	}
+	if res, err := ratelimit.Expensive.Take(ctx, ratelimit.ClientIDKey(clientID)); err == nil && !res.Allowed {
+		return nil, newError(ErrCodeRateLimited, a.ErrRateLimited)
+	}
+	if err := backend.CheckQuota(ctx, p.Owner, 0, 0, 1); err != nil {
+		return nil, backendError(err)
+	}
+
+	if err := backend.Build(ctx, p.GUID, false); err != nil {
+		return nil, newError(ErrCodeBadRequest, fmt.Errorf("error building feed '%s': %v", p.GUID, err))
+	}
+	p, err = backend.GetProduction(ctx, p.GUID)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	return &model.Build{
+		GUID:         p.GUID,
+		BuildID:      p.BuildID,
+		FeedURL:      fmt.Sprintf("%s/c/%s/feed.xml", a.DefaultCDNEndpoint, p.GUID),
+		FeedAliasURL: fmt.Sprintf("%s/s/%s/feed.xml", a.DefaultPortalEndpoint, p.Name),
+	}, nil
+}
+
 func (r *queryResolver) Show(ctx context.Context, name *string) (*model.Show, error) {
 	if r.ShowLoader == nil {
 		log.Fatal("panic: missing show loader")
 	}
 
-	data, err := r.ShowLoader.Load(ctx, *name)
+	p, err := backend.FindProductionByName(ctx, *name)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("show '%s' not found", *name)
+	}
+
+	data, err := r.ShowLoader.Load(ctx, p.GUID)
 	if err != nil {
 		platform.ReportError(err)
 		return nil, err
@@ -36,15 +229,17 @@ func (r *queryResolver) Show(ctx context.Context, name *string) (*model.Show, er
 		return nil, err
 	}
 
+	// drafts and unpublished episodes are not part of the show
+	er = backend.FilterResources(er, a.EpisodeStatusPublished)
+
 	if er != nil {
-		episodes := make([]*model.Episode, len(er))
+		guids := make([]string, len(er))
 		for i := range er {
-			e, err := r.Episode(ctx, &er[i].GUID)
-			if err != nil {
-				// no need to log, already done in r.Episode
-				return nil, err
-			}
-			episodes[i] = e
+			guids[i] = er[i].GUID
+		}
+		episodes, err := r.loadEpisodes(ctx, guids)
+		if err != nil {
+			return nil, err
 		}
 		show.Episodes = episodes
 	}
@@ -62,7 +257,157 @@ func (r *queryResolver) Episode(ctx context.Context, guid *string) (*model.Episo
 		platform.ReportError(err)
 		return nil, err
 	}
-	return data.(*model.Episode), nil
+	episode := data.(*model.Episode)
+
+	// drafts, unpublished and scheduled episodes are only visible to the owner of the production
+	if episode.Status != a.EpisodeStatusPublished {
+		if episode.Production == nil {
+			return nil, newError(ErrCodeNotFound, a.ErrNoSuchResource)
+		}
+		if _, _, err := authorizedProduction(ctx, episode.Production.GUID); err != nil {
+			return nil, newError(ErrCodeNotFound, a.ErrNoSuchResource)
+		}
+	}
+	return episode, nil
+}
+
+func (r *queryResolver) Shows(ctx context.Context, first *int, after *string, orderBy *model.ShowOrder) (*model.ShowConnection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	cursor := ""
+	if after != nil {
+		cursor = *after
+	}
+
+	productions, cursors, more, err := backend.QueryProductions(ctx, showOrder(orderBy), cursor, limit)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, backendError(err)
+	}
+
+	shows, err := r.loadShows(ctx, productions)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]*model.ShowEdge, len(shows))
+	for i, show := range shows {
+		edges[i] = &model.ShowEdge{Cursor: cursors[i], Node: show}
+	}
+
+	return &model.ShowConnection{Edges: edges, PageInfo: pageInfo(cursors, after, more)}, nil
+}
+
+func (r *queryResolver) Episodes(ctx context.Context, show string, first *int, after *string, filter *model.EpisodeFilter, orderBy *model.EpisodeOrder) (*model.EpisodeConnection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	f, err := episodeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor := ""
+	if after != nil {
+		cursor = *after
+	}
+
+	p, err := backend.FindProductionByName(ctx, show)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, backendError(err)
+	}
+	if p == nil {
+		return nil, newError(ErrCodeNotFound, fmt.Errorf("show '%s' not found", show))
+	}
+
+	resources, cursors, more, err := backend.QueryEpisodes(ctx, p.GUID, f, episodeOrder(orderBy), cursor, limit)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, backendError(err)
+	}
+
+	guids := make([]string, len(resources))
+	for i, rsrc := range resources {
+		guids[i] = rsrc.GUID
+	}
+	episodes, err := r.loadEpisodes(ctx, guids)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]*model.EpisodeEdge, len(episodes))
+	for i, episode := range episodes {
+		edges[i] = &model.EpisodeEdge{Cursor: cursors[i], Node: episode}
+	}
+
+	return &model.EpisodeConnection{Edges: edges, PageInfo: pageInfo(cursors, after, more)}, nil
+}
+
+func (r *queryResolver) Search(ctx context.Context, query string, kinds []string, first *int, after *string) (*model.SearchConnection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	if err := searchKinds(kinds); err != nil {
+		return nil, err
+	}
+	cursor := ""
+	if after != nil {
+		cursor = *after
+	}
+
+	results, err := backend.Search(ctx, query, kinds, cursor, limit)
+	if err != nil {
+		if !errors.Is(err, a.ErrInvalidCursor) {
+			platform.ReportError(err)
+		}
+		return nil, backendError(err)
+	}
+
+	edges := make([]*model.SearchEdge, len(results.Results))
+	cursors := make([]string, len(results.Results))
+	for i, result := range results.Results {
+		edges[i] = &model.SearchEdge{Cursor: result.Cursor, Node: searchResult(result)}
+		cursors[i] = result.Cursor
+	}
+
+	return &model.SearchConnection{Edges: edges, PageInfo: pageInfo(cursors, after, results.More), Truncated: results.Truncated}, nil
+}
+
+func (r *queryResolver) BuildStatus(ctx context.Context, id string) (*model.Progress, error) {
+	p, _, err := authorizedProduction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := backend.GetBuildProgress(ctx, p.GUID)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, backendError(err)
+	}
+	if progress == nil {
+		return nil, nil
+	}
+	return progressModel(progress), nil
+}
+
+func (r *queryResolver) ImportStatus(ctx context.Context, production string) ([]*model.Progress, error) {
+	p, _, err := authorizedProduction(ctx, production)
+	if err != nil {
+		return nil, err
+	}
+
+	imports, err := backend.GetImportProgress(ctx, p.GUID)
+	if err != nil {
+		platform.ReportError(err)
+		return nil, backendError(err)
+	}
+	progress := make([]*model.Progress, len(imports))
+	for i := range imports {
+		progress[i] = progressModel(imports[i])
+	}
+	return progress, nil
 }
 
 func (r *queryResolver) Recent(ctx context.Context, max int) ([]*model.Show, error) {
@@ -72,20 +417,10 @@ func (r *queryResolver) Recent(ctx context.Context, max int) ([]*model.Show, err
 		return nil, err
 	}
 
-	var shows []*model.Show
-	if sh != nil {
-		shows = make([]*model.Show, len(sh))
-		for i := range sh {
-			show, err := r.ShowLoader.Load(ctx, sh[i].Name)
-			if err != nil {
-				platform.ReportError(err)
-				return nil, err
-			}
-			shows[i] = show.(*model.Show)
-		}
+	if sh == nil {
+		return nil, nil
 	}
-
-	return shows, nil
+	return r.loadShows(ctx, sh)
 }
 
 func (r *queryResolver) Popular(ctx context.Context, max int) ([]*model.Show, error) {
@@ -96,23 +431,37 @@ func (r *queryResolver) Popular(ctx context.Context, max int) ([]*model.Show, er
 		return nil, err
 	}
 
-	var shows []*model.Show
-	if sh != nil {
-		shows = make([]*model.Show, len(sh))
-		for i := range sh {
-			show, err := r.ShowLoader.Load(ctx, sh[i].Name)
-			if err != nil {
-				platform.ReportError(err)
-				return nil, err
-			}
-			shows[i] = show.(*model.Show)
-		}
+	if sh == nil {
+		return nil, nil
 	}
+	return r.loadShows(ctx, sh)
+}
 
-	return shows, nil
+func (r *subscriptionResolver) BuildProgress(ctx context.Context, id string) (<-chan *model.Progress, error) {
+	p, _, err := authorizedProduction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return watchProgress(ctx, a.ProgressBuild, p.GUID), nil
 }
 
+func (r *subscriptionResolver) ImportProgress(ctx context.Context, production string) (<-chan *model.Progress, error) {
+	p, _, err := authorizedProduction(ctx, production)
+	if err != nil {
+		return nil, err
+	}
+	return watchProgress(ctx, a.ProgressImport, p.GUID), nil
+}
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/events"
+	"github.com/podops/podops/internal/gql/graph/model"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/backend"
+)
+
+// This file will not be regenerated automatically.
+//
+// It holds the helpers of the subscription resolvers.
+
+const (
+	// progressPollInterval is how often a subscription checks for progress published by other instances
+	progressPollInterval = 5 * time.Second
+)
+
+// watchProgress streams the progress of the builds or imports of a production until ctx is done.
+// Events published by this instance arrive immediately, those of other instances when the persisted state is polled.
+func watchProgress(ctx context.Context, kind, guid string) <-chan *model.Progress {
+	out := make(chan *model.Progress, 1)
+	published := events.Subscribe(ctx, backend.ProgressTopic(kind, guid))
+
+	// the last state of each build or import, only newer states are sent
+	last := make(map[string]*a.Progress)
+	for _, p := range pollProgress(ctx, kind, guid) {
+		last[p.Resource] = p
+	}
+
+	send := func(p *a.Progress, polled bool) bool {
+		if l, ok := last[p.Resource]; ok {
+			if p.Timestamp < l.Timestamp || *p == *l {
+				return true
+			}
+			if polled && p.Timestamp == l.Timestamp {
+				return true // the persisted state lags behind the events
+			}
+		}
+		last[p.Resource] = p
+
+		select {
+		case out <- progressModel(p):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(progressPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-published:
+				if !ok || !send(e.(*a.Progress), false) {
+					return
+				}
+			case <-ticker.C:
+				for _, p := range pollProgress(ctx, kind, guid) {
+					if !send(p, true) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// pollProgress returns the persisted progress of the builds or imports of a production, the oldest first
+func pollProgress(ctx context.Context, kind, guid string) []*a.Progress {
+	if kind == a.ProgressBuild {
+		p, err := backend.GetBuildProgress(ctx, guid)
+		if err != nil {
+			platform.ReportError(err)
+		}
+		if p == nil {
+			return nil
+		}
+		return []*a.Progress{p}
+	}
+
+	imports, err := backend.GetImportProgress(ctx, guid)
+	if err != nil {
+		platform.ReportError(err)
+		return nil
+	}
+	for i, j := 0, len(imports)-1; i < j; i, j = i+1, j-1 {
+		imports[i], imports[j] = imports[j], imports[i]
+	}
+	return imports
+}
+
+// progressModel maps the progress to the model
+func progressModel(p *a.Progress) *model.Progress {
+	return &model.Progress{
+		Kind:       p.Kind,
+		Production: p.GUID,
+		Resource:   optional(p.Resource),
+		Stage:      p.Stage,
+		Current:    int(p.Current),
+		Total:      int(p.Total),
+		Error:      optional(p.Error),
+		BuildID:    optional(p.BuildID),
+		Timestamp:  strconv.FormatInt(p.Timestamp, 10),
+	}
+}
@@ -41,10 +41,11 @@ var (
 )
 
 func init() {
-	filterPages = make([]string, 3)
+	filterPages = make([]string, 4)
 	filterPages[0] = "/assets/js"
 	filterPages[1] = "/assets/css"
 	filterPages[2] = "/assets/static"
+	filterPages[3] = "/metrics"
 
 	events = make(chan *url.Values, 100)
 	go upload()
@@ -53,15 +54,13 @@ func init() {
 // PageViewMiddleware logs page views to Google Analytics
 func PageViewMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		if err := next(c); err != nil {
-			c.Error(err)
-		}
+		err := next(c)
 
 		// skip /assets/..
 		path := c.Request().URL.Path
 		for _, s := range filterPages {
 			if strings.HasPrefix(path, s) {
-				return nil
+				return err
 			}
 		}
 
@@ -69,12 +68,14 @@ func PageViewMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		v["t"] = typePageView
 		v["ds"] = "web"
 
-		if err := PostToAnalytics(c.Request(), &v); err != nil {
-			ReportError(err)
-			return err
+		if perr := PostToAnalytics(c.Request(), &v); perr != nil {
+			ReportError(perr)
+			if err == nil {
+				return perr
+			}
 		}
 
-		return nil
+		return err
 	}
 }
 
@@ -128,7 +129,12 @@ func PostToAnalytics(request *http.Request, values *map[string]string) error {
 		formValues[k] = vv
 	}
 
-	events <- &formValues
+	// never block a request, drop the event if the upload falls behind
+	select {
+	case events <- &formValues:
+	default:
+		analyticsDropped.Inc()
+	}
 	/*
 		resp, err := http.PostForm(analyticsEndpoint, formValues)
 		if err != nil {
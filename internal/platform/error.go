@@ -1,10 +1,17 @@
 package platform
 
 import (
+	"context"
+
 	p "github.com/fupas/platform"
 )
 
+// ReportError writes the error to the log and reports it to Error Reporting
 func ReportError(e error) {
+	if e == nil {
+		return
+	}
+	LogError(context.Background(), e)
 	p.ReportError(e)
 }
 
@@ -0,0 +1,159 @@
+package platform
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxRequestIDLength limits the length of request IDs sent by clients
+	maxRequestIDLength = 64
+)
+
+type (
+	// logEntry is a log line in the format of Cloud Logging, see https://cloud.google.com/logging/docs/structured-logging
+	logEntry struct {
+		Severity    string       `json:"severity"`
+		Message     string       `json:"message"`
+		Time        time.Time    `json:"time"`
+		RequestID   string       `json:"requestId,omitempty"`
+		Route       string       `json:"route,omitempty"`
+		Error       string       `json:"error,omitempty"`
+		HTTPRequest *httpRequest `json:"httpRequest,omitempty"`
+		Trace       string       `json:"logging.googleapis.com/trace,omitempty"`
+		SpanID      string       `json:"logging.googleapis.com/spanId,omitempty"`
+	}
+
+	// httpRequest is the request part of a logEntry
+	httpRequest struct {
+		RequestMethod string `json:"requestMethod"`
+		RequestURL    string `json:"requestUrl"`
+		Status        int    `json:"status"`
+		ResponseSize  int64  `json:"responseSize,string"`
+		UserAgent     string `json:"userAgent,omitempty"`
+		RemoteIP      string `json:"remoteIp,omitempty"`
+		Referer       string `json:"referer,omitempty"`
+		Latency       string `json:"latency"`
+	}
+
+	// requestIDKey is the context key of the request ID
+	requestIDKey struct{}
+)
+
+var (
+	logOutput io.Writer = os.Stdout
+	logMu     sync.Mutex
+	projectID = env.GetString("PROJECT_ID", "")
+)
+
+// LoggingMiddleware writes a JSON log entry for every request. Each request gets an ID, either from
+// its X-Request-ID header or a new one, that is returned in the response and added to the request's context.
+// Errors are passed on to the outer middlewares, only TracingMiddleware hands them to the error handler.
+func LoggingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+
+		err := next(c)
+
+		res := c.Response()
+		status := responseStatus(c, err)
+		entry := newLogEntry(c.Request().Context(), "INFO", fmt.Sprintf("%s %s %d", req.Method, req.URL.Path, status))
+		if status >= http.StatusInternalServerError {
+			entry.Severity = "ERROR"
+		} else if status >= http.StatusBadRequest {
+			entry.Severity = "WARNING"
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		entry.Route = c.Path()
+		entry.HTTPRequest = &httpRequest{
+			RequestMethod: req.Method,
+			RequestURL:    req.RequestURI,
+			Status:        status,
+			ResponseSize:  res.Size,
+			UserAgent:     req.UserAgent(),
+			RemoteIP:      c.RealIP(),
+			Referer:       req.Referer(),
+			Latency:       fmt.Sprintf("%.6fs", time.Since(start).Seconds()),
+		}
+		writeLog(entry)
+		return err
+	}
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// LogError writes err to the log, with the request ID and trace of ctx
+func LogError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	writeLog(newLogEntry(ctx, "ERROR", err.Error()))
+}
+
+// newLogEntry returns an entry with the request ID and trace of ctx
+func newLogEntry(ctx context.Context, severity, msg string) *logEntry {
+	entry := &logEntry{
+		Severity:  severity,
+		Message:   msg,
+		Time:      time.Now().UTC(),
+		RequestID: RequestID(ctx),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.Trace = sc.TraceID().String()
+		if projectID != "" {
+			entry.Trace = fmt.Sprintf("projects/%s/traces/%s", projectID, sc.TraceID().String())
+		}
+		entry.SpanID = sc.SpanID().String()
+	}
+	return entry
+}
+
+// writeLog writes one entry per line
+func writeLog(entry *logEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	logOutput.Write(append(line, '\n'))
+}
+
+// newRequestID returns a random ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strings.ReplaceAll(time.Now().UTC().Format("20060102150405.000000000"), ".", "")
+	}
+	return hex.EncodeToString(b)
+}
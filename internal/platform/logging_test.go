@@ -0,0 +1,104 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logOutput = &buf
+
+	e := echo.New()
+	e.Use(TracingMiddleware, LoggingMiddleware, MetricsMiddleware)
+	e.GET("/p/:id", func(c echo.Context) error {
+		if RequestID(c.Request().Context()) == "" {
+			t.Error("expected a request ID in the context")
+		}
+		return echo.NewHTTPError(http.StatusNotFound, "not here")
+	})
+
+	req := httptest.NewRequest("GET", "/p/1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc")
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Header().Get(echo.HeaderXRequestID) != "abc" {
+		t.Errorf("expected request ID 'abc', got '%s'", rec.Header().Get(echo.HeaderXRequestID))
+	}
+
+	var entry logEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Severity != "WARNING" || entry.RequestID != "abc" || entry.Route != "/p/:id" || entry.HTTPRequest.Status != http.StatusNotFound {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.Error == "" {
+		t.Error("expected the handler's error")
+	}
+	if entry.Trace == "" {
+		t.Error("expected the trace of the request")
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestLoggingMiddlewareInternalError(t *testing.T) {
+	var buf bytes.Buffer
+	logOutput = &buf
+
+	e := echo.New()
+	e.Use(TracingMiddleware, LoggingMiddleware, MetricsMiddleware)
+	e.GET("/", func(c echo.Context) error {
+		return errors.New("broken")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	var entry logEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Severity != "ERROR" || entry.Error != "broken" || entry.HTTPRequest.Status != http.StatusInternalServerError {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestCloudTraceContext(t *testing.T) {
+	tests := []struct {
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"105445aa7843bc8bf206b12000100000/1;o=1", true, true},
+		{"105445aa7843bc8bf206b12000100000/18446744073709551615;o=0", true, false},
+		{"105445aa7843bc8bf206b12000100000/1", true, false},
+		{"105445aa7843bc8bf206b12000100000", false, false},
+		{"xyz/1;o=1", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Cloud-Trace-Context", tt.header)
+
+		sc := trace.SpanContextFromContext(cloudTraceContext(context.Background(), req))
+		if sc.IsValid() != tt.valid || sc.IsSampled() != tt.sampled {
+			t.Errorf("%s: expected valid=%v sampled=%v, got %v %v", tt.header, tt.valid, tt.sampled, sc.IsValid(), sc.IsSampled())
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	cloudtasks "cloud.google.com/go/cloudtasks/apiv2beta3"
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	taskspb "google.golang.org/genproto/googleapis/cloud/tasks/v2beta3"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "podops_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	buildDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "podops_build_duration_seconds",
+		Help:    "Duration of feed builds.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"result"})
+
+	importBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "podops_import_bytes_total",
+		Help: "Bytes transferred by asset imports.",
+	})
+
+	importFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "podops_import_failures_total",
+		Help: "Asset imports that failed.",
+	})
+
+	analyticsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "podops_analytics_events_dropped_total",
+		Help: "Analytics events dropped because the upload channel was full.",
+	})
+
+	taskQueueDepth = prometheus.NewDesc("podops_task_queue_depth",
+		"Estimated number of tasks in the default queue.", nil, nil)
+
+	// metricsToken protects the metrics endpoint, without it the endpoint is disabled
+	metricsToken = env.GetString("METRICS_TOKEN", "")
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, buildDuration, importBytes, importFailures, analyticsDropped, queueCollector{})
+}
+
+// queueCollector reads the depth of the default queue from the Cloud Tasks queue stats on every scrape.
+// The stats are only available in the v2beta3 API.
+type queueCollector struct{}
+
+// Describe implements prometheus.Collector
+func (queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskQueueDepth
+}
+
+// Collect implements prometheus.Collector
+func (queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := cloudtasks.NewClient(ctx)
+	if err != nil {
+		ReportError(err)
+		return
+	}
+	defer client.Close()
+
+	queue, err := client.GetQueue(ctx, &taskspb.GetQueueRequest{
+		Name:     queuePath(),
+		ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"stats"}},
+	})
+	if err != nil {
+		ReportError(err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(taskQueueDepth, prometheus.GaugeValue, float64(queue.GetStats().GetTasksCount()))
+}
+
+// MetricsMiddleware records the latency of every request by its route. Errors are passed on to
+// the outer middlewares, only TracingMiddleware hands them to the error handler.
+func MetricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		requestDuration.WithLabelValues(c.Path(), c.Request().Method, strconv.Itoa(responseStatus(c, err))).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// responseStatus returns the status of the response, or the status the error handler will send for err
+// if the response was not written yet
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// MetricsEndpoint exposes the metrics to Prometheus. It requires 'Authorization: Bearer METRICS_TOKEN',
+// without METRICS_TOKEN the metrics are not exposed at all.
+func MetricsEndpoint(c echo.Context) error {
+	if metricsToken == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Request().Header.Get("Authorization")), []byte("Bearer "+metricsToken)) != 1 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+	promhttp.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// ObserveBuild records the duration of a build that started at start
+func ObserveBuild(start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	buildDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveImport records the bytes an import transferred and whether it failed
+func ObserveImport(bytes int64, err error) {
+	importBytes.Add(float64(bytes))
+	if err != nil {
+		importFailures.Inc()
+	}
+}
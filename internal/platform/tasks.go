@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
+	"go.opentelemetry.io/otel/attribute"
 	taskspb "google.golang.org/genproto/googleapis/cloud/tasks/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/fupas/commons/pkg/env"
 )
@@ -14,6 +17,13 @@ import (
 // CreateTask is used to schedule a background task using the default queue.
 // The payload can be any struct and will be marshalled into a json string.
 func CreateTask(ctx context.Context, handler string, payload interface{}) (*taskspb.Task, error) {
+	return CreateTaskAt(ctx, handler, payload, time.Time{})
+}
+
+// CreateTaskAt schedules a background task that is not run before 'at'. A zero 'at' runs the task immediately.
+func CreateTaskAt(ctx context.Context, handler string, payload interface{}, at time.Time) (task *taskspb.Task, err error) {
+	ctx, span := StartSpan(ctx, "CreateTask", attribute.String("task", handler))
+	defer func() { EndSpan(span, err) }()
 
 	client, err := cloudtasks.NewClient(ctx)
 	if err != nil {
@@ -22,10 +32,8 @@ func CreateTask(ctx context.Context, handler string, payload interface{}) (*task
 	}
 	defer client.Close()
 
-	queuePath := fmt.Sprintf("projects/%s/locations/%s/queues/%s", env.GetString("PROJECT_ID", ""), env.GetString("LOCATION_ID", ""), env.GetString("DEFAULT_QUEUE", ""))
-
 	req := &taskspb.CreateTaskRequest{
-		Parent: queuePath,
+		Parent: queuePath(),
 		Task: &taskspb.Task{
 			MessageType: &taskspb.Task_AppEngineHttpRequest{
 				AppEngineHttpRequest: &taskspb.AppEngineHttpRequest{
@@ -37,6 +45,10 @@ func CreateTask(ctx context.Context, handler string, payload interface{}) (*task
 		},
 	}
 
+	if !at.IsZero() {
+		req.Task.ScheduleTime = timestamppb.New(at)
+	}
+
 	if payload != nil {
 		// marshal the payload
 		b, err := json.Marshal(payload)
@@ -47,11 +59,15 @@ func CreateTask(ctx context.Context, handler string, payload interface{}) (*task
 		req.Task.GetAppEngineHttpRequest().Body = b
 	}
 
-	task, err := client.CreateTask(ctx, req)
+	task, err = client.CreateTask(ctx, req)
 	if err != nil {
 		// observer.ReportError(err) FIXME
 		return nil, err
 	}
-
 	return task, nil
 }
+
+// queuePath returns the resource name of the default queue
+func queuePath() string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", env.GetString("PROJECT_ID", ""), env.GetString("LOCATION_ID", ""), env.GetString("DEFAULT_QUEUE", ""))
+}
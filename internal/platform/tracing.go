@@ -0,0 +1,151 @@
+package platform
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	otelpropagation "go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName identifies the spans of the service
+	tracerName = "github.com/podops/podops"
+)
+
+// InitTracing configures the export of spans, depending on TRACE_EXPORTER:
+// 'otlp' sends them to an OpenTelemetry collector, configured with the standard OTEL_EXPORTER_OTLP_* variables,
+// 'stdout' writes them to the log, anything else disables tracing. TRACE_SAMPLE_RATE is the
+// percentage of traces that is sampled, 10 by default.
+//
+// The spans of the Google Cloud clients, e.g. Storage and Datastore, are part of the traces.
+// The returned function flushes the remaining spans.
+func InitTracing(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch env.GetString("TRACE_EXPORTER", "") {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not create the trace exporter: %v", err)
+	}
+
+	rate := float64(env.GetInt("TRACE_SAMPLE_RATE", 10)) / 100
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(otelpropagation.NewCompositeTextMapPropagator(otelpropagation.TraceContext{}, otelpropagation.Baggage{}))
+
+	// the Google Cloud clients use OpenCensus
+	octrace.DefaultTracer = opencensus.NewTracer(provider.Tracer(tracerName))
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span as child of the span in ctx. Callers must end the span.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TracingMiddleware starts a span for every request, continuing the trace of the caller if there is one.
+// It has to be the outermost middleware, it hands the errors of the inner ones to the error handler.
+func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), otelpropagation.HeaderCarrier(req.Header))
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = cloudTraceContext(ctx, req)
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(ctx, fmt.Sprintf("%s %s", req.Method, c.Path()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(req.Method),
+				semconv.HTTPRouteKey.String(c.Path()),
+				semconv.HTTPTargetKey.String(req.RequestURI),
+			))
+		defer span.End()
+		c.SetRequest(req.WithContext(ctx))
+
+		if err := next(c); err != nil {
+			span.RecordError(err)
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+		return nil
+	}
+}
+
+// cloudTraceContext continues the trace App Engine started for the request. Its header is 'TRACE_ID/SPAN_ID;o=OPTIONS',
+// with a decimal SPAN_ID.
+func cloudTraceContext(ctx context.Context, req *http.Request) context.Context {
+	h := req.Header.Get("X-Cloud-Trace-Context")
+	slash := strings.Index(h, "/")
+	if slash < 0 {
+		return ctx
+	}
+	traceID, err := trace.TraceIDFromHex(h[:slash])
+	if err != nil {
+		return ctx
+	}
+
+	span := h[slash+1:]
+	flags := trace.TraceFlags(0)
+	if semi := strings.Index(span, ";"); semi >= 0 {
+		if span[semi+1:] == "o=1" {
+			flags = trace.FlagsSampled
+		}
+		span = span[:semi]
+	}
+	n, err := strconv.ParseUint(span, 10, 64)
+	if err != nil {
+		return ctx
+	}
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], n)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: flags, Remote: true})
+	if !sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
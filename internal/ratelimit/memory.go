@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// sweepInterval is how often full buckets are removed from a MemoryStore
+	sweepInterval = time.Minute
+)
+
+type (
+	// MemoryStore keeps the token buckets in memory
+	MemoryStore struct {
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		swept   time.Time
+		now     func() time.Time
+	}
+
+	bucket struct {
+		tokens  float64
+		updated time.Time
+		full    time.Time // when the bucket is full again, it can be removed after that
+	}
+)
+
+// NewMemoryStore returns an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	perToken := limit.Period / time.Duration(limit.Rate) // the time it takes to add one token
+
+	b := s.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	// refill
+	b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	res := Result{Limit: int(burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((burst - b.tokens) * float64(perToken))
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep removes the buckets that are full again, they are the same as a new bucket
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+	s.swept = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package ratelimit
+
+/*
+Rate limiting with token buckets. Every key, e.g. a client ID or an IP address, has a bucket of Burst tokens
+that refills at Rate tokens per Period. A request takes one token and is rejected with 429 if the bucket is empty.
+
+Responses carry the headers of https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/:
+RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset, and Retry-After if the request was rejected.
+
+The buckets are kept in a Store. MemoryStore keeps them in the instance, i.e. every instance applies the
+limits on its own. Replace DefaultStore with an implementation backed by e.g. Redis to share them.
+
+*/
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/auth"
+	"google.golang.org/appengine"
+)
+
+const (
+	// clientCacheTTL is how long the client ID of a token is cached
+	clientCacheTTL = 5 * time.Minute
+	// appEngineIPHeader is the client's IP address as seen by the App Engine frontend
+	appEngineIPHeader = "X-Appengine-User-Ip"
+)
+
+type (
+	// Limit allows Rate requests per Period, with bursts of up to Burst requests. A zero Rate disables the limit.
+	Limit struct {
+		Rate   int
+		Period time.Duration
+		Burst  int
+	}
+
+	// Result is the state of a bucket after a request took a token
+	Result struct {
+		Allowed    bool
+		Limit      int           // the size of the bucket
+		Remaining  int           // the tokens left
+		Reset      time.Duration // until the bucket is full again
+		RetryAfter time.Duration // until the next token is available, if the request was not allowed
+	}
+
+	// Store keeps the token buckets
+	Store interface {
+		// Take takes a token from the bucket of key and returns the state of the bucket
+		Take(ctx context.Context, key string, limit Limit) (Result, error)
+	}
+
+	// KeyFunc returns the key of the bucket a request takes its token from
+	KeyFunc func(c echo.Context) string
+
+	// Limiter applies a limit to the requests of each key
+	Limiter struct {
+		Name  string // separates the buckets of different limiters in a shared store
+		Limit Limit
+		Store Store // DefaultStore if nil
+	}
+
+	// clientEntry caches the client ID of a token
+	clientEntry struct {
+		clientID string
+		expires  time.Time
+	}
+)
+
+var (
+	// DefaultStore keeps the buckets of all limiters that have no store of their own
+	DefaultStore Store = NewMemoryStore()
+
+	// API limits the requests of each client to /a/v1
+	API = New("api", FromEnv("RATE_LIMIT_API", "600/1m"))
+	// Expensive limits builds, uploads and imports of each client
+	Expensive = New("expensive", FromEnv("RATE_LIMIT_EXPENSIVE", "60/1h"))
+	// CDN limits the requests of each IP to feeds, assets and the other public routes
+	CDN = New("cdn", FromEnv("RATE_LIMIT_CDN", "6000/1m"))
+	// lookups limits how often each IP can have an unknown token resolved, random tokens must not cause a query per request
+	lookups = New("lookup", FromEnv("RATE_LIMIT_LOOKUP", "60/1m"))
+
+	clients sync.Map // token hash -> *clientEntry
+
+	// extractXFF trusts the proxies in local and private networks
+	extractXFF = echo.ExtractIPFromXFFHeader(echo.TrustLoopback(true), echo.TrustLinkLocal(true), echo.TrustPrivateNet(true))
+)
+
+// New returns a limiter that uses DefaultStore
+func New(name string, limit Limit) *Limiter {
+	return &Limiter{Name: name, Limit: limit}
+}
+
+// ParseLimit parses a limit like '600/1m' (600 requests per minute) or '60/1h:10' (60 requests per hour, in bursts of at most 10).
+// 'off' or '0' disable the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" || s == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid limit '%s', expected e.g. '600/1m'", s)
+	}
+	rate, err := strconv.Atoi(parts[0])
+	if err != nil || rate < 0 {
+		return Limit{}, fmt.Errorf("invalid rate in limit '%s'", s)
+	}
+
+	burst := rate
+	period := parts[1]
+	if i := strings.Index(period, ":"); i >= 0 {
+		if burst, err = strconv.Atoi(period[i+1:]); err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid burst in limit '%s'", s)
+		}
+		period = period[:i]
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in limit '%s'", s)
+	}
+	return Limit{Rate: rate, Period: d, Burst: burst}, nil
+}
+
+// FromEnv reads a limit from environment variable name, def is used if the variable is missing or invalid
+func FromEnv(name, def string) Limit {
+	l, err := ParseLimit(env.GetString(name, def))
+	if err != nil {
+		platform.ReportError(fmt.Errorf("%s: %v", name, err))
+		l, _ = ParseLimit(def)
+	}
+	return l
+}
+
+// Take takes a token for key. Requests are always allowed if the limit is disabled.
+func (l *Limiter) Take(ctx context.Context, key string) (Result, error) {
+	if l.Limit.Rate == 0 {
+		return Result{Allowed: true}, nil
+	}
+	store := l.Store
+	if store == nil {
+		store = DefaultStore
+	}
+	return store.Take(ctx, l.Name+":"+key, l.Limit)
+}
+
+// Middleware rejects requests with 429 if the bucket of their key is empty. Requests are
+// not rejected if the store fails, rate limiting must never take the service down.
+func (l *Limiter) Middleware(key KeyFunc, skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if l.Limit.Rate == 0 || (skipper != nil && skipper(c)) {
+				return next(c)
+			}
+
+			res, err := l.Take(appengine.NewContext(c.Request()), key(c))
+			if err != nil {
+				platform.ReportError(err)
+				return next(c)
+			}
+
+			SetHeaders(c.Response().Header(), res)
+			if !res.Allowed {
+				resp := a.NewErrorStatus(http.StatusTooManyRequests, a.ErrRateLimited)
+				return c.JSON(http.StatusTooManyRequests, &resp)
+			}
+			return next(c)
+		}
+	}
+}
+
+// SetHeaders adds the RateLimit-* headers, and Retry-After if the request was not allowed
+func SetHeaders(h http.Header, res Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+	}
+}
+
+// ExtractIP returns the client's IP address. Use it as the router's IPExtractor, the default trusts the
+// first X-Forwarded-For entry, which every client can set. The App Engine frontend sets X-Appengine-User-IP
+// and removes it from incoming requests. Outside of App Engine, e.g. in development, the nearest
+// X-Forwarded-For entry that is not a local address is used.
+func ExtractIP(req *http.Request) string {
+	if ip := req.Header.Get(appEngineIPHeader); ip != "" {
+		return ip
+	}
+	return extractXFF(req)
+}
+
+// IPKey returns the client's IP address
+func IPKey(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// ClientKey returns the client ID of the request's bearer token, or its IP address if there is no valid token.
+// Unknown tokens are only resolved while the IP has lookups left, see RATE_LIMIT_LOOKUP.
+func ClientKey(c echo.Context) string {
+	token := auth.GetBearerToken(c)
+	if token == "" {
+		return IPKey(c)
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	hash := hex.EncodeToString(sum[:])
+	if e, ok := clients.Load(hash); ok && time.Now().Before(e.(*clientEntry).expires) {
+		return ClientIDKey(e.(*clientEntry).clientID)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	if res, err := lookups.Take(ctx, IPKey(c)); err == nil && !res.Allowed {
+		return IPKey(c)
+	}
+	clientID, err := auth.ClientIDFromToken(ctx, token)
+	if err != nil {
+		return IPKey(c)
+	}
+	clients.Store(hash, &clientEntry{clientID: clientID, expires: time.Now().Add(clientCacheTTL)})
+	return ClientIDKey(clientID)
+}
+
+// ClientIDKey returns the key of an authenticated client, e.g. to call Take outside of the middleware
+func ClientIDKey(clientID string) string {
+	return "client:" + clientID
+}
+
+// seconds rounds d up to full seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
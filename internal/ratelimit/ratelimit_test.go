@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]Limit{
+		"600/1m":   {Rate: 600, Period: time.Minute, Burst: 600},
+		"60/1h:10": {Rate: 60, Period: time.Hour, Burst: 10},
+		"off":      {},
+		"0":        {},
+	}
+	for s, want := range tests {
+		got, err := ParseLimit(s)
+		if err != nil {
+			t.Errorf("ParseLimit(%s): %v", s, err)
+		}
+		if got != want {
+			t.Errorf("ParseLimit(%s) = %v, want %v", s, got, want)
+		}
+	}
+	for _, s := range []string{"600", "x/1m", "60/1x", "60/1m:0"} {
+		if _, err := ParseLimit(s); err == nil {
+			t.Errorf("ParseLimit(%s): expected an error", s)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if res, _ := s.Take(ctx, "a", limit); !res.Allowed || res.Remaining != 1-i {
+			t.Fatalf("request %d: expected to be allowed with %d remaining, got %+v", i, 1-i, res)
+		}
+	}
+	res, _ := s.Take(ctx, "a", limit)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected to be rejected for 500ms, got %+v", res)
+	}
+	if res, _ := s.Take(ctx, "b", limit); !res.Allowed {
+		t.Error("expected other keys to have their own bucket")
+	}
+
+	// one token is added every 500ms
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := s.Take(ctx, "a", limit); !res.Allowed || res.Remaining != 0 || res.Reset != time.Second {
+		t.Errorf("expected a refilled token, got %+v", res)
+	}
+
+	// full buckets are removed
+	now = now.Add(2 * sweepInterval)
+	s.Take(ctx, "c", limit)
+	if len(s.buckets) != 1 {
+		t.Errorf("expected full buckets to be removed, %d left", len(s.buckets))
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := &Limiter{Name: "test", Limit: Limit{Rate: 1, Period: time.Minute, Burst: 1}, Store: NewMemoryStore()}
+	e := echo.New()
+	h := l.Middleware(IPKey, nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		h(e.NewContext(req, rec))
+		return rec
+	}
+
+	rec := serve()
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("expected 200 with RateLimit headers, got %d %v", rec.Code, rec.Header())
+	}
+	rec = serve()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected 429 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestExtractIP(t *testing.T) {
+	tests := []struct {
+		remote    string
+		appEngine string
+		xff       string
+		expected  string
+	}{
+		{"169.254.1.1:1234", "198.51.100.7", "203.0.113.9, 198.51.100.7", "198.51.100.7"},
+		{"127.0.0.1:1234", "", "203.0.113.9, 198.51.100.7, 10.0.0.1", "198.51.100.7"},
+		{"192.0.2.1:1234", "", "203.0.113.9", "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.appEngine != "" {
+			req.Header.Set("X-Appengine-User-IP", tt.appEngine)
+		}
+		if tt.xff != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, tt.xff)
+		}
+		if ip := ExtractIP(req); ip != tt.expected {
+			t.Errorf("expected '%s', got '%s'", tt.expected, ip)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		match    string
+		revision int
+		want     bool
+	}{
+		{`"3"`, 3, true},
+		{`"2"`, 3, false},
+		{`"1", "3"`, 3, true},
+		{`*`, 3, true},
+		{`W/"3"`, 3, false}, // If-Match uses the strong comparison
+		{`3`, 3, false},
+		{``, 0, false},
+	}
+	for _, tt := range tests {
+		if got := MatchETag(tt.match, tt.revision); got != tt.want {
+			t.Errorf("MatchETag(%s, %d) = %v, want %v", tt.match, tt.revision, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -9,6 +10,11 @@ import (
 	"google.golang.org/appengine"
 )
 
+type contextKey string
+
+// clientIDKey is the key of the authenticated client in a request context
+const clientIDKey contextKey = "client_id"
+
 // Authorized verifies that clientID can access resource kind/GUID
 func Authorized(c echo.Context, role string) (int, error) {
 	//return fmt.Errorf("Not allowed to access '%s/%s'", kind, guid)
@@ -37,11 +43,15 @@ func GetBearerToken(c echo.Context) string {
 
 // GetClientID extracts the ClientID from the token
 func GetClientID(c echo.Context) (string, error) {
-	token := GetBearerToken(c)
+	return ClientIDFromToken(appengine.NewContext(c.Request()), GetBearerToken(c))
+}
+
+// ClientIDFromToken returns the ClientID the token belongs to
+func ClientIDFromToken(ctx context.Context, token string) (string, error) {
 	if token == "" {
 		return "", a.ErrNoToken
 	}
-	auth, err := FindAuthorization(appengine.NewContext(c.Request()), token)
+	auth, err := FindAuthorization(ctx, token)
 	if err != nil {
 		return "", err
 	}
@@ -51,3 +61,17 @@ func GetClientID(c echo.Context) (string, error) {
 
 	return auth.ClientID, nil
 }
+
+// WithClientID returns a copy of ctx that carries the authenticated client
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey, clientID)
+}
+
+// ClientIDFromContext returns the client added by WithClientID
+func ClientIDFromContext(ctx context.Context) (string, error) {
+	clientID, ok := ctx.Value(clientIDKey).(string)
+	if !ok || clientID == "" {
+		return "", a.ErrNotAuthorized
+	}
+	return clientID, nil
+}
@@ -85,6 +85,20 @@ func CreateAuthorization(ctx context.Context, auth *Authorization) error {
 	return err
 }
 
+// DeleteAuthorizations removes all authorizations issued for a realm, e.g. a production
+func DeleteAuthorizations(ctx context.Context, realm string) error {
+	var auth []*Authorization
+
+	keys, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreAuthorizations).Filter("Name =", realm), &auth)
+	if err != nil {
+		return err
+	}
+	for _, a := range auth {
+		s.InvalidateKV(ctx, namedKey(a.ClientID, a.AuthType))
+	}
+	return platform.DataStore().DeleteMulti(ctx, keys)
+}
+
 // authorizationKey creates a datastore key for a workspace authorization based on the team_id.
 func authorizationKey(clientID, authType string) *datastore.Key {
 	return datastore.NameKey(DatastoreAuthorizations, namedKey(clientID, authType), nil)
@@ -26,10 +26,46 @@ func (e EpisodeList) Less(i, j int) bool {
 	return e[i].PublishDateTimestamp() > e[j].PublishDateTimestamp() // sorting direction is descending
 }
 
-// Build gathers all resources and builds the feed
-func Build(ctx context.Context, guid string, validateOnly bool) error {
+// Build gathers all resources and builds the feed. Its progress is published on topic ProgressTopic(a.ProgressBuild, guid).
+func Build(ctx context.Context, guid string, validateOnly bool) (err error) {
+	start := time.Now()
+	ctx, span := startSpan(ctx, "Build", guid)
+
+	progress := &a.Progress{Kind: a.ProgressBuild, GUID: guid, Stage: a.StageStarted}
+	report := func(persist bool) {
+		if !validateOnly {
+			publishProgress(ctx, progress, persist)
+		}
+	}
+	report(true)
+	var due []*a.Episode // scheduled episodes this build publishes
+	defer func() {
+		if err != nil {
+			progress.Stage = a.StageFailed
+			progress.Error = err.Error()
+		} else {
+			progress.Stage = a.StageDone
+		}
+		report(true)
+		endSpan(span, err)
+
+		if validateOnly {
+			return
+		}
+		p.ObserveBuild(start, err)
+		if err != nil {
+			fireEvent(ctx, &a.WebhookEvent{Event: a.EventBuildFailed, GUID: guid, Error: err.Error()})
+			return
+		}
+		fireEvent(ctx, &a.WebhookEvent{Event: a.EventBuildSucceeded, GUID: guid, BuildID: progress.BuildID, URL: FeedURL(guid)})
+		for _, e := range due {
+			fireEvent(ctx, episodeEvent(a.EventEpisodePublished, e))
+		}
+	}()
 
 	var episodes EpisodeList
+	next := int64(0) // publish date of the next scheduled episode
+	now := util.Timestamp()
 
 	p, err := GetProduction(ctx, guid)
 	if err != nil {
@@ -40,6 +76,7 @@ func Build(ctx context.Context, guid string, validateOnly bool) error {
 	}
 
 	// find all episodes and sort them by pubDate
+	progress.Stage = a.StageEpisodes
 	q := &storage.Query{
 		Prefix: fmt.Sprintf("%s/episode", p.GUID),
 	}
@@ -59,21 +96,42 @@ func Build(ctx context.Context, guid string, validateOnly bool) error {
 		}
 		episode := e.(*a.Episode)
 
-		// FIXME skip episodes if block == yes or publish date is in the future
-		if episode.PublishDateTimestamp() > util.Timestamp() {
+		progress.Current++
+		report(false)
+
+		// skip drafts, unpublished episodes and episodes with a publish date in the future
+		switch episode.StatusAt(now) {
+		case a.EpisodeStatusScheduled:
+			if ts := episode.PublishDateTimestamp(); next == 0 || ts < next {
+				next = ts
+			}
 			continue
-		}
-		if episode.Metadata.Labels[a.LabelBlock] == "yes" {
+		case a.EpisodeStatusDraft, a.EpisodeStatusUnpublished:
 			continue
 		}
 
+		// written before its publish date and not part of a previous build
+		if ts := episode.PublishDateTimestamp(); ts > p.BuildDate && attr.Updated.Unix() < ts {
+			due = append(due, episode)
+		}
 		episodes = append(episodes, episode)
 	}
 	if episodes.Len() == 0 {
+		if !validateOnly {
+			// the first episode might be scheduled
+			p.NextBuild = next
+			if err := UpdateProduction(ctx, p); err != nil {
+				return err
+			}
+		}
 		return fmt.Errorf("can not build feed with zero episodes")
 	}
 
 	sort.Sort(episodes)
+	progress.Stage = a.StageFeed
+	progress.Current = int64(episodes.Len())
+	progress.Total = progress.Current
+	report(true)
 
 	// read the show
 	s, kind, _, err := ReadResource(ctx, fmt.Sprintf("%s/show-%s.yaml", guid, guid))
@@ -86,10 +144,16 @@ func Build(ctx context.Context, guid string, validateOnly bool) error {
 
 	// build the feed XML
 	show := s.(*a.Show)
+	if p.State == a.ProductionStateMoved {
+		// keep announcing the new location until all subscribers moved
+		show.Description.NewFeed = &a.Asset{URI: p.NewFeedURL, Rel: a.ResourceTypeExternal}
+	}
 	feed, err := a.TransformToPodcast(show)
 	if err != nil {
 		return err
 	}
+	feed.AddAtomLink(FeedURL(guid))
+	feed.AddHubLink(HubURL())
 
 	tt, _ := time.Parse(time.RFC1123Z, episodes[0].PublishDate())
 	feed.AddPubDate(&tt)
@@ -109,17 +173,46 @@ func Build(ctx context.Context, guid string, validateOnly bool) error {
 		return nil
 	}
 
-	// dump the feed to the CDN location
-	obj := platform.Storage().Bucket(a.BucketCDN).Object(fmt.Sprintf("%s/feed.xml", guid))
-	writer := obj.NewWriter(ctx)
-	if _, err := writer.Write(feed.Bytes()); err != nil {
+	// keep a copy of the feed, then dump it to the CDN location
+	data := feed.Bytes()
+	snapshot, err := snapshotFeed(ctx, guid, data, episodes.Len())
+	if err != nil {
 		return err
 	}
-	if err := writer.Close(); err != nil {
+	if err := publishFeed(ctx, guid, data); err != nil {
+		return err
+	}
+	if err := trackBuild(ctx, guid); err != nil {
 		return err
 	}
 
-	return nil
+	// record the build and when to rebuild the feed
+	progress.BuildID = snapshot.BuildID
+	p.BuildID = snapshot.BuildID
+	p.BuildDate = now
+	p.NextBuild = next
+	return UpdateProduction(ctx, p)
+}
+
+// scheduleBuild makes sure that the feed is rebuilt once an episode's publish date 'ts' has passed
+func scheduleBuild(ctx context.Context, guid string, ts int64) error {
+	if ts <= util.Timestamp() {
+		return nil
+	}
+
+	p, err := GetProduction(ctx, guid)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("can not find '%s'", guid)
+	}
+	if p.NextBuild != 0 && p.NextBuild <= ts {
+		return nil // an earlier rebuild is already scheduled
+	}
+
+	p.NextBuild = ts
+	return UpdateProduction(ctx, p)
 }
 
 // EnsureAsset validates the existence of the asset and imports it if necessary
@@ -140,21 +233,22 @@ func EnsureAsset(ctx context.Context, parent string, rsrc *a.Asset) error {
 		if err != nil {
 			return err
 		}
-
-		path := rsrc.FingerprintURI(parent)
-		if resourceExists(ctx, path) { // do nothing as the asset is present FIXME re-download if --force is set
-			return nil // FIXME verify that the asset is unchanged, otherwise re-import
-		}
-
-		// dispatch a request for background import
-		_, err = p.CreateTask(ctx, importTaskWithPrefix, &a.Import{Source: rsrc.URI, Dest: path})
-		if err != nil {
-			return err
-		}
+		return scheduleImport(ctx, parent, rsrc)
 	}
 	return nil
 }
 
+// scheduleImport dispatches a request for background import of the asset unless it already exists
+func scheduleImport(ctx context.Context, parent string, rsrc *a.Asset) error {
+	path := rsrc.FingerprintURI(parent)
+	if resourceExists(ctx, path) { // do nothing as the asset is present FIXME re-download if --force is set
+		return nil // FIXME verify that the asset is unchanged, otherwise re-import
+	}
+
+	_, err := p.CreateTask(ctx, importTaskWithPrefix, &a.Import{Source: rsrc.URI, Dest: path})
+	return err
+}
+
 // pingURL tries a HEAD or GET request to verify that 'url' exists and is reachable
 func pingURL(url string) (http.Header, error) {
 
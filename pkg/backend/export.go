@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"google.golang.org/api/iterator"
+)
+
+// ExportProduction writes a zip archive of everything stored for a production to w:
+// its metadata and inventory as .json, all files from the production bucket in 'production/'
+// and all assets and feeds from the CDN bucket in 'cdn/'.
+func ExportProduction(ctx context.Context, p *a.Production, w io.Writer) (err error) {
+	ctx, span := startSpan(ctx, "ExportProduction", p.GUID)
+	defer func() { endSpan(span, err) }()
+
+	archive := zip.NewWriter(w)
+
+	resources, err := ListResources(ctx, p.GUID, a.ResourceALL)
+	if err != nil {
+		return err
+	}
+	var audit []*a.AuditEntry
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreAudit).Filter("ParentGUID =", p.GUID), &audit); err != nil {
+		return err
+	}
+	var revisions []*a.Revision
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreRevisions).Filter("ParentGUID =", p.GUID), &revisions); err != nil {
+		return err
+	}
+	var builds []*a.FeedSnapshot
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreBuilds).Filter("GUID =", p.GUID), &builds); err != nil {
+		return err
+	}
+
+	metadata := map[string]interface{}{
+		"production.json": p,
+		"resources.json":  resources,
+		"audit.json":      audit,
+		"revisions.json":  revisions,
+		"builds.json":     builds,
+	}
+	for name, v := range metadata {
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	if err := archiveObjects(ctx, archive, a.BucketProduction, p.GUID, "production"); err != nil {
+		return err
+	}
+	if err := archiveObjects(ctx, archive, a.BucketCDN, p.GUID, "cdn"); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// archiveObjects copies all objects in folder 'guid' of a bucket into folder 'dir' of the archive
+func archiveObjects(ctx context.Context, archive *zip.Writer, bucket, guid, dir string) error {
+	bkt := platform.Storage().Bucket(bucket)
+
+	it := bkt.Objects(ctx, &storage.Query{Prefix: guid + "/"})
+	for {
+		attr, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		f, err := archive.Create(fmt.Sprintf("%s/%s", dir, strings.TrimPrefix(attr.Name, guid+"/")))
+		if err != nil {
+			return err
+		}
+		reader, err := bkt.Object(attr.Name).NewReader(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
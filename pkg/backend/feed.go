@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fupas/commons/pkg/util"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"github.com/podops/podops/pkg/rss"
+)
+
+const (
+	// feedTimeout limits the time to retrieve a feed
+	feedTimeout = 30 * time.Second
+	// maxFeedSize limits the size of an imported feed
+	maxFeedSize = 20 << 20
+)
+
+var feedClient = &http.Client{Timeout: feedTimeout}
+
+// ImportFeed creates a new production from an existing podcast feed. The show and all its episodes
+// are converted into resources, images and media files are imported into the CDN in the background.
+// The show and its episodes are validated before anything is written, if the import fails anyway,
+// the new production is removed again.
+func ImportFeed(ctx context.Context, feedURL, name, clientID string) (_ *a.FeedImport, err error) {
+	ch, err := fetchFeed(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	if ch.PodcastLocked == "yes" {
+		return nil, fmt.Errorf("can not import '%s': the feed is locked by its owner", feedURL)
+	}
+	if err := CheckQuota(ctx, clientID, 0, int64(len(ch.Items)), 0); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = a.ImportName(ch.Title)
+	}
+	if !a.ValidResourceName(name) {
+		return nil, fmt.Errorf("invalid name '%s'", name)
+	}
+
+	// never import into an existing production
+	p, err := FindProductionByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return nil, fmt.Errorf("name '%s' already exists", name)
+	}
+	p, err = FindProductionByFeedURL(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return nil, fmt.Errorf("'%s' has already been imported", feedURL)
+	}
+
+	p, err = CreateProduction(ctx, name, ch.Title, ch.Description, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if derr := DeleteProduction(ctx, p.GUID); derr != nil {
+				platform.ReportError(fmt.Errorf("can not remove the failed import '%s': %w", p.GUID, derr))
+			}
+		}
+	}()
+
+	// the show
+	show := a.TransformFromPodcast(ch, p.Name, p.GUID)
+	if v := show.Validate(a.NewValidator(a.ResourceShow)); !v.IsValid() {
+		return nil, fmt.Errorf(v.Error())
+	}
+
+	// the episodes, the oldest one first
+	var episodes []*a.Episode
+	names := make(map[string]bool)
+	for i := len(ch.Items) - 1; i >= 0; i-- {
+		item := ch.Items[i]
+		if item.Enclosure == nil {
+			continue // e.g. a blog post
+		}
+
+		id, _ := util.ShortUUID()
+		n := len(episodes) + 1
+
+		episode, err := a.TransformFromItem(item, uniqueName(names, item.Title, n), strings.ToLower(id), show, n)
+		if err != nil {
+			return nil, err
+		}
+		if v := episode.Validate(a.NewValidator(a.ResourceEpisode)); !v.IsValid() {
+			return nil, fmt.Errorf("item '%s': %s", item.Title, v.Error())
+		}
+		episodes = append(episodes, episode)
+	}
+
+	location := fmt.Sprintf("%s/show-%s.yaml", p.GUID, p.GUID)
+	if err := WriteResourceContent(ctx, location, false, true, show); err != nil {
+		return nil, err
+	}
+	if err := UpdateShow(ctx, location, show); err != nil {
+		return nil, err
+	}
+	if _, err := RecordRevision(ctx, p.GUID, clientID, show); err != nil {
+		return nil, err
+	}
+
+	p.Title = show.Description.Title
+	p.Summary = show.Description.Summary
+	p.Updated = util.Timestamp()
+	if err := setOldFeedURL(ctx, p, feedURL); err != nil {
+		return nil, err
+	}
+	if err := UpdateProduction(ctx, p); err != nil {
+		return nil, err
+	}
+
+	for _, episode := range episodes {
+		location := fmt.Sprintf("%s/episode-%s.yaml", p.GUID, episode.GUID())
+		if err := UpdateEpisode(ctx, location, episode); err != nil {
+			return nil, err
+		}
+		if err := WriteResourceContent(ctx, location, true, false, episode); err != nil {
+			return nil, err
+		}
+		if _, err := RecordRevision(ctx, episode.GUID(), clientID, episode); err != nil {
+			return nil, err
+		}
+	}
+
+	// the assets last, nothing is imported for a failed import
+	scheduled := make(map[string]bool) // many episodes share the same image
+	importAssets := func(assets ...*a.Asset) error {
+		for _, asset := range assets {
+			if asset.Rel != a.ResourceTypeImport || scheduled[asset.URI] {
+				continue
+			}
+			if err := scheduleImport(ctx, p.GUID, asset); err != nil {
+				return err
+			}
+			scheduled[asset.URI] = true
+		}
+		return nil
+	}
+	if err := importAssets(&show.Image); err != nil {
+		return nil, err
+	}
+	for _, episode := range episodes {
+		if err := importAssets(&episode.Image, &episode.Enclosure); err != nil {
+			return nil, err
+		}
+	}
+
+	return &a.FeedImport{URL: feedURL, Name: p.Name, GUID: p.GUID, Episodes: len(episodes)}, nil
+}
+
+// fetchFeed retrieves and parses a podcast feed
+func fetchFeed(feedURL string) (*rss.Channel, error) {
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", a.UserAgentString)
+
+	resp, err := feedClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can not retrieve '%s': %s", feedURL, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("can not retrieve '%s': %w", feedURL, err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, fmt.Errorf("can not import '%s': the feed is larger than %d bytes", feedURL, maxFeedSize)
+	}
+	return rss.Parse(bytes.NewReader(data))
+}
+
+// uniqueName derives an episode name from its title
+func uniqueName(names map[string]bool, title string, index int) string {
+	name := a.ImportName(title)
+	if name == "" {
+		name = fmt.Sprintf("episode-%d", index)
+	}
+	if names[name] {
+		name = fmt.Sprintf("%s-%d", name, index)
+	}
+	names[name] = true
+	return name
+}
@@ -0,0 +1,282 @@
+package backend
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"google.golang.org/api/iterator"
+)
+
+// CheckProduction cross-checks the inventory of a production with the files in the production
+// and CDN buckets. With repair == true, orphaned files are added to the inventory, dangling
+// entries are removed, mismatched entries are updated and imports are moved from their legacy
+// location. Duplicate names are only reported.
+func CheckProduction(ctx context.Context, guid string, repair bool) (_ *a.FsckReport, err error) {
+	ctx, span := startSpan(ctx, "CheckProduction", guid)
+	defer func() { endSpan(span, err) }()
+
+	report := a.FsckReport{GUID: guid}
+
+	// first, the other checks see the moved imports
+	report.Issues, err = checkLegacyImports(ctx, guid, repair)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := ListResources(ctx, guid, a.ResourceALL)
+	if err != nil {
+		return nil, err
+	}
+	report.Resources = len(resources)
+
+	yamls, err := listObjects(ctx, a.BucketProduction, guid)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := listObjects(ctx, a.BucketCDN, guid)
+	if err != nil {
+		return nil, err
+	}
+	report.Objects = len(yamls) + len(assets)
+	indexed, err := listSearchEntries(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string][]*a.Resource)
+	for _, r := range resources {
+		names[r.Name] = append(names[r.Name], r)
+
+		objects := yamls
+		if r.Kind == a.ResourceAsset {
+			objects = assets
+		}
+		attr, ok := objects[r.Location]
+		if !ok {
+			issue := &a.FsckIssue{Issue: a.FsckDanglingEntry, GUID: r.GUID, Name: r.Name, Location: r.Location, Message: fmt.Sprintf("%s '%s' has no file", r.Kind, r.Name)}
+			if repair {
+				issue.Repaired = deleteResource(ctx, r.GUID, nil) == nil
+			}
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+		delete(objects, r.Location)
+
+		if r.Kind == a.ResourceAsset {
+			if issue := checkAsset(ctx, r, attr, repair); issue != nil {
+				report.Issues = append(report.Issues, issue)
+			}
+		}
+		if r.Kind == a.ResourceEpisode && r.EpisodeType == "" {
+			// written before the inventory kept the labels used to filter episodes
+			issue := &a.FsckIssue{Issue: a.FsckMismatch, GUID: r.GUID, Name: r.Name, Location: r.Location, Message: fmt.Sprintf("episode '%s' has no labels in the inventory", r.Name)}
+			if repair {
+				issue.Repaired = reindexResource(ctx, r) == nil
+			}
+			report.Issues = append(report.Issues, issue)
+		} else if (r.Kind == a.ResourceEpisode || r.Kind == a.ResourceShow) && !indexed[r.GUID] {
+			// written before the search index existed
+			issue := &a.FsckIssue{Issue: a.FsckMismatch, GUID: r.GUID, Name: r.Name, Location: r.Location, Message: fmt.Sprintf("%s '%s' is not in the search index", r.Kind, r.Name)}
+			if repair {
+				issue.Repaired = reindexResource(ctx, r) == nil
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	for name, r := range names {
+		if len(r) > 1 {
+			report.Issues = append(report.Issues, &a.FsckIssue{Issue: a.FsckDuplicateName, Name: name, Location: r[0].Location, Message: fmt.Sprintf("%d resources named '%s'", len(r), name)})
+		}
+	}
+
+	// whatever is left has no inventory entry
+	for location := range yamls {
+		issue := &a.FsckIssue{Issue: a.FsckOrphanedObject, Location: location, Message: "resource file without inventory entry"}
+		if repair {
+			if err := adoptResource(ctx, location); err != nil {
+				issue.Message = fmt.Sprintf("%s, can not repair: %v", issue.Message, err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	for location, attr := range assets {
+		issue := &a.FsckIssue{Issue: a.FsckOrphanedObject, Location: location, Message: "asset without inventory entry"}
+		if repair {
+			name := path.Base(location)
+			if err := UpdateAssetResource(ctx, name, util.Checksum(location), a.ResourceAsset, guid, location, attr.ContentType, hex.EncodeToString(attr.MD5), attr.Size, 0); err != nil {
+				issue.Message = fmt.Sprintf("%s, can not repair: %v", issue.Message, err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool { return report.Issues[i].Location < report.Issues[j].Location })
+	return &report, nil
+}
+
+// checkLegacyImports finds imported assets that are still stored at their LegacyFingerprintURI
+func checkLegacyImports(ctx context.Context, guid string, repair bool) ([]*a.FsckIssue, error) {
+	legacy := make(map[string]*a.Asset)
+	err := forEachResource(ctx, guid, func(rsrc interface{}) {
+		for _, asset := range resourceAssets(rsrc) {
+			if asset.Rel == a.ResourceTypeImport && asset.LegacyFingerprintURI(guid) != asset.FingerprintURI(guid) {
+				legacy[asset.LegacyFingerprintURI(guid)] = asset
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*a.FsckIssue
+	for location, asset := range legacy {
+		if !resourceExists(ctx, location) || resourceExists(ctx, asset.FingerprintURI(guid)) {
+			continue
+		}
+		issue := &a.FsckIssue{Issue: a.FsckMismatch, Location: location, Message: fmt.Sprintf("import of '%s' is stored at its legacy location", asset.URI)}
+		if repair {
+			if err := moveLegacyImport(ctx, guid, asset); err != nil {
+				issue.Message = fmt.Sprintf("%s, can not repair: %v", issue.Message, err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// moveLegacyImport moves an imported asset from its legacy location and updates its inventory entry
+func moveLegacyImport(ctx context.Context, guid string, asset *a.Asset) error {
+	bkt := platform.Storage().Bucket(a.BucketCDN)
+	legacy := bkt.Object(asset.LegacyFingerprintURI(guid))
+	location := asset.FingerprintURI(guid)
+
+	if _, err := bkt.Object(location).CopierFrom(legacy).Run(ctx); err != nil {
+		return err
+	}
+
+	r, err := GetResource(ctx, util.Checksum(asset.URI))
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		r.Name = path.Base(location)
+		r.Location = location
+		r.Updated = util.Timestamp()
+		if err := updateResource(ctx, r); err != nil {
+			return err
+		}
+	}
+	return legacy.Delete(ctx)
+}
+
+// checkAsset compares an asset's inventory entry with the attributes of its file
+func checkAsset(ctx context.Context, r *a.Resource, attr *storage.ObjectAttrs, repair bool) *a.FsckIssue {
+	var diff []string
+
+	if r.Size != attr.Size {
+		diff = append(diff, fmt.Sprintf("size %d, expected %d", r.Size, attr.Size))
+	}
+	if r.ContentType != attr.ContentType {
+		diff = append(diff, fmt.Sprintf("content type '%s', expected '%s'", r.ContentType, attr.ContentType))
+	}
+	if checksum := hex.EncodeToString(attr.MD5); checksum != "" && r.Checksum != checksum {
+		diff = append(diff, fmt.Sprintf("checksum '%s', expected '%s'", r.Checksum, checksum))
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+
+	issue := &a.FsckIssue{Issue: a.FsckMismatch, GUID: r.GUID, Name: r.Name, Location: r.Location, Message: strings.Join(diff, ", ")}
+	if repair {
+		r.Size = attr.Size
+		r.ContentType = attr.ContentType
+		r.Checksum = hex.EncodeToString(attr.MD5)
+		r.Updated = util.Timestamp()
+		issue.Repaired = updateResource(ctx, r) == nil
+	}
+	return issue
+}
+
+// adoptResource creates the inventory entry of a show or episode from its .yaml
+func adoptResource(ctx context.Context, location string) error {
+	rsrc, kind, _, err := ReadResource(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case a.ResourceShow:
+		return UpdateShow(ctx, location, rsrc.(*a.Show))
+	case a.ResourceEpisode:
+		return UpdateEpisode(ctx, location, rsrc.(*a.Episode))
+	}
+	return fmt.Errorf("unsupported resource '%s'", kind)
+}
+
+// listObjects returns all files in folder 'guid' of a bucket. The history of resources and
+// feeds, and the published feed.xml are not part of the inventory and are skipped.
+func listObjects(ctx context.Context, bucket, guid string) (map[string]*storage.ObjectAttrs, error) {
+	objects := make(map[string]*storage.ObjectAttrs)
+
+	it := platform.Storage().Bucket(bucket).Objects(ctx, &storage.Query{Prefix: guid + "/"})
+	for {
+		attr, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(attr.Name, guid+"/")
+		if strings.HasPrefix(name, historyFolder+"/") || strings.HasPrefix(name, buildsFolder+"/") || name == "feed.xml" {
+			continue
+		}
+		objects[attr.Name] = attr
+	}
+	return objects, nil
+}
+
+// reindexResource updates the inventory and search index entries of a show or episode from its .yaml file
+func reindexResource(ctx context.Context, r *a.Resource) error {
+	rsrc, _, _, err := ReadResource(ctx, r.Location)
+	if err != nil {
+		return err
+	}
+	switch resource := rsrc.(type) {
+	case *a.Show:
+		return UpdateShow(ctx, r.Location, resource)
+	case *a.Episode:
+		return UpdateEpisode(ctx, r.Location, resource)
+	}
+	return fmt.Errorf("'%s' is not a show or episode", r.Location)
+}
+
+// listSearchEntries returns the GUIDs of all search index entries of a production
+func listSearchEntries(ctx context.Context, guid string) (map[string]bool, error) {
+	keys, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreSearch).Filter("ParentGUID =", guid).KeysOnly(), nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]bool)
+	for _, k := range keys {
+		entries[k.Name] = true
+	}
+	return entries, nil
+}
@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"cloud.google.com/go/storage"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"google.golang.org/api/iterator"
+)
+
+// CollectGarbage removes all assets of a production that no show or episode references.
+// Assets changed within the last 'grace' seconds are kept, as their resource might not be
+// created or updated yet. With dryRun == true, the assets are only listed.
+func CollectGarbage(ctx context.Context, guid string, grace int64, dryRun bool) (_ *a.GCReport, err error) {
+	ctx, span := startSpan(ctx, "CollectGarbage", guid)
+	defer func() { endSpan(span, err) }()
+
+	report := a.GCReport{GUID: guid, GracePeriod: grace, DryRun: dryRun}
+
+	referenced, err := referencedAssets(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := ListResources(ctx, guid, a.ResourceAsset)
+	if err != nil {
+		return nil, err
+	}
+	inventory := make(map[string]*a.Resource)
+	for _, r := range resources {
+		inventory[r.Location] = r
+	}
+
+	objects, err := listObjects(ctx, a.BucketCDN, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := util.Timestamp() - grace
+	for location, attr := range objects {
+		if referenced[location] {
+			report.Referenced++
+			continue
+		}
+
+		asset := &a.GCAsset{Name: path.Base(location), Location: location, Size: attr.Size, Updated: attr.Updated.Unix()}
+		r := inventory[location]
+		if r != nil {
+			asset.GUID = r.GUID
+			asset.Name = r.Name
+		}
+		report.Assets = append(report.Assets, asset)
+		if asset.Updated > cutoff {
+			continue
+		}
+		report.Size += attr.Size
+		if dryRun {
+			continue
+		}
+
+		if err := platform.Storage().Bucket(a.BucketCDN).Object(location).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+			return nil, err
+		}
+		if r != nil {
+			if err := deleteResource(ctx, r.GUID, nil); err != nil {
+				return nil, err
+			}
+		}
+		asset.Collected = true
+	}
+
+	sort.Slice(report.Assets, func(i, j int) bool { return report.Assets[i].Location < report.Assets[j].Location })
+	return &report, nil
+}
+
+// referencedAssets returns the CDN locations of all local and imported assets used by the show and its episodes
+func referencedAssets(ctx context.Context, guid string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	err := forEachResource(ctx, guid, func(rsrc interface{}) {
+		addReferences(referenced, guid, rsrc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return referenced, nil
+}
+
+// forEachResource reads the show and all episodes of a production
+func forEachResource(ctx context.Context, guid string, f func(rsrc interface{})) error {
+	it := platform.Storage().Bucket(a.BucketProduction).Objects(ctx, &storage.Query{Prefix: guid + "/", Delimiter: "/"})
+	for {
+		attr, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if attr.Name == "" {
+			continue // a folder, e.g. the history of resources
+		}
+
+		rsrc, _, _, err := ReadResource(ctx, attr.Name)
+		if err != nil {
+			return fmt.Errorf("can not read '%s': %w", attr.Name, err)
+		}
+		f(rsrc)
+	}
+}
+
+// addReferences adds the CDN locations of the local and imported assets of a show or episode. Imports
+// that fsck did not move yet are still at their legacy location, both locations are referenced.
+func addReferences(referenced map[string]bool, guid string, rsrc interface{}) {
+	for _, asset := range resourceAssets(rsrc) {
+		switch asset.Rel {
+		case a.ResourceTypeLocal:
+			referenced[fmt.Sprintf("%s/%s", guid, asset.URI)] = true
+		case a.ResourceTypeImport:
+			referenced[asset.FingerprintURI(guid)] = true
+			referenced[asset.LegacyFingerprintURI(guid)] = true
+		}
+	}
+}
+
+// resourceAssets returns the assets of a show or episode
+func resourceAssets(rsrc interface{}) []*a.Asset {
+	var assets []*a.Asset
+	switch r := rsrc.(type) {
+	case *a.Show:
+		assets = append(assets, &r.Image)
+	case *a.Episode:
+		assets = append(assets, &r.Image, &r.Enclosure)
+		if r.Transcript != nil {
+			assets = append(assets, r.Transcript)
+		}
+	}
+	return assets
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+)
+
+func TestAddReferences(t *testing.T) {
+	guid := "abc"
+	show := &a.Show{Image: a.Asset{URI: "cover.png", Rel: a.ResourceTypeLocal}}
+	episode := &a.Episode{
+		Image:      a.Asset{URI: "https://example.com/e1.png", Rel: a.ResourceTypeExternal},
+		Enclosure:  a.Asset{URI: "e1.mp3", Rel: a.ResourceTypeLocal},
+		Transcript: &a.Asset{URI: "e1.vtt", Rel: a.ResourceTypeLocal},
+	}
+	imported := &a.Episode{
+		Enclosure: a.Asset{URI: "https://example.com/e2.mp3", Rel: a.ResourceTypeImport},
+	}
+
+	referenced := make(map[string]bool)
+	addReferences(referenced, guid, show)
+	addReferences(referenced, guid, episode)
+	addReferences(referenced, guid, imported)
+
+	want := map[string]bool{
+		"abc/cover.png":                         true,
+		"abc/e1.mp3":                            true,
+		"abc/e1.vtt":                            true,
+		imported.Enclosure.FingerprintURI(guid): true,
+	}
+	if !reflect.DeepEqual(referenced, want) {
+		t.Errorf("addReferences() = %v, want %v", referenced, want)
+	}
+}
+
+func TestAddReferencesLegacyImport(t *testing.T) {
+	guid := "abc"
+	episode := &a.Episode{
+		Enclosure: a.Asset{URI: "https://example.com/e3.mp3?source=rss", Rel: a.ResourceTypeImport},
+	}
+
+	referenced := make(map[string]bool)
+	addReferences(referenced, guid, episode)
+
+	legacy := episode.Enclosure.LegacyFingerprintURI(guid)
+	if legacy == episode.Enclosure.FingerprintURI(guid) {
+		t.Fatalf("expected the legacy location to differ, got '%s'", legacy)
+	}
+	if !referenced[legacy] || !referenced[episode.Enclosure.FingerprintURI(guid)] {
+		t.Errorf("expected both locations to be referenced, got %v", referenced)
+	}
+}
@@ -0,0 +1,255 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DatastoreRevisions collection REVISIONS
+	DatastoreRevisions = "REVISIONS"
+
+	// all revisions are kept in e.g. 'guid/_history/episode-guid/1.yaml'
+	historyFolder = "_history"
+)
+
+// RecordRevision keeps a copy of the resource's current content and records who wrote it.
+// Call it after the resource's .yaml and inventory entry have been updated.
+// The revision number is allocated in the same transaction that updates the inventory entry,
+// concurrent writers never record the same revision.
+func RecordRevision(ctx context.Context, guid, clientID string, rsrc interface{}) (*a.Revision, error) {
+	return recordRevision(ctx, guid, clientID, rsrc, 0)
+}
+
+// claimRevision compares the current revision of a resource with 'expected' and, if they match, claims the
+// next revision in the same transaction. Of two writers expecting the same revision, only the first one
+// succeeds, the other one gets ErrRevisionConflict. A resource that does not exist yet has nothing to compare with, 0 is returned.
+func claimRevision(ctx context.Context, guid string, expected int) (int, error) {
+	claimed := 0
+	_, err := platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var r a.Resource
+		if err := tx.Get(resourceKey(guid), &r); err != nil {
+			if err == datastore.ErrNoSuchEntity {
+				return nil
+			}
+			return err
+		}
+		if r.Revision != expected {
+			return fmt.Errorf("'%s' was changed, expected revision %d, found %d: %w", guid, expected, r.Revision, a.ErrRevisionConflict)
+		}
+
+		r.Revision++
+		if _, err := tx.Put(resourceKey(guid), &r); err != nil {
+			return err
+		}
+		claimed = r.Revision
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return claimed, nil
+}
+
+// releaseRevision gives back a revision claimed with claimRevision when the write failed before the revision
+// was recorded. A revision that was recorded, or claimed again in the meantime, is kept.
+func releaseRevision(ctx context.Context, guid string, claimed int) error {
+	_, err := platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var r a.Resource
+		if err := tx.Get(resourceKey(guid), &r); err != nil {
+			if err == datastore.ErrNoSuchEntity {
+				return nil
+			}
+			return err
+		}
+		if r.Revision != claimed {
+			return nil
+		}
+		var rev a.Revision
+		if err := tx.Get(revisionKey(guid, claimed), &rev); err != datastore.ErrNoSuchEntity {
+			return err // recorded, or the lookup failed
+		}
+
+		r.Revision = claimed - 1
+		_, err := tx.Put(resourceKey(guid), &r)
+		return err
+	})
+	return err
+}
+
+// recordRevision records revision 'claimed' that was claimed with claimRevision, or the next revision if claimed == 0
+func recordRevision(ctx context.Context, guid, clientID string, rsrc interface{}, claimed int) (_ *a.Revision, err error) {
+	ctx, span := startSpan(ctx, "RecordRevision", guid)
+	defer func() { endSpan(span, err) }()
+
+	data, err := yaml.Marshal(rsrc)
+	if err != nil {
+		return nil, err
+	}
+
+	var rev a.Revision
+	_, err = platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var r a.Resource
+		if err := tx.Get(resourceKey(guid), &r); err != nil {
+			if err == datastore.ErrNoSuchEntity {
+				return a.ErrNoSuchResource
+			}
+			return err
+		}
+
+		parent := r.ParentGUID
+		if r.Kind == a.ResourceShow {
+			parent = r.GUID
+		}
+		rev = a.Revision{
+			GUID:       r.GUID,
+			ParentGUID: parent,
+			Kind:       r.Kind,
+			Revision:   claimed,
+			ClientID:   clientID,
+			Timestamp:  util.Timestamp(),
+		}
+		if rev.Revision == 0 {
+			rev.Revision = r.Revision + 1
+		}
+		rev.Location = fmt.Sprintf("%s/%s/%s/%d.yaml", parent, historyFolder, strings.TrimSuffix(path.Base(r.Location), ".yaml"), rev.Revision)
+
+		if _, err := tx.Put(revisionKey(r.GUID, rev.Revision), &rev); err != nil {
+			return err
+		}
+
+		if rev.Revision > r.Revision {
+			r.Revision = rev.Revision
+		}
+		r.UpdatedBy = clientID
+		r.Updated = rev.Timestamp
+		_, err := tx.Put(resourceKey(r.GUID), &r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// written after the revision number is allocated, with the content of this write
+	writer := platform.Storage().Bucket(a.BucketProduction).Object(rev.Location).NewWriter(ctx)
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// ListRevisions returns all revisions of a resource, the most recent first
+func ListRevisions(ctx context.Context, guid string) (*a.RevisionList, error) {
+	r, err := GetResource(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, a.ErrNoSuchResource
+	}
+
+	var revisions []*a.Revision
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreRevisions).Filter("GUID =", guid).Order("-Revision"), &revisions); err != nil {
+		return nil, err
+	}
+	return &a.RevisionList{Current: r.Revision, Revisions: revisions}, nil
+}
+
+// RollbackResource restores revision 'revision' of a show or episode. The restored content becomes a new revision.
+func RollbackResource(ctx context.Context, guid string, revision int, clientID string) (_ *a.Revision, err error) {
+	ctx, span := startSpan(ctx, "RollbackResource", guid)
+	defer func() { endSpan(span, err) }()
+
+	r, err := GetResource(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, a.ErrNoSuchResource
+	}
+	if revision == r.Revision {
+		return nil, fmt.Errorf("revision %d is the current revision", revision)
+	}
+
+	var rev a.Revision
+	if err := platform.DataStore().Get(ctx, revisionKey(guid, revision), &rev); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, fmt.Errorf("can not find revision %d", revision)
+		}
+		return nil, err
+	}
+
+	reader, err := platform.Storage().Bucket(a.BucketProduction).Object(rev.Location).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	rsrc, kind, _, err := a.LoadResource(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same steps as an update of the resource
+	switch kind {
+	case a.ResourceShow:
+		show := rsrc.(*a.Show)
+		p, err := GetProduction(ctx, show.GUID())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, a.ErrNoSuchProduction
+		}
+		p.Title = show.Description.Title
+		p.Summary = show.Description.Summary
+		p.Updated = util.Timestamp()
+		if err := UpdateProduction(ctx, p); err != nil {
+			return nil, err
+		}
+		if err := EnsureAsset(ctx, show.GUID(), &show.Image); err != nil {
+			return nil, err
+		}
+		if err := UpdateShow(ctx, r.Location, show); err != nil {
+			return nil, err
+		}
+	case a.ResourceEpisode:
+		episode := rsrc.(*a.Episode)
+		if err := EnsureAsset(ctx, episode.ParentGUID(), &episode.Image); err != nil {
+			return nil, err
+		}
+		if err := EnsureAsset(ctx, episode.ParentGUID(), &episode.Enclosure); err != nil {
+			return nil, err
+		}
+		if err := UpdateEpisode(ctx, r.Location, episode); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported resource '%s'", kind)
+	}
+
+	if err := WriteResourceContent(ctx, r.Location, false, true, rsrc); err != nil {
+		return nil, err
+	}
+	return RecordRevision(ctx, guid, clientID, rsrc)
+}
+
+func revisionKey(guid string, revision int) *datastore.Key {
+	return datastore.NameKey(DatastoreRevisions, fmt.Sprintf("%s.%d", guid, revision), nil)
+}
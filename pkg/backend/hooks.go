@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// ChangeFunc is called after a show or episode was written or removed. kind is
+	// a.ResourceShow for changes to a production or its show, a.ResourceEpisode otherwise.
+	ChangeFunc func(ctx context.Context, kind, guid string)
+)
+
+var (
+	changeHooks []ChangeFunc
+	hooksMu     sync.RWMutex
+)
+
+// OnChange registers a function that is called after every change, e.g. to invalidate a cache
+func OnChange(f ChangeFunc) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	changeHooks = append(changeHooks, f)
+}
+
+// notifyChange calls all registered functions
+func notifyChange(ctx context.Context, kind, guid string) {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+
+	for _, f := range changeHooks {
+		f(ctx, kind, guid)
+	}
+}
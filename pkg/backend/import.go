@@ -53,40 +53,76 @@ func ImportTaskEndpoint(c echo.Context) error {
 }
 
 func importResource(ctx context.Context, src, dest string) int {
+	progress := &a.Progress{Kind: a.ProgressImport, GUID: strings.Split(dest, "/")[0], Resource: src, Stage: a.StageStarted}
+	publishProgress(ctx, progress, true)
+
+	ctx, span := startSpan(ctx, "importResource", progress.GUID)
+	status, err := transferResource(ctx, src, dest, progress)
+	endSpan(span, err)
+	platform.ObserveImport(progress.Current, err)
+
+	if err != nil {
+		platform.ReportError(err)
+		progress.Stage = a.StageFailed
+		progress.Error = err.Error()
+	} else {
+		progress.Stage = a.StageDone
+		fireEvent(ctx, &a.WebhookEvent{Event: a.EventAssetImported, GUID: progress.GUID, Name: dest, URL: src})
+	}
+	publishProgress(ctx, progress, true)
+
+	return status
+}
+
+// transferResource copies src to dest in the CDN bucket and adds it to the inventory. The status tells
+// the task queue whether to retry, errors are returned even if retrying will not change anything.
+func transferResource(ctx context.Context, src, dest string, progress *a.Progress) (int, error) {
 	resp, err := http.Get(src)
 	if err != nil {
-		return resp.StatusCode
+		return http.StatusBadRequest, fmt.Errorf("can not retrieve '%s': %v", src, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		platform.ReportError(fmt.Errorf("can not retrieve '%s': %s", src, resp.Status))
-		return http.StatusBadRequest
+		return http.StatusBadRequest, fmt.Errorf("can not retrieve '%s': %s", src, resp.Status)
 	}
 
 	meta := extractMetadataFromResponse(resp)
+
+	// the owner's quota has to cover the new file
+	parent := progress.GUID
+
+	p, err := GetProduction(ctx, parent)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if p == nil {
+		return http.StatusOK, fmt.Errorf("can not import '%s': unknown production '%s'", src, parent) // retrying will not change anything
+	}
+	if err := CheckQuota(ctx, p.Owner, meta.Size, 0, 0); err != nil {
+		return http.StatusOK, fmt.Errorf("can not import '%s': %w", src, err) // retrying will not change anything
+	}
+
 	obj := ds.Storage().Bucket(a.BucketCDN).Object(dest)
 	writer := obj.NewWriter(ctx)
 	writer.ContentType = meta.ContentType
 	defer writer.Close()
 
 	// transfer using a buffer
+	progress.Stage = a.StageTransfer
+	progress.Total = meta.Size
 	buffer := make([]byte, 65536)
-	l, err := io.CopyBuffer(writer, resp.Body, buffer)
+	l, err := io.CopyBuffer(&progressWriter{ctx: ctx, w: writer, progress: progress}, resp.Body, buffer)
 
 	// error handling & verification
 	if err != nil {
-		platform.ReportError(fmt.Errorf("can not transfer '%s': %v", dest, err))
-		return http.StatusBadRequest
+		return http.StatusBadRequest, fmt.Errorf("can not transfer '%s': %v", dest, err)
 	}
 	if l != meta.Size {
-		platform.ReportError(fmt.Errorf("error transfering '%s': expected %d, reveived %d", src, meta.Size, l))
-		return http.StatusBadRequest
+		return http.StatusBadRequest, fmt.Errorf("error transfering '%s': expected %d, reveived %d", src, meta.Size, l)
 	}
 
 	// update the inventory
-	parent := strings.Split(dest, "/")[0]
-
 	temp := a.Asset{
 		URI: src,
 		Rel: a.ResourceTypeImport,
@@ -95,12 +131,11 @@ func importResource(ctx context.Context, src, dest string) int {
 
 	duration := int64(0) // FIXME implement it
 
-	if err := UpdateAssetResource(ctx, name, util.Checksum(src), a.ResourceAsset, parent, dest, meta.ContentType, meta.Size, duration); err != nil {
-		platform.ReportError(fmt.Errorf("error updating inventory: %v", err))
-		return http.StatusBadRequest
+	if err := UpdateAssetResource(ctx, name, util.Checksum(src), a.ResourceAsset, parent, dest, meta.ContentType, "", meta.Size, duration); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("error updating inventory: %v", err)
 	}
 
-	return http.StatusOK
+	return http.StatusOK, nil
 }
 
 // extractMetadataFromResponse extracts the metadata from http.Response
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fupas/commons/pkg/env"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"google.golang.org/appengine"
+)
+
+const (
+	// NotifyTask route to NotifyTaskEndpoint
+	NotifyTask = "/notify"
+	// full canonical route
+	notifyTaskWithPrefix = "/_t/notify"
+
+	// pingTimeout limits the time an endpoint has to respond to a ping
+	pingTimeout = 10 * time.Second
+)
+
+type (
+	// Notifier is told whenever the feed of a production changed, e.g. to ping podcast directories
+	Notifier interface {
+		Notify(ctx context.Context, p *a.Production) error
+	}
+
+	// NotifierFunc adapts a function to the Notifier interface
+	NotifierFunc func(ctx context.Context, p *a.Production) error
+
+	// Pinger notifies an endpoint podping-style: GET Endpoint?url=FEED&reason=update&medium=podcast,
+	// once for the feed's canonical location and once for its alias.
+	Pinger struct {
+		Endpoint string
+		Token    string // sent as 'Authorization' header, if not empty
+		Client   *http.Client
+	}
+
+	// notifyTask is the payload of a NotifyTask
+	notifyTask struct {
+		GUID string `json:"guid"`
+	}
+)
+
+var (
+	notifiers   []Notifier
+	notifiersMu sync.RWMutex
+)
+
+func init() {
+	// the built-in WebSub hub
+	RegisterNotifier(NotifierFunc(notifySubscribers))
+
+	// e.g. PODPING_ENDPOINTS=https://podping.cloud/
+	for _, endpoint := range strings.Split(env.GetString("PODPING_ENDPOINTS", ""), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			RegisterNotifier(&Pinger{Endpoint: endpoint, Token: env.GetString("PODPING_TOKEN", "")})
+		}
+	}
+}
+
+// RegisterNotifier adds a notifier that is told about every change of a feed
+func RegisterNotifier(n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+
+	notifiers = append(notifiers, n)
+}
+
+// Notify calls f(ctx, p)
+func (f NotifierFunc) Notify(ctx context.Context, p *a.Production) error {
+	return f(ctx, p)
+}
+
+// Notify pings the endpoint about the feed of production p
+func (pg *Pinger) Notify(ctx context.Context, p *a.Production) error {
+	client := pg.Client
+	if client == nil {
+		client = &http.Client{Timeout: pingTimeout}
+	}
+
+	for _, feed := range []string{FeedURL(p.GUID), FeedAliasURL(p.Name)} {
+		q := url.Values{}
+		q.Set("url", feed)
+		q.Set("reason", "update")
+		q.Set("medium", "podcast")
+
+		req, err := http.NewRequestWithContext(ctx, "GET", appendQuery(pg.Endpoint, q), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", a.UserAgentString)
+		if pg.Token != "" {
+			req.Header.Set("Authorization", pg.Token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("can not ping '%s': %v", pg.Endpoint, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("can not ping '%s': %s", pg.Endpoint, resp.Status)
+		}
+	}
+	return nil
+}
+
+// FeedURL returns the canonical location of a production's feed
+func FeedURL(guid string) string {
+	return fmt.Sprintf("%s/c/%s/feed.xml", a.DefaultCDNEndpoint, guid)
+}
+
+// FeedAliasURL returns the location of a production's feed that uses its name
+func FeedAliasURL(name string) string {
+	return fmt.Sprintf("%s/s/%s/feed.xml", a.DefaultPortalEndpoint, name)
+}
+
+// NotifyTaskEndpoint tells all notifiers that the feed of a production changed
+func NotifyTaskEndpoint(c echo.Context) error {
+	var req notifyTask
+	if err := c.Bind(&req); err != nil {
+		// just report and return, resending will not change anything
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	p, err := GetProduction(ctx, req.GUID)
+	if err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+	if p == nil {
+		return c.NoContent(http.StatusOK) // deleted in the meantime
+	}
+
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+
+	// failures are only reported, retrying all notifiers would notify some of them twice
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, p); err != nil {
+			platform.ReportError(fmt.Errorf("can not notify about '%s': %v", p.GUID, err))
+		}
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// feedChanged schedules the notification of all notifiers. Errors are only reported, notifications never fail a build.
+func feedChanged(ctx context.Context, guid string) {
+	if _, err := platform.CreateTask(ctx, notifyTaskWithPrefix, &notifyTask{GUID: guid}); err != nil {
+		platform.ReportError(fmt.Errorf("can not notify about '%s': %v", guid, err))
+	}
+}
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+)
+
+func TestPinger(t *testing.T) {
+	var pinged []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("reason") != "update" || r.URL.Query().Get("medium") != "podcast" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		pinged = append(pinged, r.URL.Query().Get("url"))
+	}))
+	defer srv.Close()
+
+	p := &a.Production{GUID: "abc123", Name: "show"}
+	if err := (&Pinger{Endpoint: srv.URL, Token: "token"}).Notify(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	if len(pinged) != 2 || pinged[0] != FeedURL(p.GUID) || pinged[1] != FeedAliasURL(p.Name) {
+		t.Errorf("unexpected pings: %v", pinged)
+	}
+
+	if err := (&Pinger{Endpoint: srv.URL}).Notify(context.Background(), p); err == nil {
+		t.Error("expected an error for a rejected ping")
+	}
+}
+
+func TestAppendQuery(t *testing.T) {
+	q := url.Values{}
+	q.Set("hub.mode", "subscribe")
+
+	if got := appendQuery("https://example.com/cb", q); got != "https://example.com/cb?hub.mode=subscribe" {
+		t.Errorf("unexpected url '%s'", got)
+	}
+	if got := appendQuery("https://example.com/cb?id=1", q); got != "https://example.com/cb?id=1&hub.mode=subscribe" {
+		t.Errorf("unexpected url '%s'", got)
+	}
+}
+
+func TestSubscriptionID(t *testing.T) {
+	id := subscriptionID("https://example.com/feed.xml", "https://example.com/cb")
+	if id != subscriptionID("https://example.com/feed.xml", "https://example.com/cb") {
+		t.Error("expected the same ID for the same topic and callback")
+	}
+	if id == subscriptionID("https://example.com/feed.xml", "https://example.com/other") {
+		t.Error("expected different IDs for different callbacks")
+	}
+}
@@ -3,12 +3,16 @@ package backend
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
 	"github.com/fupas/commons/pkg/util"
 	"github.com/fupas/platform/pkg/platform"
 	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/pkg/auth"
+	"google.golang.org/api/iterator"
 )
 
 const (
@@ -82,11 +86,109 @@ func GetProduction(ctx context.Context, guid string) (*a.Production, error) {
 	return &p, nil
 }
 
+// GetProductions returns the productions of all GUIDs, nil for those that do not exist
+func GetProductions(ctx context.Context, guids []string) ([]*a.Production, error) {
+	keys := make([]*datastore.Key, len(guids))
+	for i, guid := range guids {
+		keys[i] = productionKey(guid)
+	}
+	productions := make([]*a.Production, len(guids))
+	if err := getMulti(ctx, keys, productions); err != nil {
+		return nil, err
+	}
+	return productions, nil
+}
+
 // UpdateProduction does what the name suggests
 func UpdateProduction(ctx context.Context, p *a.Production) error {
 	if _, err := platform.DataStore().Put(ctx, productionKey(p.GUID), p); err != nil {
 		return err
 	}
+	notifyChange(ctx, a.ResourceShow, p.GUID)
+	return nil
+}
+
+// DeleteProduction removes a production and everything that belongs to it: all files in the
+// production and CDN buckets, the inventory, revisions, audit, builds, usage, search index,
+// progress entries, webhook deliveries, webhooks, WebSub subscriptions and the authorizations
+// issued for it.
+func DeleteProduction(ctx context.Context, guid string) error {
+	// the files first, a failed attempt can be repeated as long as the PRODUCTION entry exists
+	if err := removeObjects(ctx, a.BucketCDN, guid); err != nil {
+		return err
+	}
+	if err := removeObjects(ctx, a.BucketProduction, guid); err != nil {
+		return err
+	}
+
+	queries := []*datastore.Query{
+		datastore.NewQuery(DatastoreResources).Filter("ParentGUID =", guid),
+		datastore.NewQuery(DatastoreRevisions).Filter("ParentGUID =", guid),
+		datastore.NewQuery(DatastoreAudit).Filter("ParentGUID =", guid),
+		datastore.NewQuery(DatastoreBuilds).Filter("GUID =", guid),
+		datastore.NewQuery(DatastoreUsage).Filter("GUID =", guid),
+		datastore.NewQuery(DatastoreSearch).Filter("ParentGUID =", guid),
+		datastore.NewQuery(DatastoreProgress).Filter("GUID =", guid),
+		datastore.NewQuery(DatastoreDeliveries).Filter("GUID =", guid),
+		datastore.NewQuery(DatastoreWebhooks).Filter("GUID =", guid),
+		datastore.NewQuery(DatastoreSubscriptions).Filter("GUID =", guid),
+	}
+	for _, q := range queries {
+		if err := deleteAll(ctx, q); err != nil {
+			return err
+		}
+	}
+	if err := auth.DeleteAuthorizations(ctx, guid); err != nil {
+		return err
+	}
+
+	// the show's inventory entry has no parent
+	if err := platform.DataStore().Delete(ctx, resourceKey(guid)); err != nil {
+		return err
+	}
+	if err := platform.DataStore().Delete(ctx, productionKey(guid)); err != nil {
+		return err
+	}
+	notifyChange(ctx, a.ResourceShow, guid)
+	return nil
+}
+
+// removeObjects deletes all objects in folder 'guid' of a bucket
+func removeObjects(ctx context.Context, bucket, guid string) error {
+	bkt := platform.Storage().Bucket(bucket)
+
+	it := bkt.Objects(ctx, &storage.Query{Prefix: guid + "/"})
+	for {
+		attr, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := bkt.Object(attr.Name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteAll deletes all entities matching a query, in batches the datastore accepts
+func deleteAll(ctx context.Context, q *datastore.Query) error {
+	keys, err := platform.DataStore().GetAll(ctx, q.KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > 500 {
+			n = 500
+		}
+		if err := platform.DataStore().DeleteMulti(ctx, keys[:n]); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
 	return nil
 }
 
@@ -114,6 +216,113 @@ func FindProductionsByOwner(ctx context.Context, owner string) ([]*a.Production,
 	return p, nil
 }
 
+// FindProductionByFeedURL returns the production that was registered with feedURL as its previous location
+func FindProductionByFeedURL(ctx context.Context, feedURL string) (*a.Production, error) {
+	alias, err := feedAlias(feedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var p []*a.Production
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreProductions).Filter("FeedAlias =", alias), &p); err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return p[0], nil
+}
+
+// MigrateProduction moves a production to another host or registers the feed's previous location.
+// Moving a production, or cancelling a move, rebuilds the feed. If the build fails, the migration
+// is undone and the feed stays where it was.
+func MigrateProduction(ctx context.Context, p *a.Production, m *a.FeedMigration) error {
+	previous := *p
+	rebuild := false
+
+	if m.Cancel {
+		p.State = ""
+		p.NewFeedURL = ""
+		rebuild = true
+	} else if m.NewFeedURL != "" {
+		if _, err := feedAlias(m.NewFeedURL); err != nil {
+			return err
+		}
+		p.State = a.ProductionStateMoved
+		p.NewFeedURL = m.NewFeedURL
+		rebuild = true
+	}
+	if m.OldFeedURL != "" {
+		if err := setOldFeedURL(ctx, p, m.OldFeedURL); err != nil {
+			return err
+		}
+	}
+
+	p.Updated = util.Timestamp()
+	if err := UpdateProduction(ctx, p); err != nil {
+		return err
+	}
+
+	if rebuild {
+		if err := Build(ctx, p.GUID, false); err != nil {
+			if rerr := undoMigration(ctx, p, &previous); rerr != nil {
+				return fmt.Errorf("can not rebuild feed: %v, can not undo the migration: %w", err, rerr)
+			}
+			return fmt.Errorf("can not rebuild feed: %w", err)
+		}
+	}
+	return nil
+}
+
+// undoMigration restores the locations of a production, keeping everything a failed build recorded
+func undoMigration(ctx context.Context, p, previous *a.Production) error {
+	current, err := GetProduction(ctx, p.GUID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return a.ErrNoSuchProduction
+	}
+	current.State = previous.State
+	current.NewFeedURL = previous.NewFeedURL
+	current.OldFeedURL = previous.OldFeedURL
+	current.FeedAlias = previous.FeedAlias
+	current.Updated = previous.Updated
+	if err := UpdateProduction(ctx, current); err != nil {
+		return err
+	}
+	*p = *current
+	return nil
+}
+
+// setOldFeedURL registers the feed's previous location, unless another production already claimed it
+func setOldFeedURL(ctx context.Context, p *a.Production, feedURL string) error {
+	other, err := FindProductionByFeedURL(ctx, feedURL)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.GUID != p.GUID {
+		return fmt.Errorf("'%s' is already registered", feedURL)
+	}
+
+	alias, _ := feedAlias(feedURL) // already verified above
+	p.OldFeedURL = feedURL
+	p.FeedAlias = alias
+	return nil
+}
+
+// feedAlias normalizes a feed URL by removing its scheme and query, e.g. 'https://Example.com/feed/' becomes 'example.com/feed'
+func feedAlias(feedURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(feedURL))
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid feed url '%s'", feedURL)
+	}
+	return strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/"), nil
+}
+
 func productionKey(guid string) *datastore.Key {
 	return datastore.NameKey(DatastoreProductions, guid, nil)
 }
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/events"
+	p "github.com/podops/podops/internal/platform"
+)
+
+const (
+	// DatastoreProgress collection PROGRESS
+	DatastoreProgress = "PROGRESS"
+
+	// progressSteps is the number of transfer updates of an import with a known size
+	progressSteps = 20
+	// progressBytes is the transfer update interval of an import with an unknown size
+	progressBytes = 1024 * 1024
+	// importProgressRetention limits the imports that are listed
+	importProgressRetention = 24 * time.Hour
+)
+
+type (
+	// progressWriter publishes the progress of a transfer
+	progressWriter struct {
+		ctx      context.Context
+		w        io.Writer
+		progress *a.Progress
+		next     int64
+	}
+)
+
+// ProgressTopic is the event topic of all builds or imports of a production
+func ProgressTopic(kind, guid string) string {
+	return fmt.Sprintf("%s/%s", kind, guid)
+}
+
+// GetBuildProgress returns the progress of the last build of a production, nil if there was none
+func GetBuildProgress(ctx context.Context, guid string) (*a.Progress, error) {
+	var progress a.Progress
+
+	if err := platform.DataStore().Get(ctx, progressKey(ProgressTopic(a.ProgressBuild, guid)), &progress); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, nil // not found is not an error
+		}
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// GetImportProgress returns the progress of all recent imports of a production, the latest first
+func GetImportProgress(ctx context.Context, guid string) ([]*a.Progress, error) {
+	var progress []*a.Progress
+
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreProgress).Filter("Kind =", a.ProgressImport).Filter("GUID =", guid), &progress); err != nil {
+		return nil, err
+	}
+
+	since := util.IncT(util.Timestamp(), -int(importProgressRetention.Minutes()))
+	recent := make([]*a.Progress, 0, len(progress))
+	for _, pr := range progress {
+		if pr.Timestamp >= since {
+			recent = append(recent, pr)
+		}
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i].Timestamp > recent[j].Timestamp })
+
+	return recent, nil
+}
+
+// publishProgress sends a copy of the progress to all subscribers. With persist == true, it is
+// also kept for clients that poll or are connected to another instance. Errors are only reported.
+func publishProgress(ctx context.Context, progress *a.Progress, persist bool) {
+	progress.Timestamp = util.Timestamp()
+
+	event := *progress
+	events.Publish(ProgressTopic(event.Kind, event.GUID), &event)
+
+	if persist {
+		key := ProgressTopic(event.Kind, event.GUID)
+		if event.Kind == a.ProgressImport {
+			key = fmt.Sprintf("%s/%s", key, util.Checksum(event.Resource))
+		}
+		if _, err := platform.DataStore().Put(ctx, progressKey(key), &event); err != nil {
+			p.ReportError(fmt.Errorf("can not record progress of '%s': %v", key, err))
+		}
+	}
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.progress.Current += int64(n)
+
+	if pw.progress.Current >= pw.next {
+		publishProgress(pw.ctx, pw.progress, false)
+
+		step := int64(progressBytes)
+		if pw.progress.Total > 0 {
+			step = pw.progress.Total/progressSteps + 1
+		}
+		pw.next = pw.progress.Current + step
+	}
+	return n, err
+}
+
+func progressKey(topic string) *datastore.Key {
+	return datastore.NameKey(DatastoreProgress, topic, nil)
+}
@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"context"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// OrderPublishedDesc sorts episodes by publish date, the latest first
+	OrderPublishedDesc = "-Published"
+	// OrderPublishedAsc sorts episodes by publish date, the oldest first
+	OrderPublishedAsc = "Published"
+	// OrderEpisodeDesc sorts episodes by episode number, the highest first
+	OrderEpisodeDesc = "-Index"
+	// OrderEpisodeAsc sorts episodes by episode number, the lowest first
+	OrderEpisodeAsc = "Index"
+
+	// OrderRecent sorts productions by build date, the most recently built first
+	OrderRecent = "-BuildDate"
+	// OrderName sorts productions by name
+	OrderName = "Name"
+)
+
+type (
+	// EpisodeFilter selects the published episodes of a production. Zero values match all episodes.
+	EpisodeFilter struct {
+		Season          int
+		EpisodeType     string // Full, Trailer or Bonus
+		Explicit        *bool
+		PublishedAfter  int64
+		PublishedBefore int64
+	}
+)
+
+// QueryEpisodes returns up to 'limit' published episodes of a production that match the filter, starting after 'cursor'.
+// It also returns the cursor of each episode and whether more episodes follow.
+func QueryEpisodes(ctx context.Context, parent string, filter *EpisodeFilter, order, cursor string, limit int) ([]*a.Resource, []string, bool, error) {
+	if filter == nil {
+		filter = &EpisodeFilter{}
+	}
+	now := util.Timestamp()
+
+	// future episodes are never listed
+	before := filter.PublishedBefore
+	if before == 0 || before > now {
+		before = now
+	}
+
+	// the equality filters are merged with the indexes of each property, see cmd/index.yaml
+	q := datastore.NewQuery(DatastoreResources).Filter("Kind =", a.ResourceEpisode).Filter("ParentGUID =", parent)
+	if filter.Season > 0 {
+		q = q.Filter("Season =", filter.Season)
+	}
+	if filter.EpisodeType != "" {
+		q = q.Filter("EpisodeType =", episodeType(filter.EpisodeType))
+	}
+	if filter.Explicit != nil {
+		q = q.Filter("Explicit =", *filter.Explicit)
+	}
+	if order == OrderPublishedAsc || order == OrderPublishedDesc {
+		// the date range can be part of the query only if the results are sorted by date
+		q = q.Filter("Published <=", before)
+		if filter.PublishedAfter > 0 {
+			q = q.Filter("Published >=", filter.PublishedAfter)
+		}
+	}
+	q = q.Order(order)
+
+	matches := func(r *a.Resource) bool {
+		if r.StatusAt(now) != a.EpisodeStatusPublished {
+			return false
+		}
+		return r.Published <= before && r.Published >= filter.PublishedAfter
+	}
+
+	results, cursors, more, err := runQuery(ctx, q, cursor, limit, func() interface{} { return &a.Resource{} }, func(dst interface{}) bool {
+		return matches(dst.(*a.Resource))
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	episodes := make([]*a.Resource, len(results))
+	for i := range results {
+		episodes[i] = results[i].(*a.Resource)
+	}
+	return episodes, cursors, more, nil
+}
+
+// QueryProductions returns up to 'limit' productions with a published feed, starting after 'cursor'.
+// It also returns the cursor of each production and whether more productions follow.
+func QueryProductions(ctx context.Context, order, cursor string, limit int) ([]*a.Production, []string, bool, error) {
+	q := datastore.NewQuery(DatastoreProductions)
+	if order == OrderRecent {
+		q = q.Filter("BuildDate >", 0)
+	}
+	q = q.Order(order)
+
+	results, cursors, more, err := runQuery(ctx, q, cursor, limit, func() interface{} { return &a.Production{} }, func(dst interface{}) bool {
+		return dst.(*a.Production).BuildDate > 0 // only shows with a feed
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	productions := make([]*a.Production, len(results))
+	for i := range results {
+		productions[i] = results[i].(*a.Production)
+	}
+	return productions, cursors, more, nil
+}
+
+// episodeType returns the spelling of an episode type that is stored in the inventory, e.g. 'Full' for 'full'
+func episodeType(t string) string {
+	for _, known := range []string{a.EpisodeTypeFull, a.EpisodeTypeTrailer, a.EpisodeTypeBonus} {
+		if strings.EqualFold(t, known) {
+			return known
+		}
+	}
+	return t
+}
+
+// runQuery returns up to 'limit' results of 'q' that 'match' accepts, starting after 'cursor'.
+// It also returns the cursor of each result and whether more results follow.
+func runQuery(ctx context.Context, q *datastore.Query, cursor string, limit int, newEntity func() interface{}, match func(interface{}) bool) ([]interface{}, []string, bool, error) {
+	if cursor != "" {
+		c, err := datastore.DecodeCursor(cursor)
+		if err != nil {
+			return nil, nil, false, a.ErrInvalidCursor
+		}
+		q = q.Start(c)
+	}
+
+	var results []interface{}
+	var cursors []string
+
+	it := platform.DataStore().Run(ctx, q)
+	for {
+		dst := newEntity()
+		if _, err := it.Next(dst); err != nil {
+			if err == iterator.Done {
+				return results, cursors, false, nil
+			}
+			return nil, nil, false, err
+		}
+		if !match(dst) {
+			continue
+		}
+		if len(results) == limit {
+			return results, cursors, true, nil // the first result of the next page
+		}
+
+		c, err := it.Cursor()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		results = append(results, dst)
+		cursors = append(cursors, c.String())
+	}
+}
@@ -81,7 +81,7 @@ func UpdateResource(ctx context.Context, name, guid, kind, parent, location stri
 }
 
 // UpdateAssetResource updates the resource inventory
-func UpdateAssetResource(ctx context.Context, name, guid, kind, parent, location, contentType string, size, duration int64) error {
+func UpdateAssetResource(ctx context.Context, name, guid, kind, parent, location, contentType, checksum string, size, duration int64) error {
 	r, _ := GetResource(ctx, guid)
 
 	if r != nil {
@@ -93,6 +93,7 @@ func UpdateAssetResource(ctx context.Context, name, guid, kind, parent, location
 		r.ParentGUID = parent
 		r.Location = location
 		r.ContentType = contentType
+		r.Checksum = checksum
 		r.Size = size
 		r.Duration = duration
 		r.Updated = util.Timestamp()
@@ -109,6 +110,7 @@ func UpdateAssetResource(ctx context.Context, name, guid, kind, parent, location
 		ParentGUID:  parent,
 		Location:    location,
 		ContentType: contentType,
+		Checksum:    checksum,
 		Size:        size,
 		Duration:    duration,
 		Created:     now,
@@ -117,8 +119,22 @@ func UpdateAssetResource(ctx context.Context, name, guid, kind, parent, location
 	return updateResource(ctx, &rsrc)
 }
 
-// DeleteResource deletes a resource and it's backing .yaml file
-func DeleteResource(ctx context.Context, guid string) error {
+// GetResources retrieves the resources of all GUIDs, nil for those that do not exist
+func GetResources(ctx context.Context, guids []string) ([]*a.Resource, error) {
+	keys := make([]*datastore.Key, len(guids))
+	for i, guid := range guids {
+		keys[i] = resourceKey(guid)
+	}
+	resources := make([]*a.Resource, len(guids))
+	if err := getMulti(ctx, keys, resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// DeleteResource deletes a resource and it's backing .yaml file.
+// If 'expected' is not nil, the resource is only deleted if it is still at that revision, otherwise ErrRevisionConflict is returned.
+func DeleteResource(ctx context.Context, guid string, expected *int) error {
 	r, err := GetResource(ctx, guid)
 	if err != nil {
 		return err
@@ -129,16 +145,30 @@ func DeleteResource(ctx context.Context, guid string) error {
 
 	// FIXME verify ACL etc
 
-	if err := platform.DataStore().Delete(ctx, resourceKey(r.GUID)); err != nil {
+	if err := deleteResource(ctx, r.GUID, expected); err != nil {
 		return err
 	}
-
-	// FIXME put r back if this fails?
+	if err := removeFromIndex(ctx, r.GUID); err != nil {
+		return err
+	}
+	notifyChange(ctx, r.Kind, r.GUID)
 
 	if r.Kind == a.ResourceAsset {
-		return RemoveAsset(ctx, r.Location)
+		err = RemoveAsset(ctx, r.Location)
+	} else {
+		err = RemoveResource(ctx, r.Location)
+	}
+	if err == a.ErrNoSuchAsset || err == a.ErrNoSuchResource {
+		return nil // a dangling inventory entry, nothing else to remove
 	}
-	return RemoveResource(ctx, r.Location)
+	if err != nil {
+		// put the inventory entry back, the file still exists
+		if err := updateResource(ctx, r); err != nil {
+			return fmt.Errorf("inconsistent inventory: can not restore '%s': %w", r.GUID, err)
+		}
+		return err
+	}
+	return nil
 }
 
 // ListResources returns all resources of type kind belonging to parentID
@@ -170,6 +200,19 @@ func ListResources(ctx context.Context, parent, kind string) ([]*a.Resource, err
 	return r, nil
 }
 
+// FilterResources returns all episodes with lifecycle status 'status'. Other kinds of resources are dropped.
+func FilterResources(r []*a.Resource, status string) []*a.Resource {
+	var filtered []*a.Resource
+	now := util.Timestamp()
+
+	for _, rsrc := range r {
+		if rsrc.Kind == a.ResourceEpisode && rsrc.StatusAt(now) == status {
+			filtered = append(filtered, rsrc)
+		}
+	}
+	return filtered
+}
+
 // GetResourceContent retrieves a resource file
 func GetResourceContent(ctx context.Context, guid string) (interface{}, error) {
 	r, err := GetResource(ctx, guid)
@@ -293,7 +336,12 @@ func UpdateShow(ctx context.Context, location string, show *a.Show) error {
 		r.Image = show.Image.ResolveURI(a.StorageEndpoint, show.GUID())
 		r.Updated = util.Timestamp()
 
-		return updateResource(ctx, r)
+		if err := updateResource(ctx, r); err != nil {
+			return err
+		}
+		indexShow(ctx, show)
+		notifyChange(ctx, a.ResourceShow, show.GUID())
+		return nil
 	}
 
 	// create a new inventory entry
@@ -311,7 +359,12 @@ func UpdateShow(ctx context.Context, location string, show *a.Show) error {
 		Created: now,
 		Updated: now,
 	}
-	return updateResource(ctx, &rsrc)
+	if err := updateResource(ctx, &rsrc); err != nil {
+		return err
+	}
+	indexShow(ctx, show)
+	notifyChange(ctx, a.ResourceShow, show.GUID())
+	return nil
 }
 
 // UpdateEpisode is a helper function to update a episode resource
@@ -332,6 +385,16 @@ func UpdateEpisode(ctx context.Context, location string, episode *a.Episode) err
 		}
 	}
 
+	if episode.PublishStatus() == a.EpisodeStatusPublished {
+		if err := scheduleBuild(ctx, episode.ParentGUID(), episode.PublishDateTimestamp()); err != nil {
+			return err
+		}
+	}
+
+	// scheduled episodes are announced by the build that adds them to the feed
+	now := util.Timestamp()
+	published := episode.StatusAt(now) == a.EpisodeStatusPublished && (r == nil || r.StatusAt(now) != a.EpisodeStatusPublished)
+
 	if r != nil {
 		// resource already exists, just update the inventory
 		if r.Kind != episode.Kind {
@@ -345,46 +408,121 @@ func UpdateEpisode(ctx context.Context, location string, episode *a.Episode) err
 		r.Title = episode.Description.Title
 		r.Summary = episode.Description.Summary
 		r.Published = episode.PublishDateTimestamp()
+		r.Status = episode.PublishStatus()
 		r.Index = int(index) // episode number
+		r.Season = episode.Season()
+		r.EpisodeType = episodeType(episode.Metadata.Labels[a.LabelType])
+		r.Explicit = episode.Explicit()
 		r.Image = episode.Image.ResolveURI(a.StorageEndpoint, episode.ParentGUID())
 		r.Extra1 = episode.Enclosure.ResolveURI(a.DefaultCDNEndpoint+"/c", episode.ParentGUID())
 		r.Size = int64(episode.Enclosure.Size)
 		r.Duration = int64(episode.Description.Duration)
 		r.Updated = util.Timestamp()
 
-		return updateResource(ctx, r)
+		if err := updateResource(ctx, r); err != nil {
+			return err
+		}
+		indexEpisode(ctx, episode)
+		notifyChange(ctx, a.ResourceEpisode, episode.GUID())
+		if published {
+			fireEvent(ctx, episodeEvent(a.EventEpisodePublished, episode))
+		}
+		return nil
 	}
 
 	// create a new inventory entry
-	now := util.Timestamp()
 	index, _ := strconv.ParseInt(episode.Metadata.Labels[a.LabelEpisode], 10, 64)
 
 	rsrc := a.Resource{
-		Name:       episode.Metadata.Name,
-		GUID:       episode.GUID(),
-		Kind:       a.ResourceEpisode,
-		ParentGUID: episode.Metadata.Labels[a.LabelParentGUID],
-		Location:   location,
-		Title:      episode.Description.Title,
-		Summary:    episode.Description.Summary,
-		Published:  episode.PublishDateTimestamp(),
-		Index:      int(index), // episode number
-		Image:      episode.Image.ResolveURI(a.StorageEndpoint, episode.ParentGUID()),
-		Extra1:     episode.Enclosure.ResolveURI(a.DefaultCDNEndpoint+"/c", episode.ParentGUID()),
-		Size:       int64(episode.Enclosure.Size),
-		Duration:   int64(episode.Description.Duration),
-		Created:    now,
-		Updated:    now,
+		Name:        episode.Metadata.Name,
+		GUID:        episode.GUID(),
+		Kind:        a.ResourceEpisode,
+		ParentGUID:  episode.Metadata.Labels[a.LabelParentGUID],
+		Location:    location,
+		Title:       episode.Description.Title,
+		Summary:     episode.Description.Summary,
+		Published:   episode.PublishDateTimestamp(),
+		Status:      episode.PublishStatus(),
+		Index:       int(index), // episode number
+		Season:      episode.Season(),
+		EpisodeType: episodeType(episode.Metadata.Labels[a.LabelType]),
+		Explicit:    episode.Explicit(),
+		Image:       episode.Image.ResolveURI(a.StorageEndpoint, episode.ParentGUID()),
+		Extra1:      episode.Enclosure.ResolveURI(a.DefaultCDNEndpoint+"/c", episode.ParentGUID()),
+		Size:        int64(episode.Enclosure.Size),
+		Duration:    int64(episode.Description.Duration),
+		Created:     now,
+		Updated:     now,
 	}
-	return updateResource(ctx, &rsrc)
+	if err := updateResource(ctx, &rsrc); err != nil {
+		return err
+	}
+	indexEpisode(ctx, episode)
+	notifyChange(ctx, a.ResourceEpisode, episode.GUID())
+	fireEvent(ctx, episodeEvent(a.EventEpisodeCreated, episode))
+	if published {
+		fireEvent(ctx, episodeEvent(a.EventEpisodePublished, episode))
+	}
+	return nil
 }
 
-// updateResource does what the name suggests
+// updateResource writes an inventory entry and updates the usage totals. The revision belongs to recordRevision
+// and claimRevision, if it was changed since r was read, the newer revision is kept.
 func updateResource(ctx context.Context, r *a.Resource) error {
-	if _, err := platform.DataStore().Put(ctx, resourceKey(r.GUID), r); err != nil {
-		return err
+	_, err := platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var before *a.Resource
+		var current a.Resource
+		if err := tx.Get(resourceKey(r.GUID), &current); err == nil {
+			before = &current
+		} else if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		if current.Revision > r.Revision {
+			r.Revision = current.Revision
+			r.UpdatedBy = current.UpdatedBy
+		}
+		if _, err := tx.Put(resourceKey(r.GUID), r); err != nil {
+			return err
+		}
+		return updateTotals(tx, before, r)
+	})
+	return err
+}
+
+// deleteResource removes an inventory entry and updates the usage totals. If 'expected' is not nil,
+// the entry is only removed if it is still at that revision, otherwise ErrRevisionConflict is returned.
+func deleteResource(ctx context.Context, guid string, expected *int) error {
+	_, err := platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var current a.Resource
+		if err := tx.Get(resourceKey(guid), &current); err != nil {
+			if err == datastore.ErrNoSuchEntity {
+				return nil
+			}
+			return err
+		}
+		if expected != nil && current.Revision != *expected {
+			return fmt.Errorf("'%s' was changed, expected revision %d, found %d: %w", guid, *expected, current.Revision, a.ErrRevisionConflict)
+		}
+		if err := tx.Delete(resourceKey(guid)); err != nil {
+			return err
+		}
+		return updateTotals(tx, &current, nil)
+	})
+	return err
+}
+
+// getMulti reads the entities of all keys into dst, a slice of pointers. Missing entities are left nil.
+func getMulti(ctx context.Context, keys []*datastore.Key, dst interface{}) error {
+	err := platform.DataStore().GetMulti(ctx, keys, dst)
+	if merr, ok := err.(datastore.MultiError); ok {
+		for _, e := range merr {
+			if e != nil && e != datastore.ErrNoSuchEntity {
+				return e
+			}
+		}
+		return nil
 	}
-	return nil
+	return err
 }
 
 func resourceKey(guid string) *datastore.Key {
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	ds "github.com/fupas/platform/pkg/platform"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"google.golang.org/appengine"
+)
+
+const (
+	// ScheduleTask route to ScheduleTaskEndpoint
+	ScheduleTask = "/schedule"
+)
+
+// ScheduleTaskEndpoint rebuilds all feeds with scheduled episodes that are due.
+// The endpoint is called by App Engine Cron, see cron.yaml.
+func ScheduleTaskEndpoint(c echo.Context) error {
+	if c.Request().Header.Get("X-Appengine-Cron") != "true" {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	n, err := ScheduleBuilds(appengine.NewContext(c.Request()))
+	if err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if n > 0 {
+		platform.TrackEvent(c.Request(), "task", "scheduled_build", fmt.Sprintf("%d", n), n)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// ScheduleBuilds rebuilds the feeds of all productions with an episode whose publish date has passed
+func ScheduleBuilds(ctx context.Context) (int, error) {
+	var productions []*a.Production
+
+	q := datastore.NewQuery(DatastoreProductions).Filter("NextBuild >", int64(0)).Filter("NextBuild <=", util.Timestamp())
+	if _, err := ds.DataStore().GetAll(ctx, q, &productions); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, p := range productions {
+		if err := Build(ctx, p.GUID, false); err != nil {
+			platform.ReportError(fmt.Errorf("scheduled build of '%s' failed: %v", p.GUID, err))
+
+			// do not retry until the next update or build
+			p, err := GetProduction(ctx, p.GUID)
+			if err != nil || p == nil {
+				continue
+			}
+			if p.NextBuild <= util.Timestamp() {
+				p.NextBuild = 0
+				UpdateProduction(ctx, p)
+			}
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
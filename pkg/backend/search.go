@@ -0,0 +1,464 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	ds "github.com/fupas/platform/pkg/platform"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"google.golang.org/appengine"
+)
+
+const (
+	// DatastoreSearch collection SEARCH
+	DatastoreSearch = "SEARCH"
+
+	// IndexTask route to IndexTaskEndpoint
+	IndexTask = "/index"
+	// full canonical route
+	indexTaskWithPrefix = "/_t/index"
+
+	// limits that keep an entry below the Datastore's entity and index size limits
+	maxSearchTerms = 5000
+	maxSearchText  = 128 * 1024
+	// transcripts larger than this are only indexed partially
+	maxTranscriptSize = 1024 * 1024
+	// transcriptTimeout limits the time to retrieve an external transcript
+	transcriptTimeout = 30 * time.Second
+	// the number of matches that are ranked, the best ones are returned. Results are marked as
+	// truncated if there are more matches.
+	maxSearchCandidates = 500
+
+	// snippet length around the first match in an episode's text
+	snippetLength = 200
+)
+
+var (
+	transcriptClient = &http.Client{Timeout: transcriptTimeout}
+
+	markupRegex  = regexp.MustCompile(`<[^>]*>`)
+	timingRegex  = regexp.MustCompile(`(?m)^(WEBVTT.*|\d+|[\d:.,]+ --> [\d:.,]+.*)$`)
+	stopWords    = map[string]bool{"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "with": true}
+	searchFields = []string{"title", "summary", "text"}
+)
+
+type (
+	// searchEntry is the search index entry of a show or episode
+	searchEntry struct {
+		GUID       string
+		Kind       string
+		Name       string
+		ParentGUID string
+		Show       string
+		Title      string `datastore:",noindex"`
+		Summary    string `datastore:",noindex"`
+		Text       string `datastore:",noindex"` // episode text and transcript
+		Terms      []string
+		Published  int64
+		Status     string
+		Updated    int64
+	}
+
+	// indexTask is the payload of an IndexTask
+	indexTask struct {
+		GUID string `json:"guid"`
+	}
+)
+
+// indexShow adds a show to the search index, replacing a previous entry. Errors are only reported,
+// a show that is missing from the index is still published.
+func indexShow(ctx context.Context, show *a.Show) {
+	entry := searchEntry{
+		GUID:       show.GUID(),
+		Kind:       a.ResourceShow,
+		Name:       show.Metadata.Name,
+		ParentGUID: show.GUID(),
+		Show:       show.Metadata.Name,
+		Title:      show.Description.Title,
+		Summary:    show.Description.Summary,
+		Status:     a.EpisodeStatusPublished,
+	}
+	if err := putSearchEntry(ctx, &entry); err != nil {
+		platform.ReportError(fmt.Errorf("can not index '%s': %v", entry.GUID, err))
+	}
+}
+
+// indexEpisode adds an episode to the search index, replacing a previous entry. Its transcript is added by
+// an IndexTask, downloading it could take a while. Errors are only reported.
+func indexEpisode(ctx context.Context, episode *a.Episode) {
+	entry := episodeEntry(ctx, episode)
+	if err := putSearchEntry(ctx, entry); err != nil {
+		platform.ReportError(fmt.Errorf("can not index '%s': %v", entry.GUID, err))
+		return
+	}
+
+	if episode.Transcript != nil && episode.Transcript.URI != "" {
+		if _, err := platform.CreateTask(ctx, indexTaskWithPrefix, &indexTask{GUID: entry.GUID}); err != nil {
+			platform.ReportError(fmt.Errorf("can not index transcript of '%s': %v", entry.GUID, err))
+		}
+	}
+}
+
+// IndexTaskEndpoint adds the transcript of an episode to its search index entry
+func IndexTaskEndpoint(c echo.Context) error {
+	var req indexTask
+	if err := c.Bind(&req); err != nil {
+		// just report and return, resending will not change anything
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	rsrc, err := GetResourceContent(ctx, req.GUID)
+	if err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+	episode, ok := rsrc.(*a.Episode)
+	if !ok || episode.Transcript == nil || episode.Transcript.URI == "" {
+		return c.NoContent(http.StatusOK) // deleted or changed in the meantime
+	}
+
+	entry := episodeEntry(ctx, episode)
+	transcript, err := transcriptText(ctx, entry.ParentGUID, episode.Transcript)
+	if err != nil {
+		// the episode is still searchable, just not by its transcript
+		platform.ReportError(fmt.Errorf("can not index transcript of '%s': %v", entry.GUID, err))
+		return c.NoContent(http.StatusOK)
+	}
+	entry.Text = entry.Text + "\n" + transcript
+
+	if err := putSearchEntry(ctx, entry); err != nil {
+		platform.ReportError(fmt.Errorf("can not index '%s': %v", entry.GUID, err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// episodeEntry returns the search index entry of an episode, without its transcript
+func episodeEntry(ctx context.Context, episode *a.Episode) *searchEntry {
+	entry := searchEntry{
+		GUID:       episode.GUID(),
+		Kind:       a.ResourceEpisode,
+		Name:       episode.Metadata.Name,
+		ParentGUID: episode.ParentGUID(),
+		Title:      episode.Description.Title,
+		Summary:    episode.Description.Summary,
+		Text:       stripMarkup(episode.Description.EpisodeText),
+		Published:  episode.PublishDateTimestamp(),
+		Status:     episode.PublishStatus(),
+	}
+
+	if p, _ := GetProduction(ctx, entry.ParentGUID); p != nil {
+		entry.Show = p.Name
+	}
+	return &entry
+}
+
+// removeFromIndex removes a show or episode from the search index
+func removeFromIndex(ctx context.Context, guid string) error {
+	if err := ds.DataStore().Delete(ctx, searchKey(guid)); err != nil && err != datastore.ErrNoSuchEntity {
+		return err
+	}
+	return nil
+}
+
+func putSearchEntry(ctx context.Context, entry *searchEntry) error {
+	entry.Terms = uniqueTerms(tokenize(entry.Title), tokenize(entry.Summary), tokenize(entry.Text))
+	if len(entry.Terms) > maxSearchTerms {
+		entry.Terms = entry.Terms[:maxSearchTerms]
+	}
+	if len(entry.Text) > maxSearchText {
+		entry.Text = strings.ToValidUTF8(entry.Text[:maxSearchText], "")
+	}
+	entry.Updated = util.Timestamp()
+
+	_, err := ds.DataStore().Put(ctx, searchKey(entry.GUID), entry)
+	return err
+}
+
+// Search returns up to 'limit' published shows and episodes that contain all words of the query, starting after 'cursor'.
+// Results are ranked by where the words are found, matches in titles count most. 'kinds' limits the results to shows or episodes.
+// Only the first maxSearchCandidates matches are ranked, the results are marked as truncated if there are more.
+func Search(ctx context.Context, query string, kinds []string, cursor string, limit int) (*a.SearchResults, error) {
+	results := a.SearchResults{Query: query, Results: make([]*a.SearchResult, 0)}
+
+	offset := 0
+	if cursor != "" {
+		o, err := decodeOffset(cursor)
+		if err != nil {
+			return nil, err
+		}
+		offset = o
+	}
+
+	terms := uniqueTerms(tokenize(query))
+	if len(terms) == 0 {
+		return &results, nil
+	}
+
+	// all words must match, the Datastore merges the filters on the list property
+	q := datastore.NewQuery(DatastoreSearch)
+	for _, term := range terms {
+		q = q.Filter("Terms =", term)
+	}
+	if len(kinds) == 1 {
+		q = q.Filter("Kind =", kinds[0])
+	}
+	q = q.Limit(maxSearchCandidates + 1)
+
+	var entries []*searchEntry
+	if _, err := ds.DataStore().GetAll(ctx, q, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) > maxSearchCandidates {
+		entries = entries[:maxSearchCandidates]
+		results.Truncated = true
+	}
+
+	kind := make(map[string]bool)
+	for _, k := range kinds {
+		kind[k] = true
+	}
+	now := util.Timestamp()
+	match := highlightRegex(terms)
+
+	var ranked []*a.SearchResult
+	for _, e := range entries {
+		if len(kind) > 0 && !kind[e.Kind] {
+			continue
+		}
+		if (&a.Resource{Status: e.Status, Published: e.Published}).StatusAt(now) != a.EpisodeStatusPublished {
+			continue // drafts, scheduled and unpublished episodes are not public
+		}
+		ranked = append(ranked, &a.SearchResult{
+			GUID:       e.GUID,
+			Kind:       e.Kind,
+			Name:       e.Name,
+			ParentGUID: e.ParentGUID,
+			Show:       e.Show,
+			Title:      e.Title,
+			Summary:    e.Summary,
+			Published:  e.Published,
+			Score:      score(e, terms),
+			Highlights: highlights(e, match),
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Published > ranked[j].Published
+	})
+
+	if offset < len(ranked) {
+		end := offset + limit
+		if end < len(ranked) {
+			results.More = true
+			results.Cursor = encodeOffset(end)
+		} else {
+			end = len(ranked)
+		}
+		results.Results = ranked[offset:end]
+		for i, r := range results.Results {
+			r.Cursor = encodeOffset(offset + i + 1)
+		}
+	}
+	return &results, nil
+}
+
+// score weighs the occurrences of the terms, matches in the title count most
+func score(e *searchEntry, terms []string) float64 {
+	title := strings.ToLower(e.Title)
+	summary := strings.ToLower(e.Summary)
+	text := strings.ToLower(e.Text)
+
+	s := 0.0
+	for _, term := range terms {
+		s += 3 * float64(strings.Count(title, term))
+		s += 2 * float64(strings.Count(summary, term))
+		if n := strings.Count(text, term); n > 10 {
+			s += 10 // long texts should not outweigh a title
+		} else {
+			s += float64(n)
+		}
+	}
+	return s
+}
+
+// highlights returns the title and summary with all matches wrapped in <em>, and a snippet of the text around the first match
+func highlights(e *searchEntry, match *regexp.Regexp) map[string]string {
+	h := make(map[string]string)
+	for _, field := range searchFields {
+		var text string
+		switch field {
+		case "title":
+			text = e.Title
+		case "summary":
+			text = e.Summary
+		case "text":
+			text = snippet(e.Text, match)
+		}
+		if match.MatchString(text) {
+			h[field] = highlight(text, match)
+		}
+	}
+	return h
+}
+
+// highlight escapes the text and wraps all matches in <em>
+func highlight(text string, match *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range match.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+		b.WriteString("</em>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// snippet cuts the text around the first match
+func snippet(text string, match *regexp.Regexp) string {
+	loc := match.FindStringIndex(text)
+	if loc == nil {
+		return ""
+	}
+	start := loc[0] - snippetLength/4
+	prefix := "..."
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+	end := start + snippetLength
+	suffix := "..."
+	if end >= len(text) {
+		end = len(text)
+		suffix = ""
+	}
+	// do not cut a word or rune in half
+	for start > 0 && !unicode.IsSpace(rune(text[start-1])) {
+		start--
+	}
+	for end < len(text) && !unicode.IsSpace(rune(text[end])) {
+		end++
+	}
+	return prefix + strings.TrimSpace(text[start:end]) + suffix
+}
+
+func highlightRegex(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// tokenize splits a text into lower case words, dropping stop words and single characters
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if len(w) > 1 && !stopWords[w] {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// uniqueTerms merges lists of terms, keeping the first occurrence of each term
+func uniqueTerms(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, l := range lists {
+		for _, t := range l {
+			if !seen[t] {
+				seen[t] = true
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms
+}
+
+// stripMarkup removes HTML tags and the cue timings of VTT and SRT transcripts
+func stripMarkup(text string) string {
+	text = markupRegex.ReplaceAllString(text, " ")
+	text = timingRegex.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
+
+// transcriptText retrieves the text of a transcript, from the CDN bucket or its external location
+func transcriptText(ctx context.Context, parent string, transcript *a.Asset) (string, error) {
+	var reader io.ReadCloser
+
+	if transcript.Rel == a.ResourceTypeLocal {
+		r, err := ds.Storage().Bucket(a.BucketCDN).Object(fmt.Sprintf("%s/%s", parent, transcript.URI)).NewReader(ctx)
+		if err != nil {
+			return "", err
+		}
+		reader = r
+	} else {
+		req, err := http.NewRequestWithContext(ctx, "GET", transcript.URI, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", a.UserAgentString)
+		resp, err := transcriptClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return "", fmt.Errorf("can not retrieve '%s': %s", transcript.URI, resp.Status)
+		}
+		reader = resp.Body
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxTranscriptSize))
+	if err != nil {
+		return "", err
+	}
+	return stripMarkup(strings.ToValidUTF8(string(data), "")), nil
+}
+
+func encodeOffset(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeOffset(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, a.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, a.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+func searchKey(guid string) *datastore.Key {
+	return datastore.NameKey(DatastoreSearch, guid, nil)
+}
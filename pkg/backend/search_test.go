@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := uniqueTerms(tokenize("The Story of Podcasting: Episode 1, a <b>Story</b> in 2 parts!"))
+	want := []string{"story", "podcasting", "episode", "parts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestStripMarkup(t *testing.T) {
+	vtt := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:04.000\nWelcome to <i>the</i> show &amp; more\n"
+	got := tokenize(stripMarkup(vtt))
+	want := []string{"welcome", "show", "more"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stripMarkup() = %v, want %v", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	match := highlightRegex([]string{"go", "podcast"})
+
+	got := highlight("A <Go> Podcast about going", match)
+	want := "A &lt;<em>Go</em>&gt; <em>Podcast</em> about going"
+	if got != want {
+		t.Errorf("highlight() = %q, want %q", got, want)
+	}
+
+	if s := snippet("nothing to see here", match); s != "" {
+		t.Errorf("snippet() = %q, want empty", s)
+	}
+}
+
+func TestOffsetCursor(t *testing.T) {
+	offset, err := decodeOffset(encodeOffset(42))
+	if err != nil || offset != 42 {
+		t.Errorf("decodeOffset() = %d, %v, want 42", offset, err)
+	}
+	if _, err := decodeOffset("not-a-cursor"); err == nil {
+		t.Error("decodeOffset() expected an error")
+	}
+}
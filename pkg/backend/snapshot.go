@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+)
+
+const (
+	// DatastoreBuilds collection BUILDS
+	DatastoreBuilds = "BUILDS"
+
+	// all feeds are kept in e.g. 'guid/_builds/buildid-checksum.xml'
+	buildsFolder = "_builds"
+)
+
+// snapshotFeed stores a generated feed as an immutable snapshot
+func snapshotFeed(ctx context.Context, guid string, feed []byte, episodes int) (_ *a.FeedSnapshot, err error) {
+	ctx, span := startSpan(ctx, "snapshotFeed", guid)
+	defer func() { endSpan(span, err) }()
+
+	id, _ := util.ShortUUID()
+	sum := md5.Sum(feed)
+
+	snapshot := a.FeedSnapshot{
+		BuildID:  strings.ToLower(id),
+		GUID:     guid,
+		Checksum: hex.EncodeToString(sum[:]),
+		Size:     int64(len(feed)),
+		Episodes: episodes,
+		Created:  util.Timestamp(),
+	}
+	snapshot.Location = fmt.Sprintf("%s/%s/%s-%s.xml", guid, buildsFolder, snapshot.BuildID, snapshot.Checksum)
+
+	obj := platform.Storage().Bucket(a.BucketProduction).Object(snapshot.Location)
+	writer := obj.If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	writer.ContentType = "application/xml"
+	if _, err := writer.Write(feed); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	if _, err := platform.DataStore().Put(ctx, snapshotKey(snapshot.BuildID), &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// publishFeed writes the feed to the CDN location
+func publishFeed(ctx context.Context, guid string, feed []byte) (err error) {
+	ctx, span := startSpan(ctx, "publishFeed", guid)
+	defer func() { endSpan(span, err) }()
+
+	writer := platform.Storage().Bucket(a.BucketCDN).Object(fmt.Sprintf("%s/feed.xml", guid)).NewWriter(ctx)
+	if _, err := writer.Write(feed); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	feedChanged(ctx, guid)
+	return nil
+}
+
+// ListFeedSnapshots returns all feeds that were built for a production, the most recent first
+func ListFeedSnapshots(ctx context.Context, guid string) (*a.FeedSnapshotList, error) {
+	p, err := GetProduction(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, a.ErrNoSuchProduction
+	}
+
+	var snapshots []*a.FeedSnapshot
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreBuilds).Filter("GUID =", guid).Order("-Created"), &snapshots); err != nil {
+		return nil, err
+	}
+	return &a.FeedSnapshotList{Active: p.BuildID, Snapshots: snapshots}, nil
+}
+
+// RestoreFeed publishes a previous feed snapshot again. It counts as a new build, clients see a new
+// Last-Modified date and the CDN replaces its cached copy once it sees the changed build ID.
+func RestoreFeed(ctx context.Context, guid, buildID string) (*a.FeedSnapshot, error) {
+	p, err := GetProduction(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, a.ErrNoSuchProduction
+	}
+
+	var snapshot a.FeedSnapshot
+	if err := platform.DataStore().Get(ctx, snapshotKey(buildID), &snapshot); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, fmt.Errorf("can not find build '%s'", buildID)
+		}
+		return nil, err
+	}
+	if snapshot.GUID != guid {
+		return nil, fmt.Errorf("build '%s' does not belong to '%s'", buildID, guid)
+	}
+
+	reader, err := platform.Storage().Bucket(a.BucketProduction).Object(snapshot.Location).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	feed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if sum := md5.Sum(feed); hex.EncodeToString(sum[:]) != snapshot.Checksum {
+		return nil, fmt.Errorf("checksum mismatch for build '%s'", buildID)
+	}
+
+	if err := publishFeed(ctx, guid, feed); err != nil {
+		return nil, err
+	}
+
+	now := util.Timestamp()
+	p.BuildID = snapshot.BuildID
+	p.BuildDate = now
+	p.Updated = now
+	if err := UpdateProduction(ctx, p); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func snapshotKey(buildID string) *datastore.Key {
+	return datastore.NameKey(DatastoreBuilds, buildID, nil)
+}
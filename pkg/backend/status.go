@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	p "github.com/podops/podops/internal/platform"
+)
+
+const (
+	// DatastoreAudit collection AUDIT
+	DatastoreAudit = "AUDIT"
+)
+
+// ChangeEpisodeStatus moves an episode to a new lifecycle status, records the change
+// and rebuilds the feed if it was built before. The last published episode of a built feed
+// can not be unpublished, the feed would keep listing it. A failed rebuild does not undo
+// the change, it is reported and recorded as the progress of the build, see GetBuildProgress.
+func ChangeEpisodeStatus(ctx context.Context, prod, guid, status, clientID string) (*a.Resource, error) {
+	r, err := GetResource(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.Kind != a.ResourceEpisode || r.ParentGUID != prod {
+		return nil, a.ErrNoSuchResource
+	}
+
+	e, _, _, err := ReadResource(ctx, r.Location)
+	if err != nil {
+		return nil, err
+	}
+	episode := e.(*a.Episode)
+
+	from := episode.StatusAt(util.Timestamp())
+	if from == status {
+		return nil, fmt.Errorf("episode '%s' is already %s", episode.Metadata.Name, status)
+	}
+	if from == a.EpisodeStatusScheduled && status == a.EpisodeStatusPublished {
+		return nil, fmt.Errorf("episode '%s' is scheduled for %s, change its 'date' label to publish it now", episode.Metadata.Name, episode.PublishDate())
+	}
+	if !a.ValidStatusTransition(from, status) {
+		return nil, fmt.Errorf("can not change status of episode '%s' from '%s' to '%s'", episode.Metadata.Name, from, status)
+	}
+
+	production, err := GetProduction(ctx, prod)
+	if err != nil {
+		return nil, err
+	}
+	if production != nil && production.BuildDate > 0 && from == a.EpisodeStatusPublished {
+		episodes, err := ListResources(ctx, prod, a.ResourceEpisode)
+		if err != nil {
+			return nil, err
+		}
+		if lastPublished(episodes, guid, util.Timestamp()) {
+			return nil, fmt.Errorf("can not change status of episode '%s' to '%s': it is the last published episode of the feed", episode.Metadata.Name, status)
+		}
+	}
+
+	episode.Status = status
+	if status == a.EpisodeStatusPublished && episode.Blocked() {
+		episode.Metadata.Labels[a.LabelBlock] = "no" // the status replaces the label
+	}
+
+	if err := UpdateEpisode(ctx, r.Location, episode); err != nil {
+		return nil, err
+	}
+	if err := WriteResourceContent(ctx, r.Location, false, false, episode); err != nil {
+		return nil, err
+	}
+	if _, err := RecordRevision(ctx, guid, clientID, episode); err != nil {
+		return nil, err
+	}
+
+	entry := a.AuditEntry{
+		GUID:       guid,
+		ParentGUID: prod,
+		From:       from,
+		To:         status,
+		ClientID:   clientID,
+		Timestamp:  util.Timestamp(),
+	}
+	if _, err := platform.DataStore().Put(ctx, datastore.IncompleteKey(DatastoreAudit, nil), &entry); err != nil {
+		return nil, err
+	}
+
+	if production != nil && production.BuildDate > 0 {
+		if err := Build(ctx, prod, false); err != nil {
+			p.ReportError(fmt.Errorf("can not rebuild feed '%s': %w", prod, err))
+		}
+	}
+
+	return GetResource(ctx, guid)
+}
+
+// lastPublished returns true if episode 'guid' is the only published one
+func lastPublished(episodes []*a.Resource, guid string, now int64) bool {
+	for _, e := range episodes {
+		if e.GUID != guid && e.StatusAt(now) == a.EpisodeStatusPublished {
+			return false
+		}
+	}
+	return true
+}
+
+// ListAuditEntries returns all status changes of a resource, the most recent first
+func ListAuditEntries(ctx context.Context, guid string) ([]*a.AuditEntry, error) {
+	var entries []*a.AuditEntry
+
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreAudit).Filter("GUID =", guid).Order("-Timestamp"), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
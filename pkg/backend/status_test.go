@@ -0,0 +1,22 @@
+package backend
+
+import (
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+)
+
+func TestLastPublished(t *testing.T) {
+	now := int64(1000)
+	published := &a.Resource{GUID: "e1", Status: a.EpisodeStatusPublished, Published: 500}
+	other := &a.Resource{GUID: "e2", Status: a.EpisodeStatusPublished, Published: 600}
+	scheduled := &a.Resource{GUID: "e3", Status: a.EpisodeStatusPublished, Published: 2000}
+	draft := &a.Resource{GUID: "e4", Status: a.EpisodeStatusDraft, Published: 500}
+
+	if !lastPublished([]*a.Resource{published, scheduled, draft}, "e1", now) {
+		t.Error("expected 'e1' to be the last published episode")
+	}
+	if lastPublished([]*a.Resource{published, other, draft}, "e1", now) {
+		t.Error("expected 'e2' to remain published")
+	}
+}
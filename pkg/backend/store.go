@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fupas/commons/pkg/util"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+)
+
+// StoreShow writes a show's .yaml file, updates the inventory and the production and records a new revision.
+// A new .yaml file is created if create==true, an existing one will be overwritten if force==true.
+// If 'expected' is not nil, the show is only written if it is still at that revision, otherwise ErrRevisionConflict is returned.
+func StoreShow(ctx context.Context, show *a.Show, clientID string, create, force bool, expected *int) (rev *a.Revision, err error) {
+	guid := show.GUID()
+	ctx, span := startSpan(ctx, "StoreShow", guid)
+	defer func() { endSpan(span, err) }()
+
+	claimed := 0
+	if expected != nil {
+		if claimed, err = claimRevision(ctx, guid, *expected); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil && claimed > 0 {
+			if rerr := releaseRevision(ctx, guid, claimed); rerr != nil {
+				platform.ReportError(rerr)
+			}
+		}
+	}()
+
+	location := fmt.Sprintf("%s/%s-%s.yaml", guid, a.ResourceShow, guid)
+
+	// update the PRODUCTION entry based on resource
+	p, err := GetProduction(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, a.ErrNoSuchProduction
+	}
+
+	// the attributes we copy from the .yaml
+	p.Title = show.Description.Title
+	p.Summary = show.Description.Summary
+	p.Updated = util.Timestamp()
+
+	if err := UpdateProduction(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := EnsureAsset(ctx, guid, &show.Image); err != nil {
+		return nil, err
+	}
+	if err := UpdateShow(ctx, location, show); err != nil {
+		return nil, err
+	}
+	if err := WriteResourceContent(ctx, location, create, force, show); err != nil {
+		return nil, err
+	}
+	return recordRevision(ctx, guid, clientID, show, claimed)
+}
+
+// StoreEpisode writes an episode's .yaml file, updates the inventory and records a new revision.
+// A new .yaml file is created if create==true, an existing one will be overwritten if force==true.
+// If 'expected' is not nil, the episode is only written if it is still at that revision, otherwise ErrRevisionConflict is returned.
+func StoreEpisode(ctx context.Context, episode *a.Episode, clientID string, create, force bool, expected *int) (rev *a.Revision, err error) {
+	parent := episode.ParentGUID()
+	ctx, span := startSpan(ctx, "StoreEpisode", parent)
+	defer func() { endSpan(span, err) }()
+
+	claimed := 0
+	if expected != nil {
+		if claimed, err = claimRevision(ctx, episode.GUID(), *expected); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil && claimed > 0 {
+			if rerr := releaseRevision(ctx, episode.GUID(), claimed); rerr != nil {
+				platform.ReportError(rerr)
+			}
+		}
+	}()
+
+	location := fmt.Sprintf("%s/%s-%s.yaml", parent, a.ResourceEpisode, episode.GUID())
+
+	// ensure images and media files
+	if err := EnsureAsset(ctx, parent, &episode.Image); err != nil {
+		return nil, err
+	}
+	if err := EnsureAsset(ctx, parent, &episode.Enclosure); err != nil {
+		return nil, err
+	}
+
+	if err := UpdateEpisode(ctx, location, episode); err != nil {
+		return nil, err
+	}
+	if err := WriteResourceContent(ctx, location, create, force, episode); err != nil {
+		return nil, err
+	}
+	return recordRevision(ctx, episode.GUID(), clientID, episode, claimed)
+}
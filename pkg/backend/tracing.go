@@ -0,0 +1,19 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/podops/podops/internal/platform"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span for an operation on production guid. The span must be ended with endSpan.
+func startSpan(ctx context.Context, name, guid string) (context.Context, trace.Span) {
+	return platform.StartSpan(ctx, "backend."+name, attribute.String("guid", guid))
+}
+
+// endSpan records err, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	platform.EndSpan(span, err)
+}
@@ -0,0 +1,322 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/platform/pkg/platform"
+	a "github.com/podops/podops/apiv1"
+	p "github.com/podops/podops/internal/platform"
+)
+
+const (
+	// DatastoreUsage collection USAGE
+	DatastoreUsage = "USAGE"
+	// DatastoreQuotas collection QUOTAS
+	DatastoreQuotas = "QUOTAS"
+
+	// egress is counted on every CDN request, spread the writes across several entities
+	usageShards = 20
+	// egressFlushInterval is how often the egress counted in memory is written
+	egressFlushInterval = time.Minute
+)
+
+type (
+	// usageCounter is one shard of the monthly counters of a production
+	usageCounter struct {
+		GUID   string
+		Month  string
+		Egress int64
+		Builds int64
+	}
+
+	// usageTotals are the running totals of a production, updated with every change to its inventory
+	usageTotals struct {
+		GUID     string
+		Storage  int64
+		Episodes int64
+	}
+)
+
+var (
+	pendingEgress = make(map[string]int64) // production GUID -> bytes
+	egressMu      sync.Mutex
+	egressFlusher sync.Once
+)
+
+// TrackEgress adds 'bytes' to the monthly egress of a production. The bytes are counted in memory
+// and written by FlushEgress, every minute and when the service shuts down.
+func TrackEgress(guid string, bytes int64) {
+	egressFlusher.Do(func() { go flushEgressPeriodically() })
+
+	egressMu.Lock()
+	defer egressMu.Unlock()
+	pendingEgress[guid] += bytes
+}
+
+// FlushEgress writes the egress counted in memory. Egress that can not be written is kept for the next attempt.
+func FlushEgress(ctx context.Context) error {
+	egressMu.Lock()
+	pending := pendingEgress
+	pendingEgress = make(map[string]int64)
+	egressMu.Unlock()
+
+	var lastErr error
+	for guid, bytes := range pending {
+		if err := incrementUsage(ctx, guid, bytes, 0); err != nil {
+			lastErr = err
+
+			egressMu.Lock()
+			pendingEgress[guid] += bytes
+			egressMu.Unlock()
+		}
+	}
+	return lastErr
+}
+
+func flushEgressPeriodically() {
+	for range time.Tick(egressFlushInterval) {
+		if err := FlushEgress(context.Background()); err != nil {
+			p.ReportError(fmt.Errorf("can not write the egress: %w", err))
+		}
+	}
+}
+
+// trackBuild counts a build of a production
+func trackBuild(ctx context.Context, guid string) error {
+	return incrementUsage(ctx, guid, 0, 1)
+}
+
+func incrementUsage(ctx context.Context, guid string, egress, builds int64) error {
+	month := currentMonth()
+	k := datastore.NameKey(DatastoreUsage, fmt.Sprintf("%s.%s.%d", guid, month, rand.Intn(usageShards)), nil)
+
+	_, err := platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var counter usageCounter
+		if err := tx.Get(k, &counter); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		counter.GUID = guid
+		counter.Month = month
+		counter.Egress += egress
+		counter.Builds += builds
+		_, err := tx.Put(k, &counter)
+		return err
+	})
+	return err
+}
+
+// GetUsage returns the usage of a production in the current month
+func GetUsage(ctx context.Context, guid string) (*a.Usage, error) {
+	usage := a.Usage{GUID: guid, Month: currentMonth()}
+
+	totals, err := getTotals(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	usage.Storage = totals.Storage
+	usage.Episodes = totals.Episodes
+
+	var counters []*usageCounter
+	if _, err := platform.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreUsage).Filter("GUID =", guid).Filter("Month =", usage.Month), &counters); err != nil {
+		return nil, err
+	}
+	for _, c := range counters {
+		usage.Egress += c.Egress
+		usage.Builds += c.Builds
+	}
+	return &usage, nil
+}
+
+// GetOwnerUsage returns the total usage of all productions of an owner in the current month
+func GetOwnerUsage(ctx context.Context, owner string) (*a.Usage, error) {
+	total := a.Usage{Month: currentMonth()}
+
+	productions, err := FindProductionsByOwner(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range productions {
+		usage, err := GetUsage(ctx, p.GUID)
+		if err != nil {
+			return nil, err
+		}
+		total.Storage += usage.Storage
+		total.Egress += usage.Egress
+		total.Episodes += usage.Episodes
+		total.Builds += usage.Builds
+	}
+	return &total, nil
+}
+
+// GetQuota returns the quota of an owner. Owners without a quota of their own get the default quota.
+func GetQuota(ctx context.Context, owner string) (*a.Quota, error) {
+	var q a.Quota
+
+	if err := platform.DataStore().Get(ctx, quotaKey(owner), &q); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return &a.Quota{Owner: owner, Storage: a.QuotaStorage, Egress: a.QuotaEgress, Episodes: a.QuotaEpisodes, Builds: a.QuotaBuilds}, nil
+		}
+		return nil, err
+	}
+	return &q, nil
+}
+
+// UpdateQuota sets the quota of an owner
+func UpdateQuota(ctx context.Context, q *a.Quota) error {
+	_, err := platform.DataStore().Put(ctx, quotaKey(q.Owner), q)
+	return err
+}
+
+// GetUsageReport returns the usage of a production and its owner together with the owner's quota
+func GetUsageReport(ctx context.Context, p *a.Production) (*a.UsageReport, error) {
+	usage, err := GetUsage(ctx, p.GUID)
+	if err != nil {
+		return nil, err
+	}
+	total, err := GetOwnerUsage(ctx, p.Owner)
+	if err != nil {
+		return nil, err
+	}
+	q, err := GetQuota(ctx, p.Owner)
+	if err != nil {
+		return nil, err
+	}
+	return &a.UsageReport{Production: usage, Owner: total, Quota: q}, nil
+}
+
+// CheckQuota verifies that an owner can add 'storage' bytes, 'episodes' episodes and 'builds' builds.
+// It returns an error wrapping a.ErrQuotaExceeded or a.ErrEpisodeLimit otherwise.
+func CheckQuota(ctx context.Context, owner string, storage, episodes, builds int64) error {
+	q, err := GetQuota(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if q.Storage == 0 && q.Egress == 0 && q.Episodes == 0 && q.Builds == 0 {
+		return nil // unlimited, no need to add everything up
+	}
+	usage, err := GetOwnerUsage(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if q.Egress > 0 && usage.Egress >= q.Egress {
+		return fmt.Errorf("%w: %d of %d bytes egress used in %s", a.ErrQuotaExceeded, usage.Egress, q.Egress, usage.Month)
+	}
+	if q.Storage > 0 && storage > 0 && usage.Storage+storage > q.Storage {
+		return fmt.Errorf("%w: %d of %d bytes storage used, can not add %d bytes", a.ErrQuotaExceeded, usage.Storage, q.Storage, storage)
+	}
+	if q.Builds > 0 && builds > 0 && usage.Builds+builds > q.Builds {
+		return fmt.Errorf("%w: %d of %d builds used in %s", a.ErrQuotaExceeded, usage.Builds, q.Builds, usage.Month)
+	}
+	if q.Episodes > 0 && episodes > 0 && usage.Episodes+episodes > q.Episodes {
+		return fmt.Errorf("%w: %d of %d episodes", a.ErrEpisodeLimit, usage.Episodes, q.Episodes)
+	}
+	return nil
+}
+
+// getTotals returns the running totals of a production. Productions created before the totals were
+// introduced have none, their inventory is added up once.
+func getTotals(ctx context.Context, guid string) (*usageTotals, error) {
+	var totals usageTotals
+	err := platform.DataStore().Get(ctx, totalsKey(guid), &totals)
+	if err == nil {
+		return &totals, nil
+	}
+	if err != datastore.ErrNoSuchEntity {
+		return nil, err
+	}
+
+	totals.GUID = guid
+	assets, err := ListResources(ctx, guid, a.ResourceAsset)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range assets {
+		totals.Storage += r.Size
+	}
+	episodes, err := ListResources(ctx, guid, a.ResourceEpisode)
+	if err != nil {
+		return nil, err
+	}
+	totals.Episodes = int64(len(episodes))
+
+	_, err = platform.DataStore().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var current usageTotals
+		if err := tx.Get(totalsKey(guid), &current); err != datastore.ErrNoSuchEntity {
+			return err // added up by someone else in the meantime
+		}
+		_, err := tx.Put(totalsKey(guid), &totals)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// updateTotals adjusts the running totals in tx after 'before' was replaced by 'after', either of them can be nil.
+// Productions without totals are skipped, getTotals adds up their inventory.
+func updateTotals(tx *datastore.Transaction, before, after *a.Resource) error {
+	for guid, d := range totalsDelta(before, after) {
+		var totals usageTotals
+		if err := tx.Get(totalsKey(guid), &totals); err != nil {
+			if err == datastore.ErrNoSuchEntity {
+				continue
+			}
+			return err
+		}
+		totals.Storage += d.Storage
+		totals.Episodes += d.Episodes
+		if _, err := tx.Put(totalsKey(guid), &totals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// totalsDelta returns the changes to the totals of each production, by its GUID
+func totalsDelta(before, after *a.Resource) map[string]*usageTotals {
+	deltas := make(map[string]*usageTotals)
+	add := func(r *a.Resource, sign int64) {
+		if r == nil || (r.Kind != a.ResourceAsset && r.Kind != a.ResourceEpisode) {
+			return
+		}
+		d, ok := deltas[r.ParentGUID]
+		if !ok {
+			d = &usageTotals{GUID: r.ParentGUID}
+			deltas[r.ParentGUID] = d
+		}
+		if r.Kind == a.ResourceAsset {
+			d.Storage += sign * r.Size
+		} else {
+			d.Episodes += sign
+		}
+	}
+	add(before, -1)
+	add(after, 1)
+
+	for guid, d := range deltas {
+		if d.Storage == 0 && d.Episodes == 0 {
+			delete(deltas, guid)
+		}
+	}
+	return deltas
+}
+
+func currentMonth() string {
+	return time.Now().UTC().Format("2006-01")
+}
+
+func quotaKey(owner string) *datastore.Key {
+	return datastore.NameKey(DatastoreQuotas, owner, nil)
+}
+
+// totalsKey shares the USAGE collection with the monthly counters, which are named 'GUID.MONTH.SHARD'
+func totalsKey(guid string) *datastore.Key {
+	return datastore.NameKey(DatastoreUsage, guid, nil)
+}
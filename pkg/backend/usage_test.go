@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+
+	a "github.com/podops/podops/apiv1"
+)
+
+func TestTotalsDelta(t *testing.T) {
+	asset := &a.Resource{Kind: a.ResourceAsset, ParentGUID: "abc", Size: 100}
+	resized := &a.Resource{Kind: a.ResourceAsset, ParentGUID: "abc", Size: 250}
+	episode := &a.Resource{Kind: a.ResourceEpisode, ParentGUID: "abc", Size: 1000}
+	show := &a.Resource{Kind: a.ResourceShow, ParentGUID: "abc"}
+
+	tests := []struct {
+		name          string
+		before, after *a.Resource
+		want          map[string]*usageTotals
+	}{
+		{"new asset", nil, asset, map[string]*usageTotals{"abc": {GUID: "abc", Storage: 100}}},
+		{"resized asset", asset, resized, map[string]*usageTotals{"abc": {GUID: "abc", Storage: 150}}},
+		{"deleted asset", asset, nil, map[string]*usageTotals{"abc": {GUID: "abc", Storage: -100}}},
+		{"new episode", nil, episode, map[string]*usageTotals{"abc": {GUID: "abc", Episodes: 1}}},
+		{"updated episode", episode, episode, map[string]*usageTotals{}},
+		{"show", nil, show, map[string]*usageTotals{}},
+	}
+	for _, tt := range tests {
+		if got := totalsDelta(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
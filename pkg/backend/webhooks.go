@@ -0,0 +1,433 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	ds "github.com/fupas/platform/pkg/platform"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"google.golang.org/appengine"
+)
+
+const (
+	// DatastoreWebhooks collection WEBHOOKS
+	DatastoreWebhooks = "WEBHOOKS"
+	// DatastoreDeliveries collection DELIVERIES
+	DatastoreDeliveries = "DELIVERIES"
+
+	// WebhookTask route to WebhookTaskEndpoint
+	WebhookTask = "/webhook"
+	// full canonical route
+	webhookTaskWithPrefix = "/_t/webhook"
+
+	// SignatureHeader carries the HMAC-SHA256 of the payload, keyed with the webhook's secret
+	SignatureHeader = "X-Podops-Signature"
+	// EventHeader carries the event, e.g. episode.created
+	EventHeader = "X-Podops-Event"
+	// DeliveryHeader carries the delivery ID
+	DeliveryHeader = "X-Podops-Delivery"
+
+	// maxWebhooks limits the webhooks of a production
+	maxWebhooks = 10
+	// maxWebhookAttempts limits the attempts to deliver an event
+	maxWebhookAttempts = 8
+	// webhookBackoff is the delay before the second attempt, it doubles with every further attempt
+	webhookBackoff = 30 * time.Second
+	// maxWebhookBackoff limits the delay between two attempts
+	maxWebhookBackoff = 6 * time.Hour
+	// webhookTimeout limits the time a receiver has to respond
+	webhookTimeout = 10 * time.Second
+	// maxDeliveries limits the deliveries that are listed
+	maxDeliveries = 50
+	// maxResponseSize is the part of a receiver's response that is kept in the delivery log
+	maxResponseSize = 512
+)
+
+var (
+	// webhookEvents are all events a webhook can subscribe to
+	webhookEvents = map[string]bool{
+		a.EventEpisodeCreated:   true,
+		a.EventEpisodePublished: true,
+		a.EventBuildSucceeded:   true,
+		a.EventBuildFailed:      true,
+		a.EventAssetImported:    true,
+	}
+
+	webhookClient = &http.Client{Timeout: webhookTimeout}
+)
+
+type (
+	// deliveryTask is the payload of a WebhookTask
+	deliveryTask struct {
+		ID string `json:"id"`
+	}
+)
+
+// CreateWebhook subscribes a URL to the events of a production. The webhook returned includes its secret.
+func CreateWebhook(ctx context.Context, w *a.Webhook) (*a.Webhook, error) {
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	hooks, err := listWebhooks(ctx, w.GUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) >= maxWebhooks {
+		return nil, fmt.Errorf("can not add more than %d webhooks", maxWebhooks)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	id, _ := util.ShortUUID()
+	now := util.Timestamp()
+
+	hook := a.Webhook{
+		ID:      strings.ToLower(id),
+		GUID:    w.GUID,
+		URL:     w.URL,
+		Events:  w.Events,
+		Secret:  hex.EncodeToString(secret),
+		Active:  true,
+		Created: now,
+		Updated: now,
+	}
+	if _, err := ds.DataStore().Put(ctx, webhookKey(hook.ID), &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// GetWebhook returns a webhook without its secret, nil if it does not exist
+func GetWebhook(ctx context.Context, id string) (*a.Webhook, error) {
+	w, err := getWebhook(ctx, id)
+	if w != nil {
+		w.Secret = ""
+	}
+	return w, err
+}
+
+// ListWebhooks returns the webhooks of a production without their secrets
+func ListWebhooks(ctx context.Context, guid string) ([]*a.Webhook, error) {
+	hooks, err := listWebhooks(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range hooks {
+		w.Secret = ""
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook changes the URL, the events or whether a webhook is active. The secret is kept.
+func UpdateWebhook(ctx context.Context, w *a.Webhook) (*a.Webhook, error) {
+	hook, err := getWebhook(ctx, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil || hook.GUID != w.GUID {
+		return nil, a.ErrNoSuchWebhook
+	}
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	hook.URL = w.URL
+	hook.Events = w.Events
+	hook.Active = w.Active
+	hook.Updated = util.Timestamp()
+
+	if _, err := ds.DataStore().Put(ctx, webhookKey(hook.ID), hook); err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook and its deliveries
+func DeleteWebhook(ctx context.Context, guid, id string) error {
+	hook, err := getWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hook == nil || hook.GUID != guid {
+		return a.ErrNoSuchWebhook
+	}
+	if err := deleteAll(ctx, datastore.NewQuery(DatastoreDeliveries).Filter("WebhookID =", id)); err != nil {
+		return err
+	}
+	return ds.DataStore().Delete(ctx, webhookKey(id))
+}
+
+// ListDeliveries returns the recent deliveries of a webhook, the latest first
+func ListDeliveries(ctx context.Context, guid, id string) ([]*a.WebhookDelivery, error) {
+	hook, err := getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil || hook.GUID != guid {
+		return nil, a.ErrNoSuchWebhook
+	}
+
+	var deliveries []*a.WebhookDelivery
+	q := datastore.NewQuery(DatastoreDeliveries).Filter("WebhookID =", id).Order("-Created").Limit(maxDeliveries)
+	if _, err := ds.DataStore().GetAll(ctx, q, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ReplayDelivery delivers the event of a previous delivery again, as a new delivery
+func ReplayDelivery(ctx context.Context, guid, id string) (*a.WebhookDelivery, error) {
+	var d a.WebhookDelivery
+	if err := ds.DataStore().Get(ctx, deliveryKey(id), &d); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, a.ErrNoSuchWebhook
+		}
+		return nil, err
+	}
+	if d.GUID != guid {
+		return nil, a.ErrNoSuchWebhook
+	}
+	return scheduleDelivery(ctx, d.WebhookID, d.GUID, d.Event, d.EventID, d.Payload)
+}
+
+// fireEvent schedules the delivery of an event to all webhooks of the production that subscribed to it.
+// Errors are only reported, webhooks never fail the operation that caused the event.
+func fireEvent(ctx context.Context, e *a.WebhookEvent) {
+	hooks, err := listWebhooks(ctx, e.GUID)
+	if err != nil {
+		platform.ReportError(fmt.Errorf("can not fire '%s' of '%s': %v", e.Event, e.GUID, err))
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	id, _ := util.ShortUUID()
+	e.ID = strings.ToLower(id)
+	e.Timestamp = util.Timestamp()
+	payload, err := json.Marshal(e)
+	if err != nil {
+		platform.ReportError(err)
+		return
+	}
+
+	for _, w := range hooks {
+		if !w.Active || !subscribed(w, e.Event) {
+			continue
+		}
+		if _, err := scheduleDelivery(ctx, w.ID, e.GUID, e.Event, e.ID, string(payload)); err != nil {
+			platform.ReportError(fmt.Errorf("can not schedule '%s' for webhook '%s': %v", e.Event, w.ID, err))
+		}
+	}
+}
+
+// episodeEvent returns event 'event' of an episode
+func episodeEvent(event string, episode *a.Episode) *a.WebhookEvent {
+	return &a.WebhookEvent{
+		Event:    event,
+		GUID:     episode.ParentGUID(),
+		Resource: episode.GUID(),
+		Name:     episode.Metadata.Name,
+		URL:      episode.Enclosure.ResolveURI(a.DefaultCDNEndpoint+"/c", episode.ParentGUID()),
+	}
+}
+
+// WebhookTaskEndpoint makes one attempt to deliver an event and schedules the next attempt if it fails
+func WebhookTaskEndpoint(c echo.Context) error {
+	var req deliveryTask
+	if err := c.Bind(&req); err != nil {
+		// just report and return, resending will not change anything
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	if err := deliverEvent(ctx, req.ID); err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// deliverEvent POSTs the payload of a delivery to its webhook. Failed attempts are rescheduled with exponential backoff.
+func deliverEvent(ctx context.Context, id string) (err error) {
+	var d a.WebhookDelivery
+	if err := ds.DataStore().Get(ctx, deliveryKey(id), &d); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil // the webhook was deleted
+		}
+		return err
+	}
+	ctx, span := startSpan(ctx, "deliverEvent", d.GUID)
+	defer func() { endSpan(span, err) }()
+
+	if d.Delivered {
+		return nil // the task ran twice
+	}
+	w, err := getWebhook(ctx, d.WebhookID)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return nil // the webhook was deleted
+	}
+
+	d.Attempts++
+	d.Status, d.Error = postEvent(ctx, w, &d)
+	d.Delivered = d.Status >= 200 && d.Status < 300
+	d.NextAttempt = 0
+	d.Updated = util.Timestamp()
+
+	if !d.Delivered && d.Attempts < maxWebhookAttempts && w.Active {
+		next := time.Now().Add(backoff(d.Attempts))
+		if _, err := platform.CreateTaskAt(ctx, webhookTaskWithPrefix, &deliveryTask{ID: d.ID}, next); err != nil {
+			return err
+		}
+		d.NextAttempt = next.Unix()
+	}
+
+	_, err = ds.DataStore().Put(ctx, deliveryKey(d.ID), &d)
+	return err
+}
+
+// postEvent sends the payload and returns the HTTP status, or 0 and the error if there was no response
+func postEvent(ctx context.Context, w *a.Webhook, d *a.WebhookDelivery) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, "POST", w.URL, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", a.UserAgentString)
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(SignatureHeader, Sign(w.Secret, []byte(d.Payload)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, ""
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	return resp.StatusCode, strings.TrimSpace(fmt.Sprintf("%s %s", resp.Status, strings.ToValidUTF8(string(body), "")))
+}
+
+// Sign returns the signature of a payload, as sent in header X-Podops-Signature
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the delay after the n-th failed attempt
+func backoff(n int) time.Duration {
+	d := webhookBackoff
+	for i := 1; i < n && d < maxWebhookBackoff; i++ {
+		d *= 2
+	}
+	if d > maxWebhookBackoff {
+		return maxWebhookBackoff
+	}
+	return d
+}
+
+func scheduleDelivery(ctx context.Context, webhookID, guid, event, eventID, payload string) (*a.WebhookDelivery, error) {
+	id, _ := util.ShortUUID()
+	now := util.Timestamp()
+
+	d := a.WebhookDelivery{
+		ID:          strings.ToLower(id),
+		WebhookID:   webhookID,
+		GUID:        guid,
+		Event:       event,
+		EventID:     eventID,
+		Payload:     payload,
+		NextAttempt: now,
+		Created:     now,
+		Updated:     now,
+	}
+	if _, err := ds.DataStore().Put(ctx, deliveryKey(d.ID), &d); err != nil {
+		return nil, err
+	}
+	if _, err := platform.CreateTask(ctx, webhookTaskWithPrefix, &deliveryTask{ID: d.ID}); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func validateWebhook(w *a.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid url '%s'", w.URL)
+	}
+	for _, e := range w.Events {
+		if !webhookEvents[e] {
+			return fmt.Errorf("unknown event '%s'", e)
+		}
+	}
+	return nil
+}
+
+func subscribed(w *a.Webhook, event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func getWebhook(ctx context.Context, id string) (*a.Webhook, error) {
+	var w a.Webhook
+
+	if err := ds.DataStore().Get(ctx, webhookKey(id), &w); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, nil // not found is not an error
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func listWebhooks(ctx context.Context, guid string) ([]*a.Webhook, error) {
+	var hooks []*a.Webhook
+
+	if _, err := ds.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreWebhooks).Filter("GUID =", guid), &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func webhookKey(id string) *datastore.Key {
+	return datastore.NameKey(DatastoreWebhooks, id, nil)
+}
+
+func deliveryKey(id string) *datastore.Key {
+	return datastore.NameKey(DatastoreDeliveries, id, nil)
+}
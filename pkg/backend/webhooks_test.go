@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	a "github.com/podops/podops/apiv1"
+)
+
+func TestSign(t *testing.T) {
+	// RFC 4231, test case 2
+	got := Sign("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{20, maxWebhookBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	if err := validateWebhook(&a.Webhook{URL: "https://example.com/hook", Events: []string{a.EventBuildFailed}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateWebhook(&a.Webhook{URL: "ftp://example.com/hook"}); err == nil {
+		t.Error("expected an error for an invalid url")
+	}
+	if err := validateWebhook(&a.Webhook{URL: "https://example.com/hook", Events: []string{"show.deleted"}}); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
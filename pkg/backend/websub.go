@@ -0,0 +1,333 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/fupas/commons/pkg/util"
+	ds "github.com/fupas/platform/pkg/platform"
+	"github.com/labstack/echo/v4"
+	a "github.com/podops/podops/apiv1"
+	"github.com/podops/podops/internal/platform"
+	"google.golang.org/appengine"
+)
+
+// A WebSub hub, see https://www.w3.org/TR/websub/. Subscribers POST hub.mode, hub.topic, hub.callback and
+// optionally hub.lease_seconds and hub.secret to the hub. The hub verifies the intent of the subscriber
+// and POSTs the feed to the callback whenever it changes.
+
+const (
+	// DatastoreSubscriptions collection SUBSCRIPTIONS
+	DatastoreSubscriptions = "SUBSCRIPTIONS"
+
+	// WebSubVerifyTask route to WebSubVerifyTaskEndpoint
+	WebSubVerifyTask = "/websub/verify"
+	// WebSubDeliverTask route to WebSubDeliverTaskEndpoint
+	WebSubDeliverTask = "/websub/deliver"
+	// full canonical routes
+	webSubVerifyTaskWithPrefix  = "/_t/websub/verify"
+	webSubDeliverTaskWithPrefix = "/_t/websub/deliver"
+
+	// HubSignatureHeader carries the HMAC-SHA256 of the feed, keyed with the subscriber's secret
+	HubSignatureHeader = "X-Hub-Signature"
+
+	// HubModeSubscribe subscribes a callback to a topic
+	HubModeSubscribe = "subscribe"
+	// HubModeUnsubscribe removes a subscription
+	HubModeUnsubscribe = "unsubscribe"
+
+	defaultLease  = 10 * 86400 // seconds
+	minLease      = 3600
+	maxLease      = 30 * 86400
+	maxSecretSize = 200 // bytes, as required by the spec
+	// maxHubAttempts limits the attempts to deliver a feed to a subscriber
+	maxHubAttempts = 5
+)
+
+type (
+	// verifyTask is the payload of a WebSubVerifyTask
+	verifyTask struct {
+		Mode         string `json:"mode"`
+		GUID         string `json:"guid"`
+		Topic        string `json:"topic"`
+		Callback     string `json:"callback"`
+		Secret       string `json:"secret"`
+		LeaseSeconds int64  `json:"lease_seconds"`
+	}
+
+	// deliverTask is the payload of a WebSubDeliverTask
+	deliverTask struct {
+		ID       string `json:"id"`
+		Attempts int    `json:"attempts"`
+	}
+)
+
+// HubURL returns the location of the WebSub hub, as advertised in the feeds
+func HubURL() string {
+	return a.DefaultCDNEndpoint + "/hub"
+}
+
+// Subscribe handles a subscription request. The request is only accepted after the subscriber verified its intent.
+func Subscribe(ctx context.Context, mode, topic, callback, secret string, lease int64) error {
+	if mode != HubModeSubscribe && mode != HubModeUnsubscribe {
+		return fmt.Errorf("invalid hub.mode '%s'", mode)
+	}
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid hub.callback '%s'", callback)
+	}
+	if len(secret) > maxSecretSize {
+		return fmt.Errorf("hub.secret must be less than %d bytes", maxSecretSize)
+	}
+	if lease <= 0 {
+		lease = defaultLease
+	} else if lease < minLease {
+		lease = minLease
+	} else if lease > maxLease {
+		lease = maxLease
+	}
+
+	p, err := topicProduction(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("unknown hub.topic '%s'", topic)
+	}
+
+	req := verifyTask{
+		Mode:         mode,
+		GUID:         p.GUID,
+		Topic:        topic,
+		Callback:     callback,
+		Secret:       secret,
+		LeaseSeconds: lease,
+	}
+	_, err = platform.CreateTask(ctx, webSubVerifyTaskWithPrefix, &req)
+	return err
+}
+
+// WebSubVerifyTaskEndpoint verifies the intent of a subscriber and adds or removes its subscription
+func WebSubVerifyTaskEndpoint(c echo.Context) error {
+	var req verifyTask
+	if err := c.Bind(&req); err != nil {
+		// just report and return, resending will not change anything
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+	if err := verifyIntent(ctx, &req); err != nil {
+		// the subscriber has to send a new request
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	id := subscriptionID(req.Topic, req.Callback)
+	if req.Mode == HubModeUnsubscribe {
+		if err := ds.DataStore().Delete(ctx, subscriptionKey(id)); err != nil {
+			platform.ReportError(err)
+			return c.NoContent(http.StatusInternalServerError) // retried by the queue
+		}
+		return c.NoContent(http.StatusOK)
+	}
+
+	now := util.Timestamp()
+	sub := a.Subscription{
+		ID:           id,
+		GUID:         req.GUID,
+		Topic:        req.Topic,
+		Callback:     req.Callback,
+		Secret:       req.Secret,
+		LeaseSeconds: req.LeaseSeconds,
+		Expires:      now + req.LeaseSeconds,
+		Created:      now,
+		Updated:      now,
+	}
+	var existing a.Subscription
+	if err := ds.DataStore().Get(ctx, subscriptionKey(id), &existing); err == nil {
+		sub.Created = existing.Created // a renewal
+	}
+	if _, err := ds.DataStore().Put(ctx, subscriptionKey(id), &sub); err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// WebSubDeliverTaskEndpoint POSTs the current feed to a subscriber. Failed attempts are rescheduled with exponential backoff.
+func WebSubDeliverTaskEndpoint(c echo.Context) error {
+	var req deliverTask
+	if err := c.Bind(&req); err != nil {
+		// just report and return, resending will not change anything
+		platform.ReportError(err)
+		return c.NoContent(http.StatusOK)
+	}
+
+	ctx := appengine.NewContext(c.Request())
+
+	var sub a.Subscription
+	if err := ds.DataStore().Get(ctx, subscriptionKey(req.ID), &sub); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return c.NoContent(http.StatusOK) // unsubscribed in the meantime
+		}
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+
+	reader, err := ds.Storage().Bucket(a.BucketCDN).Object(fmt.Sprintf("%s/feed.xml", sub.GUID)).NewReader(ctx)
+	if err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+	defer reader.Close()
+	feed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		platform.ReportError(err)
+		return c.NoContent(http.StatusInternalServerError) // retried by the queue
+	}
+
+	status, err := deliverFeed(ctx, &sub, feed)
+	if status == http.StatusGone {
+		// the subscriber does not want any further deliveries
+		if err := ds.DataStore().Delete(ctx, subscriptionKey(sub.ID)); err != nil {
+			platform.ReportError(err)
+		}
+		return c.NoContent(http.StatusOK)
+	}
+	if err != nil {
+		req.Attempts++
+		if req.Attempts < maxHubAttempts {
+			if _, err := platform.CreateTaskAt(ctx, webSubDeliverTaskWithPrefix, &req, time.Now().Add(backoff(req.Attempts))); err != nil {
+				platform.ReportError(err)
+			}
+		} else {
+			platform.ReportError(err)
+		}
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// notifySubscribers schedules the delivery of the feed to all subscribers of a production
+func notifySubscribers(ctx context.Context, p *a.Production) error {
+	var subs []*a.Subscription
+	if _, err := ds.DataStore().GetAll(ctx, datastore.NewQuery(DatastoreSubscriptions).Filter("GUID =", p.GUID), &subs); err != nil {
+		return err
+	}
+
+	now := util.Timestamp()
+	for _, sub := range subs {
+		if sub.Expires < now {
+			if err := ds.DataStore().Delete(ctx, subscriptionKey(sub.ID)); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := platform.CreateTask(ctx, webSubDeliverTaskWithPrefix, &deliverTask{ID: sub.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyIntent asks the subscriber to confirm the request by echoing a random challenge
+func verifyIntent(ctx context.Context, req *verifyTask) error {
+	challenge := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("hub.mode", req.Mode)
+	q.Set("hub.topic", req.Topic)
+	q.Set("hub.challenge", hex.EncodeToString(challenge))
+	if req.Mode == HubModeSubscribe {
+		q.Set("hub.lease_seconds", fmt.Sprintf("%d", req.LeaseSeconds))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", appendQuery(req.Callback, q), nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("User-Agent", a.UserAgentString)
+
+	resp, err := webhookClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("can not verify '%s': %v", req.Callback, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || strings.TrimSpace(string(body)) != q.Get("hub.challenge") {
+		return fmt.Errorf("can not verify '%s': the subscriber did not confirm", req.Callback)
+	}
+	return nil
+}
+
+// deliverFeed POSTs the feed to a subscriber and returns the HTTP status
+func deliverFeed(ctx context.Context, sub *a.Subscription, feed []byte) (_ int, err error) {
+	ctx, span := startSpan(ctx, "deliverFeed", sub.GUID)
+	defer func() { endSpan(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.Callback, bytes.NewReader(feed))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/rss+xml")
+	req.Header.Set("User-Agent", a.UserAgentString)
+	req.Header.Set("Link", fmt.Sprintf("<%s>; rel=\"hub\", <%s>; rel=\"self\"", HubURL(), sub.Topic))
+	if sub.Secret != "" {
+		req.Header.Set(HubSignatureHeader, Sign(sub.Secret, feed))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("can not deliver to '%s': %v", sub.Callback, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("can not deliver to '%s': %s", sub.Callback, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// topicProduction returns the production of a feed URL, either its canonical location or its alias
+func topicProduction(ctx context.Context, topic string) (*a.Production, error) {
+	if path := strings.TrimPrefix(topic, a.DefaultCDNEndpoint+"/c/"); path != topic && strings.HasSuffix(path, "/feed.xml") {
+		return GetProduction(ctx, strings.TrimSuffix(path, "/feed.xml"))
+	}
+	if path := strings.TrimPrefix(topic, a.DefaultPortalEndpoint+"/s/"); path != topic && strings.HasSuffix(path, "/feed.xml") {
+		return FindProductionByName(ctx, strings.TrimSuffix(path, "/feed.xml"))
+	}
+	return nil, nil
+}
+
+// subscriptionID identifies a subscription by its topic and callback
+func subscriptionID(topic, callback string) string {
+	sum := sha256.Sum256([]byte(topic + " " + callback))
+	return hex.EncodeToString(sum[:16])
+}
+
+// appendQuery adds q to the query of u, keeping the query u might already have
+func appendQuery(u string, q url.Values) string {
+	if strings.Contains(u, "?") {
+		return u + "&" + q.Encode()
+	}
+	return u + "?" + q.Encode()
+}
+
+func subscriptionKey(id string) *datastore.Key {
+	return datastore.NameKey(DatastoreSubscriptions, id, nil)
+}
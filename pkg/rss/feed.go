@@ -126,6 +126,19 @@ func (p *Channel) AddAtomLink(href string) {
 	}
 }
 
+// AddHubLink advertises a WebSub hub that notifies subscribers about changes of the feed.
+//
+// Calling this method multiple times adds several hubs.
+func (p *Channel) AddHubLink(href string) {
+	if len(href) == 0 {
+		return
+	}
+	p.AtomHubs = append(p.AtomHubs, &AtomLink{
+		HREF: href,
+		Rel:  "hub",
+	})
+}
+
 // AddCategory adds the category to the podcast.
 //
 // ICategory can be listed multiple times.
@@ -350,7 +363,7 @@ func (p *Channel) Encode(w io.Writer) error {
 	}
 
 	atomLink := ""
-	if p.AtomLink != nil {
+	if p.AtomLink != nil || len(p.AtomHubs) > 0 {
 		atomLink = "http://www.w3.org/2005/Atom"
 	}
 	wrapped := channelWrapper{
@@ -0,0 +1,316 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// The parser supports RSS 2.0 with the iTunes and podcast namespaces:
+//
+//	https://cyber.harvard.edu/rss/rss.html
+//	https://help.apple.com/itc/podcasts_connect/#/itcb54353390
+//	https://github.com/Podcastindex-org/podcast-namespace
+//
+// Fields with a namespace must be declared before fields with the same local name
+// but without a namespace, as encoding/xml matches the first field that fits.
+
+type (
+	xmlRSS struct {
+		XMLName xml.Name   `xml:"rss"`
+		Channel xmlChannel `xml:"channel"`
+	}
+
+	xmlChannel struct {
+		ITitle       string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+		IAuthor      string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+		ISubtitle    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd subtitle"`
+		ISummary     string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+		IBlock       string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block"`
+		IImage       xmlHREF        `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		IExplicit    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+		IComplete    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd complete"`
+		INewFeedURL  string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd new-feed-url"`
+		IType        string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd type"`
+		IOwner       xmlOwner       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd owner"`
+		ICategories  []*xmlCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+		PodcastGUID  string         `xml:"https://podcastindex.org/namespace/1.0 guid"`
+		PodcastLock  string         `xml:"https://podcastindex.org/namespace/1.0 locked"`
+		AtomLinks    []xmlHREF      `xml:"http://www.w3.org/2005/Atom link"`
+		Title        string         `xml:"title"`
+		Link         string         `xml:"link"`
+		Description  string         `xml:"description"`
+		Category     string         `xml:"category"`
+		Copyright    string         `xml:"copyright"`
+		Generator    string         `xml:"generator"`
+		Language     string         `xml:"language"`
+		LastBuild    string         `xml:"lastBuildDate"`
+		Editor       string         `xml:"managingEditor"`
+		PubDate      string         `xml:"pubDate"`
+		TTL          int            `xml:"ttl"`
+		Image        xmlImage       `xml:"image"`
+		Items        []*xmlItem     `xml:"item"`
+		UnusedFields []xmlAny       `xml:",any"`
+	}
+
+	xmlItem struct {
+		ITitle       string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+		IAuthor      string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+		ISubtitle    string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd subtitle"`
+		ISummary     string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+		IImage       xmlHREF      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		IDuration    string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+		IExplicit    string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+		ISeason      string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+		IEpisode     string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+		IEpisodeType string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episodeType"`
+		IBlock       string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block"`
+		PSeason      string       `xml:"https://podcastindex.org/namespace/1.0 season"`
+		PEpisode     string       `xml:"https://podcastindex.org/namespace/1.0 episode"`
+		PTranscripts []xmlHREF    `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+		Content      string       `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		GUID         string       `xml:"guid"`
+		Title        string       `xml:"title"`
+		Link         string       `xml:"link"`
+		Description  string       `xml:"description"`
+		Author       string       `xml:"author"`
+		Category     string       `xml:"category"`
+		Comments     string       `xml:"comments"`
+		PubDate      string       `xml:"pubDate"`
+		Enclosure    xmlEnclosure `xml:"enclosure"`
+		UnusedFields []xmlAny     `xml:",any"`
+	}
+
+	xmlHREF struct {
+		HREF string `xml:"href,attr"`
+		URL  string `xml:"url,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	}
+
+	xmlImage struct {
+		URL   string `xml:"url"`
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+	}
+
+	xmlOwner struct {
+		Name  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd name"`
+		Email string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd email"`
+	}
+
+	xmlCategory struct {
+		Text        string         `xml:"text,attr"`
+		ICategories []*xmlCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+	}
+
+	xmlEnclosure struct {
+		URL    string `xml:"url,attr"`
+		Length string `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	}
+
+	// xmlAny swallows all elements we do not care about
+	xmlAny struct {
+		XMLName xml.Name
+	}
+)
+
+// Parse reads a RSS 2.0 podcast feed
+func Parse(r io.Reader) (*Channel, error) {
+	var doc xmlRSS
+
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = charsetReader
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("can not parse feed: %w", err)
+	}
+
+	ch := &doc.Channel
+	if ch.Title == "" && len(ch.Items) == 0 {
+		return nil, fmt.Errorf("can not parse feed: missing channel")
+	}
+
+	p := Channel{
+		Title:          strings.TrimSpace(ch.Title),
+		Link:           strings.TrimSpace(ch.Link),
+		Description:    strings.TrimSpace(ch.Description),
+		Category:       ch.Category,
+		Copyright:      ch.Copyright,
+		Generator:      ch.Generator,
+		Language:       ch.Language,
+		LastBuildDate:  ch.LastBuild,
+		ManagingEditor: ch.Editor,
+		PubDate:        ch.PubDate,
+		TTL:            ch.TTL,
+		IAuthor:        ch.IAuthor,
+		ISubtitle:      ch.ISubtitle,
+		IBlock:         ch.IBlock,
+		IExplicit:      ch.IExplicit,
+		IComplete:      ch.IComplete,
+		INewFeedURL:    strings.TrimSpace(ch.INewFeedURL),
+		ITitle:         ch.ITitle,
+		IType:          ch.IType,
+		PodcastGUID:    ch.PodcastGUID,
+		PodcastLocked:  strings.ToLower(strings.TrimSpace(ch.PodcastLock)),
+		encode:         encoder,
+	}
+	if ch.ISummary != "" {
+		p.ISummary = &ISummary{Text: ch.ISummary}
+	}
+	if ch.Image.URL != "" {
+		p.Image = &Image{URL: ch.Image.URL, Title: ch.Image.Title, Link: ch.Image.Link}
+	}
+	if ch.IImage.HREF != "" {
+		p.IImage = &IImage{HREF: ch.IImage.HREF}
+	}
+	if ch.IOwner.Name != "" || ch.IOwner.Email != "" {
+		p.IOwner = &Author{Name: ch.IOwner.Name, Email: ch.IOwner.Email}
+	}
+	for _, l := range ch.AtomLinks {
+		switch l.Rel {
+		case "self":
+			p.AtomLink = &AtomLink{HREF: l.HREF, Rel: l.Rel, Type: l.Type}
+		case "hub":
+			p.AtomHubs = append(p.AtomHubs, &AtomLink{HREF: l.HREF, Rel: l.Rel})
+		}
+	}
+	for _, c := range ch.ICategories {
+		icat := ICategory{Text: c.Text}
+		for _, sc := range c.ICategories {
+			icat.ICategories = append(icat.ICategories, &ICategory{Text: sc.Text})
+		}
+		p.ICategories = append(p.ICategories, &icat)
+	}
+
+	for _, i := range ch.Items {
+		p.Items = append(p.Items, parseItem(i))
+	}
+
+	return &p, nil
+}
+
+func parseItem(i *xmlItem) *Item {
+	item := Item{
+		GUID:             strings.TrimSpace(i.GUID),
+		Title:            strings.TrimSpace(i.Title),
+		Link:             strings.TrimSpace(i.Link),
+		Description:      strings.TrimSpace(i.Description),
+		AuthorFormatted:  i.Author,
+		Category:         i.Category,
+		Comments:         i.Comments,
+		PubDateFormatted: i.PubDate,
+		IAuthor:          i.IAuthor,
+		ISubtitle:        i.ISubtitle,
+		IDuration:        strings.TrimSpace(i.IDuration),
+		IExplicit:        i.IExplicit,
+		ISeason:          strings.TrimSpace(i.ISeason),
+		IEpisode:         strings.TrimSpace(i.IEpisode),
+		IEpisodeType:     i.IEpisodeType,
+		IBlock:           i.IBlock,
+		Content:          strings.TrimSpace(i.Content),
+	}
+	if item.Title == "" {
+		item.Title = i.ITitle
+	}
+	if item.ISeason == "" {
+		item.ISeason = strings.TrimSpace(i.PSeason)
+	}
+	if item.IEpisode == "" {
+		item.IEpisode = strings.TrimSpace(i.PEpisode)
+	}
+	if i.ISummary != "" {
+		item.ISummary = &ISummary{Text: i.ISummary}
+	}
+	if i.IImage.HREF != "" {
+		item.IImage = &IImage{HREF: i.IImage.HREF}
+	}
+	if len(i.PTranscripts) > 0 {
+		item.Transcript = i.PTranscripts[0].URL
+	}
+	if t, err := ParseDate(i.PubDate); err == nil {
+		item.PubDate = &t
+	}
+	if i.Enclosure.URL != "" {
+		length, _ := strconv.ParseInt(strings.TrimSpace(i.Enclosure.Length), 10, 64)
+		item.Enclosure = &Enclosure{
+			URL:             strings.TrimSpace(i.Enclosure.URL),
+			Length:          length,
+			LengthFormatted: i.Enclosure.Length,
+			Type:            ParseEnclosureType(i.Enclosure.Type),
+			TypeFormatted:   i.Enclosure.Type,
+		}
+	}
+	return &item
+}
+
+// ParseDate parses the different date formats found in the wild
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	formats := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+		"2 Jan 2006 15:04:05 MST",
+		time.RFC822Z,
+		time.RFC822,
+		time.RFC3339,
+	}
+	for _, f := range formats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse date '%s'", s)
+}
+
+// ParseDuration converts an itunes:duration value, i.e. 'HH:MM:SS', 'MM:SS' or seconds, into seconds
+func ParseDuration(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var d int64
+	for _, part := range strings.Split(s, ":") {
+		// some feeds use fractions of a second
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("can not parse duration '%s'", s)
+		}
+		d = d*60 + int64(n)
+	}
+	return d, nil
+}
+
+// ParseEnclosureType returns the EnclosureType of a MIME type
+func ParseEnclosureType(mimeType string) EnclosureType {
+	mt := strings.ToLower(strings.TrimSpace(mimeType))
+	for _, et := range []EnclosureType{M4A, M4V, MP4, MP3, MOV, PDF, EPUB} {
+		if et.String() == mt {
+			return et
+		}
+	}
+	return EnclosureType(0) // unknown, see EnclosureType.String()
+}
+
+// charsetReader converts the common single-byte charsets to UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return input, nil
+	case "iso-8859-1", "latin1":
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("unsupported charset '%s'", charset)
+}
@@ -0,0 +1,148 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:podcast="https://podcastindex.org/namespace/1.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Test Show</title>
+    <link>https://example.com</link>
+    <description>A test show</description>
+    <language>en-us</language>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <itunes:author>Jane Doe</itunes:author>
+    <itunes:explicit>no</itunes:explicit>
+    <itunes:type>serial</itunes:type>
+    <itunes:image href="https://example.com/cover.png"/>
+    <itunes:owner>
+      <itunes:name>Jane Doe</itunes:name>
+      <itunes:email>jane@example.com</itunes:email>
+    </itunes:owner>
+    <itunes:category text="Technology">
+      <itunes:category text="Podcasting"/>
+    </itunes:category>
+    <podcast:locked owner="jane@example.com">Yes</podcast:locked>
+    <podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>
+    <item>
+      <title>Episode 1</title>
+      <itunes:title>Episode One</itunes:title>
+      <description>The first episode</description>
+      <content:encoded><![CDATA[<p>The first episode</p>]]></content:encoded>
+      <guid isPermaLink="false">ep-0001</guid>
+      <pubDate>Tue, 2 Feb 2021 10:00:00 GMT</pubDate>
+      <enclosure url="https://example.com/ep1.mp3?x=1" length="1234" type="audio/mpeg"/>
+      <itunes:duration>1:02:03</itunes:duration>
+      <itunes:season>2</itunes:season>
+      <itunes:episode>1</itunes:episode>
+      <podcast:transcript url="https://example.com/ep1.vtt" type="text/vtt"/>
+    </item>
+  </channel>
+</rss>`
+
+func TestParse(t *testing.T) {
+	ch, err := Parse(strings.NewReader(testFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ch.Title != "Test Show" || ch.Description != "A test show" || ch.Language != "en-us" {
+		t.Errorf("unexpected channel attributes: '%s', '%s', '%s'", ch.Title, ch.Description, ch.Language)
+	}
+	if ch.IImage == nil || ch.IImage.HREF != "https://example.com/cover.png" {
+		t.Errorf("expected itunes:image")
+	}
+	if ch.IOwner == nil || ch.IOwner.Email != "jane@example.com" {
+		t.Errorf("expected itunes:owner")
+	}
+	if len(ch.ICategories) != 1 || len(ch.ICategories[0].ICategories) != 1 || ch.ICategories[0].ICategories[0].Text != "Podcasting" {
+		t.Errorf("expected itunes:category with a sub-category")
+	}
+	if ch.AtomLink == nil || ch.AtomLink.HREF != "https://example.com/feed.xml" {
+		t.Errorf("expected atom:link")
+	}
+	if ch.PodcastLocked != "yes" || ch.PodcastGUID == "" {
+		t.Errorf("expected podcast:locked and podcast:guid")
+	}
+
+	if len(ch.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(ch.Items))
+	}
+	item := ch.Items[0]
+	if item.Title != "Episode 1" || item.GUID != "ep-0001" {
+		t.Errorf("unexpected item attributes: '%s', '%s'", item.Title, item.GUID)
+	}
+	if item.Content != "<p>The first episode</p>" || item.Transcript != "https://example.com/ep1.vtt" {
+		t.Errorf("expected content:encoded and podcast:transcript")
+	}
+	if item.PubDate == nil || item.PubDate.Day() != 2 {
+		t.Errorf("expected a pubDate")
+	}
+	if item.Enclosure == nil || item.Enclosure.Length != 1234 || item.Enclosure.Type != MP3 {
+		t.Errorf("expected an enclosure")
+	}
+	if item.ISeason != "2" || item.IEpisode != "1" {
+		t.Errorf("expected season and episode")
+	}
+}
+
+func TestParseLatin1(t *testing.T) {
+	feed := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss version=\"2.0\"><channel><title>Caf\xe9 \xfcber alles</title></channel></rss>"
+	ch, err := Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.Title != "Café über alles" {
+		t.Errorf("expected 'Café über alles', got '%s'", ch.Title)
+	}
+
+	feed = "<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n<rss version=\"2.0\"><channel><title>5 \x80</title></channel></rss>"
+	if ch, err = Parse(strings.NewReader(feed)); err != nil {
+		t.Fatal(err)
+	}
+	if ch.Title != "5 €" {
+		t.Errorf("expected '5 €', got '%s'", ch.Title)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	durations := map[string]int64{
+		"1:02:03": 3723,
+		"02:03":   123,
+		"3723":    3723,
+		"61.5":    61,
+	}
+	for s, expected := range durations {
+		d, err := ParseDuration(s)
+		if err != nil {
+			t.Errorf("can not parse '%s': %v", s, err)
+		}
+		if d != expected {
+			t.Errorf("expected %d for '%s', got %d", expected, s, d)
+		}
+	}
+	if _, err := ParseDuration("1:xx"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestAtomLinks(t *testing.T) {
+	now := time.Now()
+	feed := New("Test Show", "https://example.com", "A test show", &now, &now)
+	feed.AddAtomLink("https://example.com/feed.xml")
+	feed.AddHubLink("https://example.com/hub")
+
+	ch, err := Parse(strings.NewReader(feed.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.AtomLink == nil || ch.AtomLink.HREF != "https://example.com/feed.xml" {
+		t.Errorf("expected atom:link rel=self")
+	}
+	if len(ch.AtomHubs) != 1 || ch.AtomHubs[0].HREF != "https://example.com/hub" {
+		t.Errorf("expected atom:link rel=hub")
+	}
+}
@@ -30,6 +30,7 @@ type (
 		Image          *Image
 		TextInput      *TextInput
 		AtomLink       *AtomLink
+		AtomHubs       []*AtomLink // WebSub hubs
 
 		// https://help.apple.com/itc/podcasts_connect/#/itcb54353390
 		IAuthor     string `xml:"itunes:author,omitempty"`
@@ -47,6 +48,10 @@ type (
 		ITitle string `xml:"itunes:title,omitempty"`
 		IType  string `xml:"itunes:type,omitempty"`
 
+		// https://github.com/Podcastindex-org/podcast-namespace, only used when parsing a feed
+		PodcastGUID   string `xml:"-"`
+		PodcastLocked string `xml:"-"`
+
 		Items []*Item
 
 		encode func(w io.Writer, o interface{}) error
@@ -106,6 +111,10 @@ type (
 		IEpisodeType string `xml:"itunes:episodeType,omitempty"`
 		IBlock       string `xml:"itunes:block,omitempty"`
 
+		// only used when parsing a feed
+		Content    string `xml:"-"` // content:encoded
+		Transcript string `xml:"-"` // podcast:transcript
+
 		// REMOVE IIsClosedCaptioned string `xml:"itunes:isClosedCaptioned,omitempty"`
 		// REMOVE IOrder string `xml:"itunes:order,omitempty"`
 	}
@@ -115,7 +124,7 @@ type (
 		XMLName xml.Name `xml:"atom:link"`
 		HREF    string   `xml:"href,attr"`
 		Rel     string   `xml:"rel,attr"`
-		Type    string   `xml:"type,attr"`
+		Type    string   `xml:"type,attr,omitempty"`
 	}
 
 	// Image represents an image.